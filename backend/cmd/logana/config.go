@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/config"
+)
+
+func runConfig(args []string) error {
+	if len(args) == 0 || args[0] != "validate" {
+		fmt.Fprintln(os.Stderr, "Usage: logana config validate [flags]")
+		return errors.New("unknown config command")
+	}
+
+	fs := flag.NewFlagSet("config validate", flag.ExitOnError)
+	configPath := fs.String("config", os.Getenv("LOGANA_CONFIG"), "config file to check, with the environment applied on top")
+	fs.Parse(args[1:])
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	if _, err := cfg.LoadRules(); err != nil {
+		return fmt.Errorf("invalid rules: %w", err)
+	}
+
+	source := *configPath
+	if source == "" {
+		source = "defaults and environment"
+	}
+	fmt.Printf("%s: configuration is valid\n", source)
+	return nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/config"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/importer"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/pipeline"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/redact"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/repository"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tenant"
+)
+
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	format := fs.String("format", "ndjson", "input format: ndjson or text")
+	pattern := fs.String("pattern", "", "regexp with named groups (timestamp, level, source, message, others go to metadata) for -format=text")
+	timeLayout := fs.String("time-layout", "2006-01-02T15:04:05Z07:00", "Go time layout of the timestamp group for -format=text")
+	source := fs.String("source", "", "source to use when a line has none")
+	level := fs.String("level", "INFO", "level to use when a line has none")
+	workers := fs.Int("workers", 4, "number of parallel bulk writers")
+	batchSize := fs.Int("batch", 500, "logs per bulk request")
+	checkpointPath := fs.String("checkpoint", "", "checkpoint file used to resume an interrupted import")
+	dryRun := fs.Bool("dry-run", false, "parse and validate input without writing anything")
+	configPath := fs.String("config", os.Getenv("LOGANA_CONFIG"), "backend config file for the Elasticsearch connection and default rules")
+	redactionConfig := fs.String("redaction", "", "redaction rules applied before indexing (default: the backend's)")
+	tenantID := fs.String("tenant", tenant.Default, "tenant whose index receives the logs")
+	pipelineConfig := fs.String("pipelines", "", "ingest pipeline config applied before indexing (default: the backend's)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: logana import [flags] FILE...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	files := fs.Args()
+	if len(files) == 0 {
+		fs.Usage()
+		return errors.New("no input files")
+	}
+
+	if !tenant.Valid(*tenantID) {
+		return fmt.Errorf("invalid tenant %q", *tenantID)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+	if *pipelineConfig == "" {
+		*pipelineConfig = cfg.Ingestion.Pipelines
+	}
+	if *redactionConfig == "" {
+		*redactionConfig = cfg.Ingestion.Redaction
+	}
+
+	defaults := importer.Defaults{Source: *source, Level: *level}
+	var parser importer.LineParser
+	switch *format {
+	case "ndjson":
+		parser = importer.NewNDJSONParser(defaults)
+	case "text":
+		if *pattern == "" {
+			return errors.New("-pattern is required for -format=text")
+		}
+		var err error
+		parser, err = importer.NewTextParser(*pattern, *timeLayout, defaults)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported format %q", *format)
+	}
+
+	var pipelines *pipeline.Set
+	if *pipelineConfig != "" {
+		var err error
+		pipelines, err = pipeline.LoadFile(*pipelineConfig)
+		if err != nil {
+			return err
+		}
+	}
+
+	var redactor *redact.Redactor
+	if *redactionConfig != "" {
+		var err error
+		redactor, err = redact.LoadFile(*redactionConfig, []byte(cfg.Ingestion.RedactionHMACKey))
+		if err != nil {
+			return err
+		}
+	}
+
+	checkpoint, err := importer.LoadCheckpoint(*checkpointPath)
+	if err != nil {
+		return err
+	}
+
+	var repo repository.LogRepository
+	if !*dryRun {
+		esConfig, err := config.NewElasticsearchClient(cfg.Elasticsearch)
+		if err != nil {
+			return fmt.Errorf("failed to create Elasticsearch client: %w", err)
+		}
+		repo = repository.NewLogRepository(esConfig)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx = tenant.WithTenant(ctx, *tenantID)
+
+	imp := importer.New(repo, importer.Options{
+		Parser:     parser,
+		Workers:    *workers,
+		BatchSize:  *batchSize,
+		DryRun:     *dryRun,
+		Checkpoint: checkpoint,
+		Pipelines:  pipelines,
+		Redactor:   redactor,
+	})
+	report, runErr := imp.Run(ctx, files)
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+
+	if runErr != nil && *checkpointPath != "" {
+		fmt.Fprintf(os.Stderr, "import stopped; rerun with -checkpoint=%s to resume\n", *checkpointPath)
+	}
+	return runErr
+}
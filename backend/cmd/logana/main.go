@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/joho/godotenv"
+)
+
+const usage = `Usage: logana <command> [flags]
+
+Commands:
+  import    Bulk import log files into Elasticsearch
+  config    Check the backend configuration ("logana config validate")
+
+Run "logana <command> -h" for command flags.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// Same environment as the server; a missing .env is not an error here.
+	_ = godotenv.Load()
+
+	var err error
+	switch os.Args[1] {
+	case "import":
+		err = runImport(os.Args[2:])
+	case "config":
+		err = runConfig(os.Args[2:])
+	case "-h", "--help", "help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "logana %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
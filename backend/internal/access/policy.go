@@ -0,0 +1,225 @@
+package access
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+type Action string
+
+const (
+	// ActionHide removes the field from responses.
+	ActionHide Action = "hide"
+	// ActionMask replaces the value with MaskedValue, so callers can tell
+	// the field exists without seeing it.
+	ActionMask Action = "mask"
+)
+
+const MaskedValue = "[REDACTED]"
+
+const metadataPrefix = "metadata."
+
+var coreFields = map[string]bool{
+	"message":   true,
+	"level":     true,
+	"source":    true,
+	"timestamp": true,
+}
+
+// Rule restricts Fields to principals holding one of AllowRoles. Fields are
+// core field names or "metadata.<key>". Admins are never restricted.
+type Rule struct {
+	Fields     []string `yaml:"fields"`
+	Action     Action   `yaml:"action"`
+	AllowRoles []string `yaml:"allow_roles"`
+}
+
+// Policy is the field-level access policy loaded from ACCESS_POLICY.
+type Policy struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+func LoadFile(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading access policy: %w", err)
+	}
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("error parsing access policy: %w", err)
+	}
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (p *Policy) validate() error {
+	for i, rule := range p.Rules {
+		switch rule.Action {
+		case "":
+			p.Rules[i].Action = ActionHide
+		case ActionHide, ActionMask:
+		default:
+			return fmt.Errorf("rule %d: unknown action %q", i, rule.Action)
+		}
+		if len(rule.Fields) == 0 {
+			return fmt.Errorf("rule %d: no fields", i)
+		}
+		for _, field := range rule.Fields {
+			if !coreFields[field] && !(strings.HasPrefix(field, metadataPrefix) && len(field) > len(metadataPrefix)) {
+				return fmt.Errorf("rule %d: unknown field %q", i, field)
+			}
+		}
+		for _, role := range rule.AllowRoles {
+			if !auth.ValidRole(role) {
+				return fmt.Errorf("rule %d: unknown role %q", i, role)
+			}
+		}
+	}
+	return nil
+}
+
+// ViewFor returns the restrictions that apply to p, or nil when the
+// principal may see everything. Requests without a principal come from
+// background jobs and are not restricted.
+func (pol *Policy) ViewFor(p *auth.Principal) *View {
+	if pol == nil || p == nil || p.HasScope(auth.ScopeAdmin) {
+		return nil
+	}
+
+	v := &View{fields: make(map[string]Action)}
+	for _, rule := range pol.Rules {
+		if allowed(p, rule.AllowRoles) {
+			continue
+		}
+		for _, field := range rule.Fields {
+			// Hiding wins over masking when rules overlap.
+			if v.fields[field] != ActionHide {
+				v.fields[field] = rule.Action
+			}
+		}
+	}
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return v
+}
+
+func allowed(p *auth.Principal, roles []string) bool {
+	for _, role := range roles {
+		if p.HasRole(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// View is the set of fields one principal may not see.
+type View struct {
+	fields map[string]Action
+}
+
+// RestrictedFields lists the fields the principal may not query.
+func (v *View) RestrictedFields() []string {
+	if v == nil {
+		return nil
+	}
+	fields := make([]string, 0, len(v.fields))
+	for field := range v.fields {
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// Apply hides or masks restricted fields of log in place. The metadata map is
+// copied before it is changed.
+func (v *View) Apply(log *models.Log) {
+	if v == nil {
+		return
+	}
+
+	copied := false
+	for field, action := range v.fields {
+		if key, ok := strings.CutPrefix(field, metadataPrefix); ok {
+			if _, present := log.Metadata[key]; !present {
+				continue
+			}
+			if !copied {
+				log.Metadata = copyMetadata(log.Metadata)
+				copied = true
+			}
+			if action == ActionMask {
+				log.Metadata[key] = MaskedValue
+			} else {
+				delete(log.Metadata, key)
+			}
+			continue
+		}
+
+		value := ""
+		if action == ActionMask {
+			value = MaskedValue
+		}
+		switch field {
+		case "message":
+			log.Message = value
+		case "level":
+			log.Level = value
+		case "source":
+			log.Source = value
+		case "timestamp":
+			log.Timestamp = time.Time{}
+		}
+	}
+}
+
+// Restore copies restricted fields from original into log, so an update from
+// a restricted principal cannot overwrite values it was never shown.
+func (v *View) Restore(log, original *models.Log) {
+	if v == nil || original == nil {
+		return
+	}
+
+	copied := false
+	for field := range v.fields {
+		if key, ok := strings.CutPrefix(field, metadataPrefix); ok {
+			if !copied {
+				log.Metadata = copyMetadata(log.Metadata)
+				copied = true
+			}
+			if value, present := original.Metadata[key]; present {
+				log.Metadata[key] = value
+			} else {
+				delete(log.Metadata, key)
+			}
+			continue
+		}
+
+		switch field {
+		case "message":
+			log.Message = original.Message
+		case "level":
+			log.Level = original.Level
+		case "source":
+			log.Source = original.Source
+		case "timestamp":
+			log.Timestamp = original.Timestamp
+		}
+	}
+}
+
+func copyMetadata(metadata map[string]string) map[string]string {
+	copied := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		copied[k] = v
+	}
+	return copied
+}
@@ -0,0 +1,75 @@
+package access
+
+import (
+	"testing"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func testPolicy(t *testing.T) *Policy {
+	t.Helper()
+
+	p := &Policy{Rules: []Rule{
+		{Fields: []string{"metadata.user_id"}, Action: ActionMask, AllowRoles: []string{auth.RoleSecurity}},
+		{Fields: []string{"metadata.ip_address"}, AllowRoles: []string{auth.RoleSecurity}},
+	}}
+	if err := p.validate(); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestViewMasksAndHidesMetadata(t *testing.T) {
+	view := testPolicy(t).ViewFor(&auth.Principal{Roles: []string{auth.RoleViewer}, Scopes: []string{auth.ScopeLogsRead}})
+	if view == nil {
+		t.Fatal("expected viewers to be restricted")
+	}
+
+	metadata := map[string]string{"user_id": "u-1", "ip_address": "10.0.0.1", "host": "web-1"}
+	log := models.Log{Message: "login", Metadata: metadata}
+	view.Apply(&log)
+
+	if log.Metadata["user_id"] != MaskedValue {
+		t.Errorf("expected user_id to be masked, got %q", log.Metadata["user_id"])
+	}
+	if _, ok := log.Metadata["ip_address"]; ok {
+		t.Error("expected ip_address to be hidden")
+	}
+	if log.Metadata["host"] != "web-1" {
+		t.Error("expected unrestricted metadata to be kept")
+	}
+	if metadata["user_id"] != "u-1" {
+		t.Error("expected the original metadata map to be left untouched")
+	}
+}
+
+func TestViewForAllowedPrincipals(t *testing.T) {
+	p := testPolicy(t)
+
+	if p.ViewFor(&auth.Principal{Roles: []string{auth.RoleSecurity}, Scopes: []string{auth.ScopeLogsRead}}) != nil {
+		t.Error("expected the security role to see everything")
+	}
+	if p.ViewFor(&auth.Principal{Scopes: []string{auth.ScopeAdmin}}) != nil {
+		t.Error("expected admins to see everything")
+	}
+}
+
+func TestRestorePreservesHiddenValues(t *testing.T) {
+	view := testPolicy(t).ViewFor(&auth.Principal{Scopes: []string{auth.ScopeLogsWrite}})
+
+	original := &models.Log{Metadata: map[string]string{"user_id": "u-1", "ip_address": "10.0.0.1"}}
+	update := &models.Log{Message: "edited", Metadata: map[string]string{"user_id": MaskedValue}}
+	view.Restore(update, original)
+
+	if update.Metadata["user_id"] != "u-1" || update.Metadata["ip_address"] != "10.0.0.1" {
+		t.Fatalf("expected restricted values to be restored, got %v", update.Metadata)
+	}
+}
+
+func TestValidateRejectsUnknownFields(t *testing.T) {
+	p := &Policy{Rules: []Rule{{Fields: []string{"user_id"}}}}
+	if err := p.validate(); err == nil {
+		t.Fatal("expected bare metadata keys to be rejected")
+	}
+}
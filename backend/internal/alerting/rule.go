@@ -0,0 +1,93 @@
+package alerting
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+var ErrInvalidRule = errors.New("invalid alert rule")
+
+const (
+	DefaultInterval = time.Minute
+	// MinInterval keeps a busy rule set from turning into a query storm.
+	MinInterval = 10 * time.Second
+	MaxWindow   = 7 * 24 * time.Hour
+)
+
+var operators = map[string]func(value, threshold float64) bool{
+	">":  func(v, t float64) bool { return v > t },
+	">=": func(v, t float64) bool { return v >= t },
+	"<":  func(v, t float64) bool { return v < t },
+	"<=": func(v, t float64) bool { return v <= t },
+	"==": func(v, t float64) bool { return v == t },
+	"!=": func(v, t float64) bool { return v != t },
+}
+
+// Validate checks rule and fills in its default interval.
+func Validate(rule *models.AlertRule) error {
+	if rule.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRule)
+	}
+	if _, ok := operators[rule.Condition.Operator]; !ok {
+		return fmt.Errorf("%w: unknown operator %q", ErrInvalidRule, rule.Condition.Operator)
+	}
+
+	window := time.Duration(rule.Window)
+	if window <= 0 || window > MaxWindow {
+		return fmt.Errorf("%w: window must be between 0 and %s", ErrInvalidRule, MaxWindow)
+	}
+	if rule.For < 0 {
+		return fmt.Errorf("%w: for must not be negative", ErrInvalidRule)
+	}
+
+	if rule.Interval == 0 {
+		rule.Interval = models.Duration(DefaultInterval)
+	}
+	if time.Duration(rule.Interval) < MinInterval {
+		return fmt.Errorf("%w: interval must be at least %s", ErrInvalidRule, MinInterval)
+	}
+	return nil
+}
+
+// Matches reports whether value satisfies the condition.
+func Matches(cond models.AlertCondition, value float64) bool {
+	op, ok := operators[cond.Operator]
+	return ok && op(value, cond.Threshold)
+}
+
+// Next returns the state of alert after an evaluation of rule saw value at
+// now. A matching condition makes the alert pending, and firing once it has
+// matched for the rule's For. A firing alert whose condition stops matching
+// is resolved; a pending one goes back to inactive.
+func Next(alert models.Alert, rule models.AlertRule, value float64, now time.Time) models.Alert {
+	alert.Value = value
+	alert.LastEvaluation = now
+	alert.LastError = ""
+
+	if !Matches(rule.Condition, value) {
+		switch alert.State {
+		case models.AlertFiring:
+			alert.State = models.AlertResolved
+			alert.ResolvedAt = &now
+		case models.AlertPending:
+			alert.State = models.AlertInactive
+		}
+		alert.ActiveSince = nil
+		return alert
+	}
+
+	if alert.State != models.AlertPending && alert.State != models.AlertFiring {
+		alert.State = models.AlertPending
+		alert.ActiveSince = &now
+		alert.FiredAt = nil
+		alert.ResolvedAt = nil
+	}
+	if alert.State == models.AlertPending && now.Sub(*alert.ActiveSince) >= time.Duration(rule.For) {
+		alert.State = models.AlertFiring
+		alert.FiredAt = &now
+	}
+	return alert
+}
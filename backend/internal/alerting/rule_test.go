@@ -0,0 +1,77 @@
+package alerting
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func TestValidate(t *testing.T) {
+	rule := models.AlertRule{
+		Name:      "database errors",
+		Condition: models.AlertCondition{Operator: ">", Threshold: 50},
+		Window:    models.Duration(5 * time.Minute),
+	}
+	if err := Validate(&rule); err != nil {
+		t.Fatal(err)
+	}
+	if time.Duration(rule.Interval) != DefaultInterval {
+		t.Fatalf("expected the default interval, got %s", time.Duration(rule.Interval))
+	}
+
+	for name, change := range map[string]func(*models.AlertRule){
+		"operator": func(r *models.AlertRule) { r.Condition.Operator = "~" },
+		"window":   func(r *models.AlertRule) { r.Window = 0 },
+		"interval": func(r *models.AlertRule) { r.Interval = models.Duration(time.Second) },
+	} {
+		invalid := rule
+		change(&invalid)
+		if err := Validate(&invalid); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("%s: expected ErrInvalidRule, got %v", name, err)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	rule := models.AlertRule{
+		Condition: models.AlertCondition{Operator: ">", Threshold: 50},
+		For:       models.Duration(2 * time.Minute),
+	}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	alert := models.Alert{State: models.AlertInactive}
+
+	steps := []struct {
+		after time.Duration
+		value float64
+		want  models.AlertState
+	}{
+		{0, 10, models.AlertInactive},
+		{time.Minute, 80, models.AlertPending},
+		{2 * time.Minute, 5, models.AlertInactive},
+		{3 * time.Minute, 80, models.AlertPending},
+		{4 * time.Minute, 90, models.AlertPending},
+		{5 * time.Minute, 90, models.AlertFiring},
+		{6 * time.Minute, 95, models.AlertFiring},
+		{7 * time.Minute, 20, models.AlertResolved},
+		{8 * time.Minute, 20, models.AlertResolved},
+	}
+	for i, step := range steps {
+		alert = Next(alert, rule, step.value, start.Add(step.after))
+		if alert.State != step.want {
+			t.Fatalf("step %d: expected %s, got %s", i, step.want, alert.State)
+		}
+	}
+	if alert.FiredAt == nil || !alert.FiredAt.Equal(start.Add(5*time.Minute)) {
+		t.Fatalf("unexpected fired_at %v", alert.FiredAt)
+	}
+}
+
+func TestNoLogsRule(t *testing.T) {
+	rule := models.AlertRule{Condition: models.AlertCondition{Operator: "==", Threshold: 0}}
+	alert := Next(models.Alert{State: models.AlertInactive}, rule, 0, time.Now())
+	if alert.State != models.AlertFiring {
+		t.Fatalf("expected a rule without for to fire at once, got %s", alert.State)
+	}
+}
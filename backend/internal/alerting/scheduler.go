@@ -0,0 +1,216 @@
+package alerting
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/repository"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tenant"
+)
+
+const (
+	// schedulerTick is how often the rule set is reloaded and due rules
+	// are evaluated, so rule changes apply within one tick.
+	schedulerTick = 10 * time.Second
+	// maxEvaluationTime bounds a single rule's query.
+	maxEvaluationTime = 30 * time.Second
+)
+
+// Scheduler evaluates every enabled rule on its interval and keeps the
+// resulting alert state in memory. Each backend instance evaluates all rules
+// on its own.
+type Scheduler struct {
+	rules repository.AlertRuleRepository
+	logs  repository.LogRepository
+
+	mu     sync.Mutex
+	alerts map[string]*models.Alert
+	// observer is told about every state change.
+	observer func(models.Alert)
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type Option func(*Scheduler)
+
+// WithObserver calls observe with the alert after each state change, for
+// instance to send notifications. A firing alert whose rule is deleted or
+// disabled is reported as resolved.
+func WithObserver(observe func(models.Alert)) Option {
+	return func(s *Scheduler) {
+		s.observer = observe
+	}
+}
+
+func NewScheduler(rules repository.AlertRuleRepository, logs repository.LogRepository, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		rules:    rules,
+		logs:     logs,
+		alerts:   make(map[string]*models.Alert),
+		observer: func(models.Alert) {},
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *Scheduler) Start() {
+	go s.loop()
+}
+
+// Close stops evaluating and waits for a running evaluation to finish.
+func (s *Scheduler) Close() {
+	close(s.stop)
+	<-s.done
+}
+
+// Alerts returns the state of tenantID's rules that have been evaluated,
+// ordered by rule name.
+func (s *Scheduler) Alerts(tenantID string) []models.Alert {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alerts := make([]models.Alert, 0, len(s.alerts))
+	for _, alert := range s.alerts {
+		if alert.Tenant == tenantID {
+			alerts = append(alerts, *alert)
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].RuleName < alerts[j].RuleName })
+	return alerts
+}
+
+// Alert returns the state of one rule.
+func (s *Scheduler) Alert(ruleID string) (models.Alert, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alert, ok := s.alerts[ruleID]
+	if !ok {
+		return models.Alert{}, false
+	}
+	return *alert, true
+}
+
+func (s *Scheduler) loop() {
+	defer close(s.done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.stop
+		cancel()
+	}()
+
+	ticker := time.NewTicker(schedulerTick)
+	defer ticker.Stop()
+
+	for {
+		s.evaluateDue(ctx, time.Now())
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// evaluateDue evaluates the rules whose interval has elapsed and forgets
+// the state of rules that were deleted or disabled.
+func (s *Scheduler) evaluateDue(ctx context.Context, now time.Time) {
+	rules, err := s.rules.List(ctx, "")
+	if err != nil {
+		log.Printf("Failed to load alert rules: %v", err)
+		return
+	}
+
+	active := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		if rule.Disabled {
+			continue
+		}
+		active[rule.ID] = true
+
+		s.mu.Lock()
+		alert, ok := s.alerts[rule.ID]
+		s.mu.Unlock()
+		if ok && now.Sub(alert.LastEvaluation) < time.Duration(rule.Interval) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		s.evaluate(ctx, rule, now)
+	}
+
+	var resolved []models.Alert
+	s.mu.Lock()
+	for id, alert := range s.alerts {
+		if active[id] {
+			continue
+		}
+		if alert.State == models.AlertFiring {
+			gone := *alert
+			gone.State = models.AlertResolved
+			gone.ResolvedAt = &now
+			resolved = append(resolved, gone)
+		}
+		delete(s.alerts, id)
+	}
+	s.mu.Unlock()
+
+	for _, alert := range resolved {
+		s.observer(alert)
+	}
+}
+
+func (s *Scheduler) evaluate(ctx context.Context, rule models.AlertRule, now time.Time) {
+	timeout := time.Duration(rule.Interval)
+	if timeout > maxEvaluationTime {
+		timeout = maxEvaluationTime
+	}
+	ctx, cancel := context.WithTimeout(tenant.WithTenant(ctx, rule.Tenant), timeout)
+	defer cancel()
+
+	count, err := s.logs.Count(ctx, models.LogFilter{
+		Query:  rule.Query,
+		Level:  rule.Level,
+		Source: rule.Source,
+		From:   now.Add(-time.Duration(rule.Window)),
+		To:     now,
+	})
+
+	s.mu.Lock()
+	prev, ok := s.alerts[rule.ID]
+	if !ok {
+		prev = &models.Alert{RuleID: rule.ID, State: models.AlertInactive}
+	}
+	alert := *prev
+	alert.RuleName, alert.Tenant, alert.Labels = rule.Name, rule.Tenant, rule.Labels
+
+	if err != nil {
+		// Keep the last state; a flapping Elasticsearch should neither
+		// fire nor resolve alerts.
+		alert.LastEvaluation = now
+		alert.LastError = err.Error()
+		log.Printf("Failed to evaluate alert rule %s (%s): %v", rule.Name, rule.ID, err)
+	} else {
+		alert = Next(alert, rule, float64(count), now)
+	}
+
+	s.alerts[rule.ID] = &alert
+	s.mu.Unlock()
+
+	if alert.State != prev.State {
+		log.Printf("Alert %s (%s) of tenant %s is %s: value %g", rule.Name, rule.ID, rule.Tenant, alert.State, alert.Value)
+		s.observer(alert)
+	}
+}
@@ -0,0 +1,86 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/repository"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tenant"
+)
+
+type fakeRules struct {
+	repository.AlertRuleRepository
+	rules []models.AlertRule
+}
+
+func (f *fakeRules) List(ctx context.Context, tenantID string) ([]models.AlertRule, error) {
+	return f.rules, nil
+}
+
+type fakeLogs struct {
+	repository.LogRepository
+	counts  map[string]int64
+	err     error
+	filters []models.LogFilter
+}
+
+func (f *fakeLogs) Count(ctx context.Context, filter models.LogFilter) (int64, error) {
+	f.filters = append(f.filters, filter)
+	return f.counts[tenant.FromContext(ctx)], f.err
+}
+
+func TestSchedulerEvaluatesDueRules(t *testing.T) {
+	rules := &fakeRules{rules: []models.AlertRule{{
+		ID:        "r1",
+		Tenant:    "payments",
+		Name:      "no auth logs",
+		Source:    "auth-service",
+		Condition: models.AlertCondition{Operator: "==", Threshold: 0},
+		Window:    models.Duration(10 * time.Minute),
+		Interval:  models.Duration(time.Minute),
+	}}}
+	logs := &fakeLogs{counts: map[string]int64{"default": 5}}
+	var observed []models.AlertState
+	s := NewScheduler(rules, logs, WithObserver(func(alert models.Alert) {
+		observed = append(observed, alert.State)
+	}))
+
+	now := time.Now()
+	s.evaluateDue(context.Background(), now)
+	alert, ok := s.Alert("r1")
+	if !ok || alert.State != models.AlertFiring {
+		t.Fatalf("expected the payments tenant's empty count to fire, got %+v", alert)
+	}
+	if f := logs.filters[0]; f.Source != "auth-service" || now.Sub(f.From) != 10*time.Minute {
+		t.Fatalf("unexpected filter %+v", f)
+	}
+
+	// Not due yet.
+	s.evaluateDue(context.Background(), now.Add(30*time.Second))
+	if len(logs.filters) != 1 {
+		t.Fatalf("expected one evaluation, got %d", len(logs.filters))
+	}
+
+	// Errors keep the state.
+	logs.err = errors.New("unavailable")
+	s.evaluateDue(context.Background(), now.Add(time.Minute))
+	if alert, _ := s.Alert("r1"); alert.State != models.AlertFiring || alert.LastError == "" {
+		t.Fatalf("expected a failed evaluation to keep firing, got %+v", alert)
+	}
+
+	if got := s.Alerts("default"); len(got) != 0 {
+		t.Fatalf("expected no alerts for another tenant, got %v", got)
+	}
+
+	rules.rules = nil
+	s.evaluateDue(context.Background(), now.Add(2*time.Minute))
+	if _, ok := s.Alert("r1"); ok {
+		t.Fatal("expected a deleted rule's state to be dropped")
+	}
+	if len(observed) != 2 || observed[0] != models.AlertFiring || observed[1] != models.AlertResolved {
+		t.Fatalf("expected firing then resolved for the deleted rule, got %v", observed)
+	}
+}
@@ -0,0 +1,337 @@
+// Package anomaly flags unusual log volume per source and level. Each
+// completed time bucket is compared with the same bucket in previous
+// seasons, such as the same five minutes on the previous days, so daily
+// traffic patterns do not count as anomalies.
+package anomaly
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/repository"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tenant"
+)
+
+const (
+	// AlertName is the rule name of anomaly alerts, matched as "alertname"
+	// by notification routes and silences.
+	AlertName = "LogVolumeAnomaly"
+
+	// detectorTick is how often the detector checks for a newly completed
+	// bucket.
+	detectorTick = 15 * time.Second
+	// maxEvaluationTime bounds one tenant's evaluation.
+	maxEvaluationTime = 30 * time.Second
+	// minBaseline is how many earlier buckets a series needs before it is
+	// scored.
+	minBaseline = 3
+)
+
+// Config mirrors the anomaly section of the configuration file.
+type Config struct {
+	Interval        time.Duration
+	Season          time.Duration
+	Periods         int
+	Delay           time.Duration
+	Threshold       float64
+	NotifyThreshold float64
+	MinVolume       float64
+	MaxSeries       int
+}
+
+// Score returns how many standard deviations value lies from the mean of
+// baseline. The deviation used is at least the square root of the mean,
+// the spread expected of a count, and at least 1, so a quiet series with
+// a flat baseline does not turn every extra log into an anomaly.
+func Score(value float64, baseline []float64) (score, mean, stddev float64) {
+	if len(baseline) == 0 {
+		return 0, 0, 0
+	}
+	for _, v := range baseline {
+		mean += v
+	}
+	mean /= float64(len(baseline))
+	for _, v := range baseline {
+		stddev += (v - mean) * (v - mean)
+	}
+	stddev = math.Sqrt(stddev / float64(len(baseline)))
+
+	spread := math.Max(stddev, math.Max(math.Sqrt(mean), 1))
+	return (value - mean) / spread, mean, stddev
+}
+
+// Detector evaluates every tenant's log volume once per completed bucket,
+// stores the anomalies it finds and keeps an alert per series whose score
+// crosses the notification threshold. Each backend instance evaluates on its
+// own; anomalies of the same bucket share an ID, so they are stored once.
+type Detector struct {
+	cfg       Config
+	logs      repository.LogRepository
+	anomalies repository.AnomalyRepository
+
+	tenants  func() []string
+	observer func(models.Alert)
+
+	mu        sync.Mutex
+	evaluated map[string]time.Time
+	// alerts holds the firing alerts by tenant and series.
+	alerts map[string]map[string]models.Alert
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type Option func(*Detector)
+
+// WithTenants sets the tenants to evaluate; by default only the default
+// tenant is.
+func WithTenants(tenants func() []string) Option {
+	return func(d *Detector) {
+		d.tenants = tenants
+	}
+}
+
+// WithObserver calls observe when a series starts or stops scoring above
+// the notification threshold, with a firing or resolved alert.
+func WithObserver(observe func(models.Alert)) Option {
+	return func(d *Detector) {
+		d.observer = observe
+	}
+}
+
+func NewDetector(cfg Config, logs repository.LogRepository, anomalies repository.AnomalyRepository, opts ...Option) *Detector {
+	d := &Detector{
+		cfg:       cfg,
+		logs:      logs,
+		anomalies: anomalies,
+		tenants:   func() []string { return []string{tenant.Default} },
+		observer:  func(models.Alert) {},
+		evaluated: make(map[string]time.Time),
+		alerts:    make(map[string]map[string]models.Alert),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+func (d *Detector) Start() {
+	go d.loop()
+}
+
+// Close stops evaluating and waits for a running evaluation to finish.
+func (d *Detector) Close() {
+	close(d.stop)
+	<-d.done
+}
+
+func (d *Detector) loop() {
+	defer close(d.done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-d.stop
+		cancel()
+	}()
+
+	ticker := time.NewTicker(detectorTick)
+	defer ticker.Stop()
+
+	for {
+		d.evaluateDue(ctx, time.Now())
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// evaluateDue evaluates the tenants whose last complete bucket has not been
+// evaluated yet.
+func (d *Detector) evaluateDue(ctx context.Context, now time.Time) {
+	bucket := d.lastBucket(now)
+	for _, id := range d.tenants() {
+		d.mu.Lock()
+		done := !d.evaluated[id].Before(bucket)
+		d.mu.Unlock()
+		if done {
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if err := d.evaluate(ctx, id, bucket, now); err != nil {
+			log.Printf("Failed to detect anomalies for tenant %s: %v", id, err)
+			continue
+		}
+		d.mu.Lock()
+		d.evaluated[id] = bucket
+		d.mu.Unlock()
+	}
+}
+
+// lastBucket returns the start of the last bucket that ended at least Delay
+// ago. Buckets are aligned to the Unix epoch.
+func (d *Detector) lastBucket(now time.Time) time.Time {
+	width := d.cfg.Interval.Milliseconds()
+	end := now.Add(-d.cfg.Delay).UnixMilli() / width * width
+	return time.UnixMilli(end).UTC().Add(-d.cfg.Interval)
+}
+
+type series struct {
+	source, level string
+}
+
+func (d *Detector) evaluate(ctx context.Context, tenantID string, bucket, now time.Time) error {
+	ctx, cancel := context.WithTimeout(tenant.WithTenant(ctx, tenantID), maxEvaluationTime)
+	defer cancel()
+
+	step := d.cfg.Season
+	if step == 0 {
+		step = d.cfg.Interval
+	}
+	// starts[0] is the bucket under evaluation, the rest its baseline.
+	starts := make([]time.Time, d.cfg.Periods+1)
+	for i := range starts {
+		starts[i] = bucket.Add(-time.Duration(i) * step)
+	}
+
+	buckets, err := d.logs.VolumeBuckets(ctx, starts, d.cfg.Interval, d.cfg.MaxSeries)
+	if err != nil {
+		return err
+	}
+	counts := make(map[series][]float64)
+	for _, b := range buckets {
+		s := series{b.Source, b.Level}
+		if counts[s] == nil {
+			counts[s] = make([]float64, len(starts))
+		}
+		for i, start := range starts {
+			if start.Equal(b.Start) {
+				counts[s][i] = float64(b.Count)
+			}
+		}
+	}
+
+	firing := make(map[string]models.Alert)
+	for s, values := range counts {
+		// Buckets before the series' first log would read as silence it
+		// never had, so they are left out of the baseline.
+		oldest := len(values) - 1
+		for oldest > 0 && values[oldest] == 0 {
+			oldest--
+		}
+		if oldest < minBaseline {
+			continue
+		}
+		value := values[0]
+		score, mean, stddev := Score(value, values[1:oldest+1])
+		if math.Max(value, mean) < d.cfg.MinVolume || math.Abs(score) < d.cfg.Threshold {
+			continue
+		}
+
+		anomaly := models.Anomaly{
+			ID:         anomalyID(tenantID, s, bucket),
+			Tenant:     tenantID,
+			Source:     s.source,
+			Level:      s.level,
+			Direction:  models.AnomalySpike,
+			Score:      math.Round(score*100) / 100,
+			Count:      int64(value),
+			Expected:   math.Round(mean*100) / 100,
+			StdDev:     math.Round(stddev*100) / 100,
+			Start:      bucket,
+			End:        bucket.Add(d.cfg.Interval),
+			DetectedAt: now,
+		}
+		if score < 0 {
+			anomaly.Direction = models.AnomalyDrop
+		}
+		if err := d.anomalies.Save(ctx, &anomaly); err != nil {
+			return err
+		}
+
+		if math.Abs(score) >= d.cfg.NotifyThreshold {
+			firing[alertID(s)] = d.alert(anomaly, now)
+		}
+	}
+
+	d.notify(tenantID, firing, now)
+	return nil
+}
+
+// notify reports series that started firing and resolves those that
+// stopped. Series that keep firing are not reported again.
+func (d *Detector) notify(tenantID string, firing map[string]models.Alert, now time.Time) {
+	var changed []models.Alert
+
+	d.mu.Lock()
+	prev := d.alerts[tenantID]
+	for id, alert := range firing {
+		if p, ok := prev[id]; ok {
+			alert.FiredAt = p.FiredAt
+			alert.ActiveSince = p.ActiveSince
+			firing[id] = alert
+			continue
+		}
+		changed = append(changed, alert)
+	}
+	for id, alert := range prev {
+		if _, ok := firing[id]; !ok {
+			alert.State = models.AlertResolved
+			alert.ResolvedAt = &now
+			alert.LastEvaluation = now
+			changed = append(changed, alert)
+		}
+	}
+	d.alerts[tenantID] = firing
+	d.mu.Unlock()
+
+	sort.Slice(changed, func(i, j int) bool { return changed[i].RuleID < changed[j].RuleID })
+	for _, alert := range changed {
+		log.Printf("Anomaly alert %s of tenant %s is %s: score %g", alert.RuleID, tenantID, alert.State, alert.Value)
+		d.observer(alert)
+	}
+}
+
+// alert describes a firing series for the notification dispatcher; its
+// value is the anomaly score.
+func (d *Detector) alert(anomaly models.Anomaly, now time.Time) models.Alert {
+	return models.Alert{
+		RuleID:   alertID(series{anomaly.Source, anomaly.Level}),
+		RuleName: AlertName,
+		Tenant:   anomaly.Tenant,
+		Labels: map[string]string{
+			"source":    anomaly.Source,
+			"level":     anomaly.Level,
+			"direction": anomaly.Direction,
+		},
+		State:          models.AlertFiring,
+		Value:          anomaly.Score,
+		ActiveSince:    &anomaly.Start,
+		FiredAt:        &now,
+		LastEvaluation: now,
+	}
+}
+
+func alertID(s series) string {
+	return fmt.Sprintf("anomaly/%s/%s", s.source, s.level)
+}
+
+// anomalyID is the same for every evaluation of a bucket.
+func anomalyID(tenantID string, s series, bucket time.Time) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s\x00%d", tenantID, s.source, s.level, bucket.UnixMilli())))
+	return hex.EncodeToString(sum[:])[:20]
+}
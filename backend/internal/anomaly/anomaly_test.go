@@ -0,0 +1,135 @@
+package anomaly
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/repository"
+)
+
+type fakeLogs struct {
+	repository.LogRepository
+	// counts maps a series to its counts for the evaluated bucket and each
+	// baseline bucket, newest first.
+	counts map[series][]int64
+	starts []time.Time
+}
+
+func (f *fakeLogs) VolumeBuckets(ctx context.Context, starts []time.Time, width time.Duration, maxSeries int) ([]models.VolumeBucket, error) {
+	f.starts = starts
+	var buckets []models.VolumeBucket
+	for s, counts := range f.counts {
+		for i, start := range starts {
+			buckets = append(buckets, models.VolumeBucket{Source: s.source, Level: s.level, Start: start, Count: counts[i]})
+		}
+	}
+	return buckets, nil
+}
+
+type fakeAnomalies struct {
+	repository.AnomalyRepository
+	saved []models.Anomaly
+}
+
+func (f *fakeAnomalies) Save(ctx context.Context, anomaly *models.Anomaly) error {
+	f.saved = append(f.saved, *anomaly)
+	return nil
+}
+
+var testConfig = Config{
+	Interval:        5 * time.Minute,
+	Season:          24 * time.Hour,
+	Periods:         7,
+	Delay:           time.Minute,
+	Threshold:       3,
+	NotifyThreshold: 5,
+	MinVolume:       10,
+	MaxSeries:       100,
+}
+
+func TestScore(t *testing.T) {
+	score, mean, _ := Score(60, []float64{10, 12, 11, 9, 10, 11, 10})
+	if mean < 10.4 || mean > 10.5 || score < 10 {
+		t.Fatalf("expected a large spike, got score %g mean %g", score, mean)
+	}
+
+	// A flat baseline of zeros uses a deviation of 1.
+	if score, _, _ := Score(2, []float64{0, 0, 0}); score != 2 {
+		t.Fatalf("expected score 2, got %g", score)
+	}
+	if score, _, _ := Score(0, []float64{100, 100, 100}); score != -10 {
+		t.Fatalf("expected a drop of 10 deviations, got %g", score)
+	}
+}
+
+func TestDetectorFlagsSpikesAndDrops(t *testing.T) {
+	database := series{"database", "ERROR"}
+	web := series{"web-server", "INFO"}
+	checkout := series{"checkout", "INFO"}
+	fresh := series{"new-service", "ERROR"}
+	logs := &fakeLogs{counts: map[series][]int64{
+		database: {60, 10, 12, 11, 9, 10, 11, 10},
+		web:      {1010, 1000, 990, 1020, 1005, 995, 1000, 1010},
+		checkout: {0, 400, 380, 410, 390, 0, 0, 0},
+		fresh:    {500, 0, 0, 0, 0, 0, 0, 0},
+	}}
+	anomalies := &fakeAnomalies{}
+	var observed []models.Alert
+	d := NewDetector(testConfig, logs, anomalies, WithObserver(func(alert models.Alert) {
+		observed = append(observed, alert)
+	}))
+
+	now := time.Date(2024, 5, 1, 12, 3, 0, 0, time.UTC)
+	d.evaluateDue(context.Background(), now)
+
+	bucket := time.Date(2024, 5, 1, 11, 55, 0, 0, time.UTC)
+	if !logs.starts[0].Equal(bucket) || !logs.starts[1].Equal(bucket.Add(-24*time.Hour)) {
+		t.Fatalf("unexpected buckets %v", logs.starts[:2])
+	}
+
+	found := make(map[string]models.Anomaly)
+	for _, a := range anomalies.saved {
+		found[a.Source] = a
+	}
+	if len(found) != 2 {
+		t.Fatalf("expected a spike and a drop, got %+v", anomalies.saved)
+	}
+	if a := found["database"]; a.Direction != models.AnomalySpike || a.Count != 60 || !a.Start.Equal(bucket) {
+		t.Fatalf("unexpected spike %+v", a)
+	}
+	if a := found["checkout"]; a.Direction != models.AnomalyDrop || math.Abs(a.Expected-395) > 0.01 {
+		t.Fatalf("unexpected drop %+v", a)
+	}
+	if len(observed) != 2 || observed[0].State != models.AlertFiring || observed[0].RuleName != AlertName {
+		t.Fatalf("expected two firing alerts, got %+v", observed)
+	}
+
+	// The same bucket is not evaluated twice.
+	d.evaluateDue(context.Background(), now.Add(30*time.Second))
+	if len(anomalies.saved) != 2 {
+		t.Fatalf("expected no new evaluation, got %+v", anomalies.saved)
+	}
+
+	logs.counts[database][0] = 11
+	logs.counts[checkout][0] = 395
+	observed = nil
+	d.evaluateDue(context.Background(), now.Add(5*time.Minute))
+	if len(observed) != 2 || observed[0].State != models.AlertResolved || observed[1].State != models.AlertResolved {
+		t.Fatalf("expected both alerts to resolve, got %+v", observed)
+	}
+}
+
+func TestDetectorWithoutSeasonComparesPrecedingBuckets(t *testing.T) {
+	cfg := testConfig
+	cfg.Season = 0
+	logs := &fakeLogs{counts: map[series][]int64{}}
+	d := NewDetector(cfg, logs, &fakeAnomalies{})
+
+	d.evaluateDue(context.Background(), time.Date(2024, 5, 1, 12, 3, 0, 0, time.UTC))
+	if got := logs.starts[0].Sub(logs.starts[1]); got != 5*time.Minute {
+		t.Fatalf("expected consecutive buckets, got a step of %s", got)
+	}
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"context"
+	"errors"
+)
+
+// Scopes granted to API keys and, through roles, to users.
+const (
+	ScopeLogsRead   = "logs:read"
+	ScopeLogsWrite  = "logs:write"
+	ScopeLogsDelete = "logs:delete"
+	// ScopeAdmin implies every other scope.
+	ScopeAdmin = "admin"
+)
+
+var knownScopes = map[string]bool{
+	ScopeLogsRead:   true,
+	ScopeLogsWrite:  true,
+	ScopeLogsDelete: true,
+	ScopeAdmin:      true,
+}
+
+func ValidScope(scope string) bool {
+	return knownScopes[scope]
+}
+
+var (
+	// ErrNotApplicable is returned by an Authenticator that does not
+	// recognise the credential format, so the next one is tried.
+	ErrNotApplicable = errors.New("credential not handled by this authenticator")
+	// ErrInvalidCredential means the credential was recognised but rejected.
+	ErrInvalidCredential = errors.New("invalid credential")
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Kind   string   `json:"kind"`
+	Tenant string   `json:"tenant"`
+	Roles  []string `json:"roles,omitempty"`
+	Scopes []string `json:"scopes"`
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	if p == nil {
+		return false
+	}
+	for _, s := range p.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Principal) HasRole(role string) bool {
+	if p == nil {
+		return false
+	}
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator verifies a bearer credential.
+type Authenticator interface {
+	Authenticate(ctx context.Context, credential string) (*Principal, error)
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the caller attached by the middleware, or nil
+// outside of an authenticated request.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
@@ -0,0 +1,283 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	jwksRefreshInterval = 5 * time.Minute
+	// jwksMinRefetch rate-limits refetches triggered by unknown key ids.
+	jwksMinRefetch   = 30 * time.Second
+	jwksFetchTimeout = 10 * time.Second
+)
+
+// ErrKeySetUnavailable means the signing keys could not be fetched. It is
+// deliberately not an ErrInvalidCredential: the token may be fine, so the
+// middleware answers 503 rather than 401.
+var ErrKeySetUnavailable = errors.New("signing keys are unavailable")
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// JWKS is a JSON Web Key Set loaded from a file or URL. URL-backed sets are
+// refreshed periodically and whenever a token names an unknown key id.
+type JWKS struct {
+	source string
+	isURL  bool
+	client *http.Client
+
+	mu          sync.RWMutex
+	keys        map[string]crypto.PublicKey
+	lastFetched time.Time
+
+	// fetching is the refresh in flight, shared by concurrent callers so a
+	// burst of tokens signed with a new key id fetches the set only once.
+	fetchMu  sync.Mutex
+	fetching *jwksFetch
+}
+
+type jwksFetch struct {
+	done chan struct{}
+	err  error
+}
+
+// NewJWKSFromFile loads a key set from disk once.
+func NewJWKSFromFile(path string) (*JWKS, error) {
+	j := &JWKS{source: path}
+	if err := j.refresh(context.Background()); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// NewJWKSFromURL fetches a key set from url, for example an identity
+// provider's jwks_uri or a local stand-in serving a static file.
+func NewJWKSFromURL(url string) (*JWKS, error) {
+	j := &JWKS{source: url, isURL: true, client: &http.Client{Timeout: jwksFetchTimeout}}
+	if err := j.refresh(context.Background()); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Key returns the public key with the given id. An empty kid is accepted
+// when the set holds exactly one key. A key that cannot be found because
+// the set could not be fetched yields ErrKeySetUnavailable.
+func (j *JWKS) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	var refreshErr error
+	if j.isURL {
+		j.mu.RLock()
+		stale := time.Since(j.lastFetched) > jwksRefreshInterval
+		j.mu.RUnlock()
+		if stale {
+			if refreshErr = j.sharedRefresh(ctx); refreshErr != nil {
+				log.Printf("Warning: failed to refresh JWKS: %v", refreshErr)
+			}
+		}
+	}
+
+	if key, ok := j.lookup(kid); ok {
+		return key, nil
+	}
+	if refreshErr != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeySetUnavailable, refreshErr)
+	}
+
+	if j.isURL {
+		j.mu.RLock()
+		canRefetch := time.Since(j.lastFetched) > jwksMinRefetch
+		j.mu.RUnlock()
+		if canRefetch {
+			if err := j.sharedRefresh(ctx); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrKeySetUnavailable, err)
+			}
+			if key, ok := j.lookup(kid); ok {
+				return key, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (j *JWKS) lookup(kid string) (crypto.PublicKey, bool) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	if kid == "" && len(j.keys) == 1 {
+		for _, key := range j.keys {
+			return key, true
+		}
+	}
+	key, ok := j.keys[kid]
+	return key, ok
+}
+
+// sharedRefresh refreshes the set, joining a refresh that is already in
+// flight instead of starting another one.
+func (j *JWKS) sharedRefresh(ctx context.Context) error {
+	j.fetchMu.Lock()
+	if call := j.fetching; call != nil {
+		j.fetchMu.Unlock()
+		select {
+		case <-call.done:
+			return call.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	call := &jwksFetch{done: make(chan struct{})}
+	j.fetching = call
+	j.fetchMu.Unlock()
+
+	// The fetch is shared, so one caller giving up must not fail it for
+	// the others; the client timeout still bounds it.
+	call.err = j.refresh(context.WithoutCancel(ctx))
+
+	j.fetchMu.Lock()
+	j.fetching = nil
+	j.fetchMu.Unlock()
+	close(call.done)
+	return call.err
+}
+
+func (j *JWKS) refresh(ctx context.Context) error {
+	data, err := j.read(ctx)
+	if err != nil {
+		return err
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("error parsing JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			log.Printf("Warning: skipping JWKS key %q: %v", k.Kid, err)
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return errors.New("JWKS contains no usable signing keys")
+	}
+
+	j.mu.Lock()
+	j.keys = keys
+	j.lastFetched = time.Now()
+	j.mu.Unlock()
+	return nil
+}
+
+func (j *JWKS) read(ctx context.Context) ([]byte, error) {
+	if !j.isURL {
+		data, err := os.ReadFile(j.source)
+		if err != nil {
+			return nil, fmt.Errorf("error reading JWKS: %w", err)
+		}
+		return data, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching JWKS: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching JWKS: %s", res.Status)
+	}
+	return io.ReadAll(io.LimitReader(res.Body, 1<<20))
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key parameter: %w", err)
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tenant"
+)
+
+// JWTConfig configures validation of bearer tokens issued by an OIDC
+// provider. RoleMapping maps claim values (groups, roles...) to logana roles;
+// when empty, claim values that already name a role are used as is.
+type JWTConfig struct {
+	Issuer      string
+	Audience    string
+	RoleClaim   string
+	RoleMapping map[string]string
+	// DefaultRole is granted to authenticated users without a mapped role.
+	DefaultRole string
+	// TenantClaim names the claim holding the user's tenant. Users without
+	// one belong to DefaultTenant.
+	TenantClaim   string
+	DefaultTenant string
+}
+
+type jwtAuthenticator struct {
+	jwks   *JWKS
+	cfg    JWTConfig
+	parser *jwt.Parser
+}
+
+func NewJWTAuthenticator(jwks *JWKS, cfg JWTConfig) (Authenticator, error) {
+	if cfg.RoleClaim == "" {
+		cfg.RoleClaim = "roles"
+	}
+	for claim, role := range cfg.RoleMapping {
+		if !ValidRole(role) {
+			return nil, fmt.Errorf("role mapping %s: unknown role %q", claim, role)
+		}
+	}
+	if cfg.DefaultRole != "" && !ValidRole(cfg.DefaultRole) {
+		return nil, fmt.Errorf("unknown default role %q", cfg.DefaultRole)
+	}
+	if cfg.TenantClaim == "" {
+		cfg.TenantClaim = "tenant"
+	}
+	if cfg.DefaultTenant == "" {
+		cfg.DefaultTenant = tenant.Default
+	}
+	if !tenant.Valid(cfg.DefaultTenant) {
+		return nil, fmt.Errorf("invalid default tenant %q", cfg.DefaultTenant)
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30e9),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+
+	return &jwtAuthenticator{jwks: jwks, cfg: cfg, parser: jwt.NewParser(opts...)}, nil
+}
+
+func (a *jwtAuthenticator) Authenticate(ctx context.Context, credential string) (*Principal, error) {
+	if strings.Count(credential, ".") != 2 || !strings.HasPrefix(credential, "eyJ") {
+		return nil, ErrNotApplicable
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := a.parser.ParseWithClaims(credential, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return a.jwks.Key(ctx, kid)
+	})
+	if errors.Is(err, ErrKeySetUnavailable) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredential, err)
+	}
+
+	tenantID := a.cfg.DefaultTenant
+	if values := claimValues(claims, a.cfg.TenantClaim); len(values) > 0 {
+		tenantID = values[0]
+	}
+	if !tenant.Valid(tenantID) {
+		return nil, fmt.Errorf("%w: invalid tenant %q", ErrInvalidCredential, tenantID)
+	}
+
+	subject, _ := claims.GetSubject()
+	roles := a.roles(claims)
+	return &Principal{
+		ID:     subject,
+		Name:   displayName(claims, subject),
+		Kind:   "user",
+		Tenant: tenantID,
+		Roles:  roles,
+		Scopes: ScopesForRoles(roles),
+	}, nil
+}
+
+func (a *jwtAuthenticator) roles(claims jwt.MapClaims) []string {
+	seen := make(map[string]bool)
+	var roles []string
+	add := func(role string) {
+		if ValidRole(role) && !seen[role] {
+			seen[role] = true
+			roles = append(roles, role)
+		}
+	}
+
+	for _, value := range claimValues(claims, a.cfg.RoleClaim) {
+		if len(a.cfg.RoleMapping) == 0 {
+			add(value)
+			continue
+		}
+		if role, ok := a.cfg.RoleMapping[value]; ok {
+			add(role)
+		}
+	}
+	if len(roles) == 0 && a.cfg.DefaultRole != "" {
+		add(a.cfg.DefaultRole)
+	}
+	return roles
+}
+
+// claimValues resolves a dotted claim path such as "realm_access.roles" and
+// returns its values. Strings are split on whitespace, like OAuth "scope".
+func claimValues(claims map[string]interface{}, path string) []string {
+	var current interface{} = claims
+	for _, part := range strings.Split(path, ".") {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = obj[part]
+	}
+
+	switch v := current.(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+func displayName(claims jwt.MapClaims, fallback string) string {
+	for _, key := range []string{"preferred_username", "email", "name"} {
+		if v, ok := claims[key].(string); ok && v != "" {
+			return v
+		}
+	}
+	return fallback
+}
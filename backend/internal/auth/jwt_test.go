@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestKey(t *testing.T) (*rsa.PrivateKey, []byte) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	}
+	data, _ := json.Marshal(set)
+	return key, data
+}
+
+func newTestJWKS(t *testing.T) (*rsa.PrivateKey, *JWKS) {
+	t.Helper()
+
+	key, data := newTestKey(t)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	jwks, err := NewJWKSFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, jwks
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test"
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestJWTAuthenticatorMapsRoles(t *testing.T) {
+	key, jwks := newTestJWKS(t)
+	a, err := NewJWTAuthenticator(jwks, JWTConfig{
+		Issuer:      "https://idp.example.com",
+		Audience:    "logana",
+		RoleClaim:   "realm_access.groups",
+		RoleMapping: map[string]string{"ops": RoleEditor},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := signToken(t, key, jwt.MapClaims{
+		"iss":                "https://idp.example.com",
+		"aud":                "logana",
+		"sub":                "u-1",
+		"preferred_username": "alice",
+		"tenant":             "payments",
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"realm_access":       map[string]interface{}{"groups": []string{"ops", "unrelated"}},
+	})
+
+	p, err := a.Authenticate(context.Background(), token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "alice" || p.Tenant != "payments" || len(p.Roles) != 1 || p.Roles[0] != RoleEditor {
+		t.Fatalf("unexpected principal %+v", p)
+	}
+	if !p.HasScope(ScopeLogsWrite) || p.HasScope(ScopeLogsDelete) {
+		t.Fatalf("unexpected scopes %v", p.Scopes)
+	}
+}
+
+func TestJWTAuthenticatorRejectsInvalidTokens(t *testing.T) {
+	key, jwks := newTestJWKS(t)
+	a, err := NewJWTAuthenticator(jwks, JWTConfig{Audience: "logana"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]jwt.MapClaims{
+		"expired":        {"aud": "logana", "exp": time.Now().Add(-time.Hour).Unix()},
+		"wrong audience": {"aud": "other", "exp": time.Now().Add(time.Hour).Unix()},
+		"no expiry":      {"aud": "logana"},
+	}
+	for name, claims := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := a.Authenticate(context.Background(), signToken(t, key, claims))
+			if !errors.Is(err, ErrInvalidCredential) {
+				t.Fatalf("expected ErrInvalidCredential, got %v", err)
+			}
+		})
+	}
+
+	if _, err := a.Authenticate(context.Background(), "lgn_abc_def"); !errors.Is(err, ErrNotApplicable) {
+		t.Fatalf("expected ErrNotApplicable for API keys, got %v", err)
+	}
+}
+
+func TestJWTAuthenticatorKeySetUnavailable(t *testing.T) {
+	key, data := newTestKey(t)
+	var down atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "idp is down", http.StatusBadGateway)
+			return
+		}
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	jwks, err := NewJWKSFromURL(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := NewJWTAuthenticator(jwks, JWTConfig{Audience: "logana"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A token signed with a key id the set has not seen forces a refetch.
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"aud": "logana", "exp": time.Now().Add(time.Hour).Unix()})
+	token.Header["kid"] = "rotated"
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	down.Store(true)
+	jwks.lastFetched = time.Now().Add(-jwksRefreshInterval - time.Second)
+	_, err = a.Authenticate(context.Background(), signed)
+	if !errors.Is(err, ErrKeySetUnavailable) || errors.Is(err, ErrInvalidCredential) {
+		t.Fatalf("expected ErrKeySetUnavailable, got %v", err)
+	}
+
+	// Once the set is reachable, an unknown key id is a bad credential.
+	down.Store(false)
+	jwks.lastFetched = time.Now().Add(-jwksMinRefetch - time.Second)
+	if _, err := a.Authenticate(context.Background(), signed); !errors.Is(err, ErrInvalidCredential) {
+		t.Fatalf("expected ErrInvalidCredential, got %v", err)
+	}
+}
+
+func TestJWKSSharesConcurrentRefreshes(t *testing.T) {
+	_, data := newTestKey(t)
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	jwks, err := NewJWKSFromURL(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetches.Store(0)
+	jwks.lastFetched = time.Now().Add(-jwksMinRefetch - time.Second)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			jwks.Key(context.Background(), "unknown")
+		}()
+	}
+	wg.Wait()
+
+	if n := fetches.Load(); n != 1 {
+		t.Fatalf("expected one shared fetch, got %d", n)
+	}
+}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tenant"
+)
+
+// Anonymous is the principal used for every request when authentication is
+// disabled. It holds the admin scope so scope checks are no-ops.
+var Anonymous = &Principal{ID: "anonymous", Name: "anonymous", Kind: "anonymous", Tenant: tenant.Default, Scopes: []string{ScopeAdmin}}
+
+// Middleware authenticates the request's bearer token (or X-API-Key header)
+// with the first authenticator that recognises it. Rejected credentials get
+// 401; any other authenticator error gets 503. With no authenticators, every
+// request runs as Anonymous.
+func Middleware(authenticators ...Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(authenticators) == 0 {
+			setPrincipal(c, Anonymous)
+			return
+		}
+
+		credential := credentialFromRequest(c.Request)
+		if credential == "" {
+			unauthorized(c, "missing credentials")
+			return
+		}
+
+		for _, a := range authenticators {
+			p, err := a.Authenticate(c.Request.Context(), credential)
+			if errors.Is(err, ErrNotApplicable) {
+				continue
+			}
+			if errors.Is(err, ErrInvalidCredential) {
+				unauthorized(c, err.Error())
+				return
+			}
+			if err != nil {
+				// A failing key store says nothing about the credential;
+				// keep its details out of the response.
+				log.Printf("Authentication failed: %v", err)
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "authentication is temporarily unavailable"})
+				return
+			}
+			setPrincipal(c, p)
+			return
+		}
+
+		unauthorized(c, ErrInvalidCredential.Error())
+	}
+}
+
+// Require aborts with 403 unless the caller holds scope.
+func Require(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !PrincipalFromContext(c.Request.Context()).HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "missing scope " + scope})
+			return
+		}
+		c.Next()
+	}
+}
+
+func setPrincipal(c *gin.Context, p *Principal) {
+	ctx := WithPrincipal(c.Request.Context(), p)
+	c.Request = c.Request.WithContext(tenant.WithTenant(ctx, p.Tenant))
+	c.Next()
+}
+
+func unauthorized(c *gin.Context, reason string) {
+	c.Header("WWW-Authenticate", `Bearer realm="logana"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": reason})
+}
+
+func credentialFromRequest(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	header := r.Header.Get("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
+
+// staticKey authenticates a single pre-shared admin key, used to bootstrap
+// the first stored API keys.
+type staticKey struct {
+	key string
+}
+
+func NewStaticKey(key string) Authenticator {
+	return &staticKey{key: key}
+}
+
+func (s *staticKey) Authenticate(ctx context.Context, credential string) (*Principal, error) {
+	if subtle.ConstantTimeCompare([]byte(credential), []byte(s.key)) != 1 {
+		return nil, ErrNotApplicable
+	}
+	return &Principal{ID: "bootstrap", Name: "bootstrap admin key", Kind: "static_key", Tenant: tenant.Default, Scopes: []string{ScopeAdmin}}, nil
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type authenticatorFunc func(ctx context.Context, credential string) (*Principal, error)
+
+func (f authenticatorFunc) Authenticate(ctx context.Context, credential string) (*Principal, error) {
+	return f(ctx, credential)
+}
+
+func TestMiddlewareStatuses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware(authenticatorFunc(func(_ context.Context, credential string) (*Principal, error) {
+		switch credential {
+		case "good":
+			return &Principal{ID: "k", Scopes: []string{ScopeLogsRead}}, nil
+		case "down":
+			return nil, errors.New("elasticsearch: connection refused to 10.0.0.5:9200")
+		default:
+			return nil, ErrInvalidCredential
+		}
+	})))
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		credential string
+		want       int
+	}{
+		{"", http.StatusUnauthorized},
+		{"bad", http.StatusUnauthorized},
+		{"down", http.StatusServiceUnavailable},
+		{"good", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.credential != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.credential)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%q: expected %d, got %d", tt.credential, tt.want, w.Code)
+		}
+		if strings.Contains(w.Body.String(), "10.0.0.5") {
+			t.Errorf("%q: backend details leaked: %s", tt.credential, w.Body)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package auth
+
+// Roles granted to human users from token claims. Each maps to a fixed set
+// of scopes so routes only ever check scopes.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+	// RoleSecurity reads logs like a viewer; field access policies use it
+	// to reveal sensitive metadata to security engineers.
+	RoleSecurity = "security"
+)
+
+var roleScopes = map[string][]string{
+	RoleViewer: {ScopeLogsRead},
+	RoleEditor: {ScopeLogsRead, ScopeLogsWrite},
+	RoleAdmin:  {ScopeAdmin},
+
+	RoleSecurity: {ScopeLogsRead},
+}
+
+func ValidRole(role string) bool {
+	_, ok := roleScopes[role]
+	return ok
+}
+
+// ScopesForRoles returns the union of the scopes granted by roles.
+func ScopesForRoles(roles []string) []string {
+	seen := make(map[string]bool)
+	var scopes []string
+	for _, role := range roles {
+		for _, scope := range roleScopes[role] {
+			if !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	return scopes
+}
@@ -0,0 +1,829 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/guardrail"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/patterns"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/querycache"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tenant"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tracing"
+)
+
+// Config is the backend configuration. It is read from the file named by
+// -config or LOGANA_CONFIG, if any, and environment variables override
+// individual settings on top of the file.
+type Config struct {
+	Server        ServerSettings        `yaml:"server" toml:"server"`
+	Elasticsearch ElasticsearchSettings `yaml:"elasticsearch" toml:"elasticsearch"`
+	Ingestion     IngestionSettings     `yaml:"ingestion" toml:"ingestion"`
+	Auth          AuthSettings          `yaml:"auth" toml:"auth"`
+	Retention     RetentionSettings     `yaml:"retention" toml:"retention"`
+	Alerting      AlertingSettings      `yaml:"alerting" toml:"alerting"`
+	Search        SearchSettings        `yaml:"search" toml:"search"`
+	Patterns      PatternSettings       `yaml:"patterns" toml:"patterns"`
+	Anomaly       AnomalySettings       `yaml:"anomaly" toml:"anomaly"`
+	Health        HealthSettings        `yaml:"health" toml:"health"`
+	Tracing       TracingSettings       `yaml:"tracing" toml:"tracing"`
+}
+
+type ServerSettings struct {
+	Port int `yaml:"port" toml:"port"`
+	// CORSAllowedOrigins may contain "*" to allow any origin.
+	CORSAllowedOrigins []string `yaml:"cors_allowed_origins" toml:"cors_allowed_origins"`
+	ReadHeaderTimeout  Duration `yaml:"read_header_timeout" toml:"read_header_timeout"`
+	IdleTimeout        Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	// ShutdownReadinessDelay is how long readiness fails before the server
+	// stops accepting connections.
+	ShutdownReadinessDelay Duration `yaml:"shutdown_readiness_delay" toml:"shutdown_readiness_delay"`
+	ShutdownTimeout        Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+}
+
+type ElasticsearchSettings struct {
+	Addresses []string `yaml:"addresses" toml:"addresses"`
+	// Username and Password, or APIKey, authenticate to the cluster.
+	Username string `yaml:"username" toml:"username"`
+	Password string `yaml:"password" toml:"password"`
+	APIKey   string `yaml:"api_key" toml:"api_key"`
+	// CACert, ClientCert and ClientKey are paths to PEM files.
+	CACert     string `yaml:"ca_cert" toml:"ca_cert"`
+	ClientCert string `yaml:"client_cert" toml:"client_cert"`
+	ClientKey  string `yaml:"client_key" toml:"client_key"`
+	Insecure   bool   `yaml:"insecure" toml:"insecure"`
+
+	Index       string `yaml:"index" toml:"index"`
+	APIKeyIndex string `yaml:"api_key_index" toml:"api_key_index"`
+	AuditIndex  string `yaml:"audit_index" toml:"audit_index"`
+	// AlertRuleIndex holds the alerting rules.
+	AlertRuleIndex string `yaml:"alert_rule_index" toml:"alert_rule_index"`
+	SilenceIndex   string `yaml:"silence_index" toml:"silence_index"`
+	// SavedSearchIndex holds saved searches and their permalinks.
+	SavedSearchIndex string `yaml:"saved_search_index" toml:"saved_search_index"`
+	// PatternIndex holds the message patterns mined from ingested logs.
+	PatternIndex string `yaml:"pattern_index" toml:"pattern_index"`
+	AnomalyIndex string `yaml:"anomaly_index" toml:"anomaly_index"`
+
+	// RequestTimeout bounds the wait for response headers.
+	RequestTimeout      Duration `yaml:"request_timeout" toml:"request_timeout"`
+	MaxIdleConnsPerHost int      `yaml:"max_idle_conns_per_host" toml:"max_idle_conns_per_host"`
+	MaxRetries          int      `yaml:"max_retries" toml:"max_retries"`
+	RetryOnStatus       []int    `yaml:"retry_on_status" toml:"retry_on_status"`
+}
+
+// IngestionSettings point at the rule files applied to incoming logs.
+type IngestionSettings struct {
+	Pipelines        string `yaml:"pipelines" toml:"pipelines"`
+	Multiline        string `yaml:"multiline" toml:"multiline"`
+	Redaction        string `yaml:"redaction" toml:"redaction"`
+	RedactionHMACKey string `yaml:"redaction_hmac_key" toml:"redaction_hmac_key"`
+	Tenants          string `yaml:"tenants" toml:"tenants"`
+	LogMetrics       string `yaml:"log_metrics" toml:"log_metrics"`
+}
+
+type AuthSettings struct {
+	Enabled      bool        `yaml:"enabled" toml:"enabled"`
+	AdminAPIKey  string      `yaml:"admin_api_key" toml:"admin_api_key"`
+	AccessPolicy string      `yaml:"access_policy" toml:"access_policy"`
+	JWT          JWTSettings `yaml:"jwt" toml:"jwt"`
+}
+
+type JWTSettings struct {
+	JWKSURL       string            `yaml:"jwks_url" toml:"jwks_url"`
+	JWKSFile      string            `yaml:"jwks_file" toml:"jwks_file"`
+	Issuer        string            `yaml:"issuer" toml:"issuer"`
+	Audience      string            `yaml:"audience" toml:"audience"`
+	RoleClaim     string            `yaml:"role_claim" toml:"role_claim"`
+	RoleMapping   map[string]string `yaml:"role_mapping" toml:"role_mapping"`
+	DefaultRole   string            `yaml:"default_role" toml:"default_role"`
+	TenantClaim   string            `yaml:"tenant_claim" toml:"tenant_claim"`
+	DefaultTenant string            `yaml:"default_tenant" toml:"default_tenant"`
+}
+
+// RetentionSettings schedule the deletion of logs past their tenant's
+// retention, which is set per tenant in the tenant quotas.
+type RetentionSettings struct {
+	Interval Duration `yaml:"interval" toml:"interval"`
+	Timeout  Duration `yaml:"timeout" toml:"timeout"`
+}
+
+// AlertingSettings point at the notification channels file.
+type AlertingSettings struct {
+	Notifications string `yaml:"notifications" toml:"notifications"`
+}
+
+type SearchSettings struct {
+	// ContextStreamKeys are the metadata keys that, with the source,
+	// identify the stream a log's context is taken from.
+	ContextStreamKeys []string          `yaml:"context_stream_keys" toml:"context_stream_keys"`
+	Cache             CacheSettings     `yaml:"cache" toml:"cache"`
+	Guardrails        GuardrailSettings `yaml:"guardrails" toml:"guardrails"`
+}
+
+// CacheSettings configure the in-memory cache of search and aggregation
+// results. Policy "lru" bounds it by MaxEntries results, "size" by
+// MaxMemoryMB.
+type CacheSettings struct {
+	Enabled     bool   `yaml:"enabled" toml:"enabled"`
+	Policy      string `yaml:"policy" toml:"policy"`
+	MaxEntries  int    `yaml:"max_entries" toml:"max_entries"`
+	MaxMemoryMB int    `yaml:"max_memory_mb" toml:"max_memory_mb"`
+	// TTL applies to time ranges that ended, LiveTTL to ranges relative to
+	// now or reaching the present.
+	TTL     Duration `yaml:"ttl" toml:"ttl"`
+	LiveTTL Duration `yaml:"live_ttl" toml:"live_ttl"`
+}
+
+// Cache returns the cache configuration.
+func (c CacheSettings) Cache() querycache.Config {
+	return querycache.Config{
+		Policy:     c.Policy,
+		MaxEntries: c.MaxEntries,
+		MaxBytes:   int64(c.MaxMemoryMB) << 20,
+		TTL:        c.TTL.Duration,
+		LiveTTL:    c.LiveTTL.Duration,
+	}
+}
+
+// GuardrailSettings bound the cost of searches. Zero values disable the
+// limit they set; roles missing from MaxRange may search any range.
+type GuardrailSettings struct {
+	MaxPageSize int                 `yaml:"max_page_size" toml:"max_page_size"`
+	MaxRange    map[string]Duration `yaml:"max_range" toml:"max_range"`
+	// DefaultMaxRange applies to principals without roles, such as API
+	// keys.
+	DefaultMaxRange Duration `yaml:"default_max_range" toml:"default_max_range"`
+	// Wildcards is "reject" or "rewrite", which drops leading wildcards
+	// and leading ".*" from regular expressions.
+	Wildcards      string   `yaml:"wildcards" toml:"wildcards"`
+	MaxRegexLength int      `yaml:"max_regex_length" toml:"max_regex_length"`
+	Timeout        Duration `yaml:"timeout" toml:"timeout"`
+	TerminateAfter int      `yaml:"terminate_after" toml:"terminate_after"`
+	MaxConcurrent  int      `yaml:"max_concurrent_queries" toml:"max_concurrent_queries"`
+}
+
+// Guardrails returns the guardrail configuration.
+func (g GuardrailSettings) Guardrails() guardrail.Config {
+	maxRange := make(map[string]time.Duration, len(g.MaxRange))
+	for role, d := range g.MaxRange {
+		maxRange[role] = d.Duration
+	}
+	return guardrail.Config{
+		MaxPageSize:     g.MaxPageSize,
+		MaxRange:        maxRange,
+		DefaultMaxRange: g.DefaultMaxRange.Duration,
+		Wildcards:       g.Wildcards,
+		MaxRegexLength:  g.MaxRegexLength,
+		Timeout:         g.Timeout.Duration,
+		TerminateAfter:  g.TerminateAfter,
+		MaxConcurrent:   g.MaxConcurrent,
+	}
+}
+
+// PatternSettings tune the mining of message patterns from ingested logs.
+// Zero values select the miner's defaults.
+type PatternSettings struct {
+	Enabled     bool    `yaml:"enabled" toml:"enabled"`
+	Depth       int     `yaml:"depth" toml:"depth"`
+	Similarity  float64 `yaml:"similarity" toml:"similarity"`
+	MaxChildren int     `yaml:"max_children" toml:"max_children"`
+	MaxPatterns int     `yaml:"max_patterns" toml:"max_patterns"`
+}
+
+// Miner returns the miner configuration.
+func (p PatternSettings) Miner() patterns.Config {
+	return patterns.Config{
+		Depth:       p.Depth,
+		Similarity:  p.Similarity,
+		MaxChildren: p.MaxChildren,
+		MaxPatterns: p.MaxPatterns,
+	}
+}
+
+// AnomalySettings configure the detection of unusual log volume per source
+// and level. Every Interval, the count of the last complete bucket is
+// compared with the same bucket one Season earlier, for Periods seasons;
+// with no season, with the Periods buckets before it.
+type AnomalySettings struct {
+	Enabled  bool     `yaml:"enabled" toml:"enabled"`
+	Interval Duration `yaml:"interval" toml:"interval"`
+	Season   Duration `yaml:"season" toml:"season"`
+	Periods  int      `yaml:"periods" toml:"periods"`
+	// Delay leaves time for late logs before a bucket is evaluated.
+	Delay Duration `yaml:"delay" toml:"delay"`
+	// Threshold is the score, in standard deviations, from which a bucket
+	// is recorded as an anomaly; NotifyThreshold the one from which an
+	// alert fires.
+	Threshold       float64 `yaml:"threshold" toml:"threshold"`
+	NotifyThreshold float64 `yaml:"notify_threshold" toml:"notify_threshold"`
+	// MinVolume ignores series whose count and baseline are both below it.
+	MinVolume float64 `yaml:"min_volume" toml:"min_volume"`
+	MaxSeries int     `yaml:"max_series" toml:"max_series"`
+}
+
+// HealthSettings decide how component checks roll up into readiness.
+type HealthSettings struct {
+	// CriticalChecks are the components whose failure takes the backend
+	// down instead of degrading it.
+	CriticalChecks []string `yaml:"critical_checks" toml:"critical_checks"`
+	FailOnDegraded bool     `yaml:"fail_on_degraded" toml:"fail_on_degraded"`
+	// QueueCapacity is the ingest queue depth considered full.
+	QueueCapacity int `yaml:"queue_capacity" toml:"queue_capacity"`
+}
+
+// healthChecks are the components the backend checks.
+var healthChecks = map[string]bool{"elasticsearch": true, "log_index": true, "ingest_queue": true}
+
+// TracingSettings select the span exporter. The OTLP exporter reads its
+// endpoint and headers from the standard OTEL_EXPORTER_OTLP_* variables.
+type TracingSettings struct {
+	// Exporter is "otlp", "stdout" or "none".
+	Exporter    string `yaml:"exporter" toml:"exporter"`
+	ServiceName string `yaml:"service_name" toml:"service_name"`
+	// Debug attaches Elasticsearch query bodies to spans as events.
+	Debug bool `yaml:"debug" toml:"debug"`
+}
+
+// Tracing returns the tracing configuration.
+func (t TracingSettings) Tracing() tracing.Config {
+	return tracing.Config{Exporter: t.Exporter, ServiceName: t.ServiceName, Debug: t.Debug}
+}
+
+// Duration is a time.Duration written as "30s" or "1h".
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// Default returns the configuration used when nothing is set.
+func Default() *Config {
+	return &Config{
+		Server: ServerSettings{
+			Port:                   8080,
+			CORSAllowedOrigins:     []string{"http://localhost:3000"},
+			ReadHeaderTimeout:      Duration{10 * time.Second},
+			IdleTimeout:            Duration{2 * time.Minute},
+			ShutdownReadinessDelay: Duration{5 * time.Second},
+			ShutdownTimeout:        Duration{30 * time.Second},
+		},
+		Elasticsearch: ElasticsearchSettings{
+			Addresses:           []string{defaultElasticsearchURL},
+			Index:               defaultIndexName,
+			APIKeyIndex:         defaultAPIKeyIndexName,
+			AuditIndex:          defaultAuditIndexName,
+			AlertRuleIndex:      defaultAlertRuleIndexName,
+			SilenceIndex:        defaultSilenceIndexName,
+			SavedSearchIndex:    defaultSavedSearchIndexName,
+			PatternIndex:        defaultPatternIndexName,
+			AnomalyIndex:        defaultAnomalyIndexName,
+			RequestTimeout:      Duration{10 * time.Second},
+			MaxIdleConnsPerHost: 10,
+			MaxRetries:          3,
+			RetryOnStatus:       []int{502, 503, 504},
+		},
+		Auth: AuthSettings{
+			Enabled: true,
+			JWT: JWTSettings{
+				RoleClaim:     "roles",
+				TenantClaim:   "tenant",
+				DefaultTenant: tenant.Default,
+			},
+		},
+		Retention: RetentionSettings{
+			Interval: Duration{time.Hour},
+			Timeout:  Duration{10 * time.Minute},
+		},
+		Search: SearchSettings{
+			ContextStreamKeys: []string{"host", "instance_id"},
+			Cache: CacheSettings{
+				Enabled:     true,
+				Policy:      querycache.PolicyLRU,
+				MaxEntries:  1000,
+				MaxMemoryMB: 64,
+				TTL:         Duration{5 * time.Minute},
+				LiveTTL:     Duration{10 * time.Second},
+			},
+			Guardrails: GuardrailSettings{
+				MaxPageSize:    1000,
+				Wildcards:      guardrail.WildcardsReject,
+				MaxRegexLength: 100,
+				Timeout:        Duration{5 * time.Second},
+				MaxConcurrent:  4,
+			},
+		},
+		Patterns: PatternSettings{
+			Enabled: true,
+		},
+		Anomaly: AnomalySettings{
+			Enabled:         true,
+			Interval:        Duration{5 * time.Minute},
+			Season:          Duration{24 * time.Hour},
+			Periods:         7,
+			Delay:           Duration{time.Minute},
+			Threshold:       3,
+			NotifyThreshold: 5,
+			MinVolume:       10,
+			MaxSeries:       100,
+		},
+		Health: HealthSettings{
+			CriticalChecks: []string{"elasticsearch", "log_index"},
+			QueueCapacity:  10000,
+		},
+		Tracing: TracingSettings{
+			Exporter:    "none",
+			ServiceName: "logana-backend",
+		},
+	}
+}
+
+// Load reads the file at path, which may be empty, over the defaults and
+// applies environment overrides. The result still needs Validate.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	if path != "" {
+		if err := cfg.readFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// readFile decodes YAML or TOML by extension. Unknown keys are rejected so
+// a misspelt setting does not silently keep its default.
+func (c *Config) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yml", ".yaml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("error parsing config file %s: %w", path, err)
+		}
+	case ".toml":
+		dec := toml.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(c); err != nil {
+			var strict *toml.StrictMissingError
+			if errors.As(err, &strict) {
+				return fmt.Errorf("error parsing config file %s: %s", path, strict.String())
+			}
+			return fmt.Errorf("error parsing config file %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("unsupported config file extension %q, want .yml, .yaml or .toml", ext)
+	}
+	return nil
+}
+
+type envOverride struct {
+	name  string
+	apply func(c *Config, value string) error
+}
+
+// envOverrides keeps the environment variables the backend always read
+// working on top of the config file.
+var envOverrides = []envOverride{
+	{"PORT", intVar(func(c *Config) *int { return &c.Server.Port })},
+	{"CORS_ALLOWED_ORIGINS", listVar(func(c *Config) *[]string { return &c.Server.CORSAllowedOrigins })},
+	{"SHUTDOWN_READINESS_DELAY", durationVar(func(c *Config) *Duration { return &c.Server.ShutdownReadinessDelay })},
+	{"SHUTDOWN_TIMEOUT", durationVar(func(c *Config) *Duration { return &c.Server.ShutdownTimeout })},
+
+	{"ELASTICSEARCH_URL", listVar(func(c *Config) *[]string { return &c.Elasticsearch.Addresses })},
+	{"ELASTICSEARCH_USERNAME", stringVar(func(c *Config) *string { return &c.Elasticsearch.Username })},
+	{"ELASTICSEARCH_PASSWORD", stringVar(func(c *Config) *string { return &c.Elasticsearch.Password })},
+	{"ELASTICSEARCH_API_KEY", stringVar(func(c *Config) *string { return &c.Elasticsearch.APIKey })},
+	{"ELASTICSEARCH_CA_CERT", stringVar(func(c *Config) *string { return &c.Elasticsearch.CACert })},
+	{"ELASTICSEARCH_CLIENT_CERT", stringVar(func(c *Config) *string { return &c.Elasticsearch.ClientCert })},
+	{"ELASTICSEARCH_CLIENT_KEY", stringVar(func(c *Config) *string { return &c.Elasticsearch.ClientKey })},
+	{"ELASTICSEARCH_INSECURE", boolVar(func(c *Config) *bool { return &c.Elasticsearch.Insecure })},
+	{"ELASTICSEARCH_INDEX", stringVar(func(c *Config) *string { return &c.Elasticsearch.Index })},
+	{"ELASTICSEARCH_API_KEY_INDEX", stringVar(func(c *Config) *string { return &c.Elasticsearch.APIKeyIndex })},
+	{"ELASTICSEARCH_AUDIT_INDEX", stringVar(func(c *Config) *string { return &c.Elasticsearch.AuditIndex })},
+	{"ELASTICSEARCH_ALERT_RULE_INDEX", stringVar(func(c *Config) *string { return &c.Elasticsearch.AlertRuleIndex })},
+	{"ELASTICSEARCH_SILENCE_INDEX", stringVar(func(c *Config) *string { return &c.Elasticsearch.SilenceIndex })},
+	{"ELASTICSEARCH_SAVED_SEARCH_INDEX", stringVar(func(c *Config) *string { return &c.Elasticsearch.SavedSearchIndex })},
+	{"ELASTICSEARCH_PATTERN_INDEX", stringVar(func(c *Config) *string { return &c.Elasticsearch.PatternIndex })},
+	{"ELASTICSEARCH_ANOMALY_INDEX", stringVar(func(c *Config) *string { return &c.Elasticsearch.AnomalyIndex })},
+	{"ELASTICSEARCH_TIMEOUT", durationVar(func(c *Config) *Duration { return &c.Elasticsearch.RequestTimeout })},
+	{"ELASTICSEARCH_MAX_RETRIES", intVar(func(c *Config) *int { return &c.Elasticsearch.MaxRetries })},
+
+	{"PIPELINE_CONFIG", stringVar(func(c *Config) *string { return &c.Ingestion.Pipelines })},
+	{"MULTILINE_CONFIG", stringVar(func(c *Config) *string { return &c.Ingestion.Multiline })},
+	{"REDACTION_CONFIG", stringVar(func(c *Config) *string { return &c.Ingestion.Redaction })},
+	{"REDACTION_HMAC_KEY", stringVar(func(c *Config) *string { return &c.Ingestion.RedactionHMACKey })},
+	{"TENANT_CONFIG", stringVar(func(c *Config) *string { return &c.Ingestion.Tenants })},
+	{"LOG_METRICS_CONFIG", stringVar(func(c *Config) *string { return &c.Ingestion.LogMetrics })},
+
+	{"AUTH_ENABLED", boolVar(func(c *Config) *bool { return &c.Auth.Enabled })},
+	{"ADMIN_API_KEY", stringVar(func(c *Config) *string { return &c.Auth.AdminAPIKey })},
+	{"ACCESS_POLICY", stringVar(func(c *Config) *string { return &c.Auth.AccessPolicy })},
+	{"JWT_JWKS_URL", stringVar(func(c *Config) *string { return &c.Auth.JWT.JWKSURL })},
+	{"JWT_JWKS_FILE", stringVar(func(c *Config) *string { return &c.Auth.JWT.JWKSFile })},
+	{"JWT_ISSUER", stringVar(func(c *Config) *string { return &c.Auth.JWT.Issuer })},
+	{"JWT_AUDIENCE", stringVar(func(c *Config) *string { return &c.Auth.JWT.Audience })},
+	{"JWT_ROLE_CLAIM", stringVar(func(c *Config) *string { return &c.Auth.JWT.RoleClaim })},
+	{"JWT_ROLE_MAPPING", mapVar(func(c *Config) *map[string]string { return &c.Auth.JWT.RoleMapping })},
+	{"JWT_DEFAULT_ROLE", stringVar(func(c *Config) *string { return &c.Auth.JWT.DefaultRole })},
+	{"JWT_TENANT_CLAIM", stringVar(func(c *Config) *string { return &c.Auth.JWT.TenantClaim })},
+	{"JWT_DEFAULT_TENANT", stringVar(func(c *Config) *string { return &c.Auth.JWT.DefaultTenant })},
+
+	{"RETENTION_INTERVAL", durationVar(func(c *Config) *Duration { return &c.Retention.Interval })},
+	{"RETENTION_TIMEOUT", durationVar(func(c *Config) *Duration { return &c.Retention.Timeout })},
+	{"NOTIFICATION_CONFIG", stringVar(func(c *Config) *string { return &c.Alerting.Notifications })},
+	{"LOG_CONTEXT_KEYS", listVar(func(c *Config) *[]string { return &c.Search.ContextStreamKeys })},
+	{"QUERY_CACHE_ENABLED", boolVar(func(c *Config) *bool { return &c.Search.Cache.Enabled })},
+	{"QUERY_CACHE_POLICY", stringVar(func(c *Config) *string { return &c.Search.Cache.Policy })},
+	{"QUERY_CACHE_TTL", durationVar(func(c *Config) *Duration { return &c.Search.Cache.TTL })},
+	{"QUERY_CACHE_LIVE_TTL", durationVar(func(c *Config) *Duration { return &c.Search.Cache.LiveTTL })},
+	{"QUERY_MAX_PAGE_SIZE", intVar(func(c *Config) *int { return &c.Search.Guardrails.MaxPageSize })},
+	{"QUERY_DEFAULT_MAX_RANGE", durationVar(func(c *Config) *Duration { return &c.Search.Guardrails.DefaultMaxRange })},
+	{"QUERY_WILDCARDS", stringVar(func(c *Config) *string { return &c.Search.Guardrails.Wildcards })},
+	{"QUERY_TIMEOUT", durationVar(func(c *Config) *Duration { return &c.Search.Guardrails.Timeout })},
+	{"QUERY_TERMINATE_AFTER", intVar(func(c *Config) *int { return &c.Search.Guardrails.TerminateAfter })},
+	{"QUERY_MAX_CONCURRENT", intVar(func(c *Config) *int { return &c.Search.Guardrails.MaxConcurrent })},
+	{"PATTERNS_ENABLED", boolVar(func(c *Config) *bool { return &c.Patterns.Enabled })},
+	{"PATTERN_SIMILARITY", floatVar(func(c *Config) *float64 { return &c.Patterns.Similarity })},
+	{"ANOMALY_DETECTION_ENABLED", boolVar(func(c *Config) *bool { return &c.Anomaly.Enabled })},
+	{"ANOMALY_THRESHOLD", floatVar(func(c *Config) *float64 { return &c.Anomaly.Threshold })},
+	{"ANOMALY_NOTIFY_THRESHOLD", floatVar(func(c *Config) *float64 { return &c.Anomaly.NotifyThreshold })},
+	{"HEALTH_CRITICAL_CHECKS", listVar(func(c *Config) *[]string { return &c.Health.CriticalChecks })},
+	{"HEALTH_FAIL_ON_DEGRADED", boolVar(func(c *Config) *bool { return &c.Health.FailOnDegraded })},
+	{"HEALTH_QUEUE_CAPACITY", intVar(func(c *Config) *int { return &c.Health.QueueCapacity })},
+	{"OTEL_TRACES_EXPORTER", stringVar(func(c *Config) *string { return &c.Tracing.Exporter })},
+	{"OTEL_SERVICE_NAME", stringVar(func(c *Config) *string { return &c.Tracing.ServiceName })},
+	{"TRACING_DEBUG", boolVar(func(c *Config) *bool { return &c.Tracing.Debug })},
+}
+
+func (c *Config) applyEnv() error {
+	for _, o := range envOverrides {
+		value, ok := os.LookupEnv(o.name)
+		if !ok || value == "" {
+			continue
+		}
+		if err := o.apply(c, value); err != nil {
+			return fmt.Errorf("invalid %s: %w", o.name, err)
+		}
+	}
+	return nil
+}
+
+func stringVar(field func(*Config) *string) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		*field(c) = value
+		return nil
+	}
+}
+
+func intVar(field func(*Config) *int) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func boolVar(field func(*Config) *bool) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+func floatVar(field func(*Config) *float64) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		*field(c) = f
+		return nil
+	}
+}
+
+func durationVar(field func(*Config) *Duration) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		return field(c).UnmarshalText([]byte(value))
+	}
+}
+
+// listVar reads a comma separated list.
+func listVar(field func(*Config) *[]string) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		*field(c) = list
+		return nil
+	}
+}
+
+// mapVar reads comma separated key=value pairs.
+func mapVar(field func(*Config) *map[string]string) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		m := make(map[string]string)
+		for _, pair := range strings.Split(value, ",") {
+			key, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok {
+				return fmt.Errorf("expected key=value, got %q", pair)
+			}
+			m[strings.TrimSpace(key)] = strings.TrimSpace(val)
+		}
+		*field(c) = m
+		return nil
+	}
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	s := c.Server
+	if s.Port < 1 || s.Port > 65535 {
+		fail("server.port: %d is not a valid port", s.Port)
+	}
+	if len(s.CORSAllowedOrigins) == 0 {
+		fail("server.cors_allowed_origins: at least one origin is required")
+	}
+	for _, d := range []struct {
+		name  string
+		value Duration
+	}{
+		{"server.read_header_timeout", s.ReadHeaderTimeout},
+		{"server.idle_timeout", s.IdleTimeout},
+		{"server.shutdown_readiness_delay", s.ShutdownReadinessDelay},
+		{"elasticsearch.request_timeout", c.Elasticsearch.RequestTimeout},
+	} {
+		if d.value.Duration < 0 {
+			fail("%s: must not be negative", d.name)
+		}
+	}
+	if s.ShutdownTimeout.Duration <= 0 {
+		fail("server.shutdown_timeout: must be positive")
+	}
+
+	es := c.Elasticsearch
+	if len(es.Addresses) == 0 {
+		fail("elasticsearch.addresses: at least one address is required")
+	}
+	for _, addr := range es.Addresses {
+		u, err := url.Parse(addr)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			fail("elasticsearch.addresses: %q is not an http or https URL", addr)
+		}
+	}
+	if es.APIKey != "" && es.Username != "" {
+		fail("elasticsearch: set either api_key or username and password, not both")
+	}
+	if es.Password != "" && es.Username == "" {
+		fail("elasticsearch.password: requires username")
+	}
+	if (es.ClientCert == "") != (es.ClientKey == "") {
+		fail("elasticsearch: client_cert and client_key must be set together")
+	}
+	if _, err := tlsConfig(es); err != nil {
+		errs = append(errs, fmt.Errorf("elasticsearch: %w", err))
+	}
+	for _, index := range []struct{ name, value string }{
+		{"elasticsearch.index", es.Index},
+		{"elasticsearch.api_key_index", es.APIKeyIndex},
+		{"elasticsearch.audit_index", es.AuditIndex},
+		{"elasticsearch.alert_rule_index", es.AlertRuleIndex},
+		{"elasticsearch.silence_index", es.SilenceIndex},
+		{"elasticsearch.saved_search_index", es.SavedSearchIndex},
+		{"elasticsearch.pattern_index", es.PatternIndex},
+		{"elasticsearch.anomaly_index", es.AnomalyIndex},
+	} {
+		if !validIndexName(index.value) {
+			fail("%s: %q is not a valid index name", index.name, index.value)
+		}
+	}
+	if es.MaxIdleConnsPerHost < 1 {
+		fail("elasticsearch.max_idle_conns_per_host: must be at least 1")
+	}
+	if es.MaxRetries < 0 {
+		fail("elasticsearch.max_retries: must not be negative")
+	}
+	for _, status := range es.RetryOnStatus {
+		if status < 100 || status > 599 {
+			fail("elasticsearch.retry_on_status: %d is not an HTTP status", status)
+		}
+	}
+
+	// The rule files themselves are checked by LoadRules.
+	for _, file := range []struct{ name, path string }{
+		{"ingestion.pipelines", c.Ingestion.Pipelines},
+		{"ingestion.multiline", c.Ingestion.Multiline},
+		{"ingestion.redaction", c.Ingestion.Redaction},
+		{"ingestion.tenants", c.Ingestion.Tenants},
+		{"ingestion.log_metrics", c.Ingestion.LogMetrics},
+		{"auth.access_policy", c.Auth.AccessPolicy},
+		{"alerting.notifications", c.Alerting.Notifications},
+		{"auth.jwt.jwks_file", c.Auth.JWT.JWKSFile},
+	} {
+		if file.path == "" {
+			continue
+		}
+		if _, err := os.Stat(file.path); err != nil {
+			fail("%s: %v", file.name, err)
+		}
+	}
+
+	jwt := c.Auth.JWT
+	if jwt.JWKSURL != "" && jwt.JWKSFile != "" {
+		fail("auth.jwt: set either jwks_url or jwks_file, not both")
+	}
+	if jwt.JWKSURL != "" {
+		if u, err := url.Parse(jwt.JWKSURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			fail("auth.jwt.jwks_url: %q is not an http or https URL", jwt.JWKSURL)
+		}
+	}
+	if jwt.DefaultRole != "" && !auth.ValidRole(jwt.DefaultRole) {
+		fail("auth.jwt.default_role: unknown role %q", jwt.DefaultRole)
+	}
+	for claim, role := range jwt.RoleMapping {
+		if !auth.ValidRole(role) {
+			fail("auth.jwt.role_mapping: %q maps to unknown role %q", claim, role)
+		}
+	}
+	if !tenant.Valid(jwt.DefaultTenant) {
+		fail("auth.jwt.default_tenant: %q is not a valid tenant id", jwt.DefaultTenant)
+	}
+
+	if c.Retention.Interval.Duration <= 0 {
+		fail("retention.interval: must be positive")
+	}
+	if c.Retention.Timeout.Duration <= 0 {
+		fail("retention.timeout: must be positive")
+	}
+
+	for _, key := range c.Search.ContextStreamKeys {
+		if key == "" || strings.ContainsAny(key, ". ") {
+			fail("search.context_stream_keys: %q is not a metadata key", key)
+		}
+	}
+
+	if c.Search.Cache.Enabled {
+		if err := c.Search.Cache.Cache().Validate(); err != nil {
+			fail("search.cache: %v", err)
+		}
+	}
+	if err := c.Search.Guardrails.Guardrails().Validate(); err != nil {
+		fail("search.guardrails: %v", err)
+	}
+	// A search Elasticsearch is still running when the client gives up
+	// reports a request error instead of the guardrail.
+	if timeout, limit := c.Search.Guardrails.Timeout.Duration, c.Elasticsearch.RequestTimeout.Duration; limit > 0 && timeout >= limit {
+		fail("search.guardrails.timeout: must be shorter than elasticsearch.request_timeout")
+	}
+
+	if err := c.Patterns.Miner().Validate(); err != nil {
+		fail("patterns: %v", err)
+	}
+
+	an := c.Anomaly
+	if an.Interval.Duration < time.Minute {
+		fail("anomaly.interval: must be at least 1m")
+	} else if an.Season.Duration < 0 || an.Season.Duration%an.Interval.Duration != 0 {
+		fail("anomaly.season: must be a multiple of the interval")
+	}
+	if an.Periods < 3 {
+		fail("anomaly.periods: must be at least 3")
+	}
+	if an.Delay.Duration < 0 {
+		fail("anomaly.delay: must not be negative")
+	}
+	if an.Threshold <= 0 {
+		fail("anomaly.threshold: must be positive")
+	}
+	if an.NotifyThreshold < an.Threshold {
+		fail("anomaly.notify_threshold: must be at least the threshold")
+	}
+	if an.MaxSeries < 1 {
+		fail("anomaly.max_series: must be positive")
+	}
+
+	for _, name := range c.Health.CriticalChecks {
+		if !healthChecks[name] {
+			fail("health.critical_checks: unknown check %q", name)
+		}
+	}
+	if c.Health.QueueCapacity < 0 {
+		fail("health.queue_capacity: must not be negative")
+	}
+
+	if err := c.Tracing.Tracing().Validate(); err != nil {
+		fail("tracing: %v", err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// validIndexName applies the Elasticsearch index naming rules that matter
+// for names we build tenant indices from.
+func validIndexName(name string) bool {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `\/*?"<>| ,#:`) {
+		return false
+	}
+	if strings.HasPrefix(name, "-") || strings.HasPrefix(name, "_") || strings.HasPrefix(name, "+") {
+		return false
+	}
+	return name == strings.ToLower(name)
+}
+
+// Reload copies the settings that can change while the backend runs from
+// next into c: CORS origins, the notification channels, the readiness
+// policy and the ingestion and access rule files, except multiline. It
+// returns the other sections that differ, which only take effect after a
+// restart.
+func (c *Config) Reload(next *Config) (restartRequired []string) {
+	server, nextServer := c.Server, next.Server
+	server.CORSAllowedOrigins, nextServer.CORSAllowedOrigins = nil, nil
+	if !reflect.DeepEqual(server, nextServer) {
+		restartRequired = append(restartRequired, "server")
+	}
+	if !reflect.DeepEqual(c.Elasticsearch, next.Elasticsearch) {
+		restartRequired = append(restartRequired, "elasticsearch")
+	}
+	if c.Ingestion.Multiline != next.Ingestion.Multiline {
+		restartRequired = append(restartRequired, "ingestion.multiline")
+	}
+	auth, nextAuth := c.Auth, next.Auth
+	auth.AccessPolicy, nextAuth.AccessPolicy = "", ""
+	if !reflect.DeepEqual(auth, nextAuth) {
+		restartRequired = append(restartRequired, "auth")
+	}
+	if c.Retention != next.Retention {
+		restartRequired = append(restartRequired, "retention")
+	}
+	if !reflect.DeepEqual(c.Search, next.Search) {
+		restartRequired = append(restartRequired, "search")
+	}
+	if c.Patterns != next.Patterns {
+		restartRequired = append(restartRequired, "patterns")
+	}
+	if c.Anomaly != next.Anomaly {
+		restartRequired = append(restartRequired, "anomaly")
+	}
+	if c.Health.QueueCapacity != next.Health.QueueCapacity {
+		restartRequired = append(restartRequired, "health.queue_capacity")
+	}
+	if c.Tracing != next.Tracing {
+		restartRequired = append(restartRequired, "tracing")
+	}
+
+	c.Server.CORSAllowedOrigins = next.Server.CORSAllowedOrigins
+	multiline := c.Ingestion.Multiline
+	c.Ingestion = next.Ingestion
+	c.Ingestion.Multiline = multiline
+	c.Auth.AccessPolicy = next.Auth.AccessPolicy
+	c.Alerting = next.Alerting
+	queueCapacity := c.Health.QueueCapacity
+	c.Health = next.Health
+	c.Health.QueueCapacity = queueCapacity
+	return restartRequired
+}
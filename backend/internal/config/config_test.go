@@ -0,0 +1,165 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFormats(t *testing.T) {
+	files := map[string]string{
+		"logana.yml": `
+server:
+  port: 9090
+  shutdown_timeout: 45s
+elasticsearch:
+  addresses: [https://es-1:9200, https://es-2:9200]
+  max_retries: 5
+`,
+		"logana.toml": `
+[server]
+port = 9090
+shutdown_timeout = "45s"
+
+[elasticsearch]
+addresses = ["https://es-1:9200", "https://es-2:9200"]
+max_retries = 5
+`,
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := Load(writeFile(t, name, content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Server.Port != 9090 || cfg.Server.ShutdownTimeout.Duration != 45*time.Second {
+				t.Fatalf("unexpected server settings: %+v", cfg.Server)
+			}
+			if len(cfg.Elasticsearch.Addresses) != 2 || cfg.Elasticsearch.MaxRetries != 5 {
+				t.Fatalf("unexpected elasticsearch settings: %+v", cfg.Elasticsearch)
+			}
+			// Unset settings keep their defaults.
+			if cfg.Elasticsearch.Index != defaultIndexName {
+				t.Fatalf("expected default index, got %q", cfg.Elasticsearch.Index)
+			}
+			if err := cfg.Validate(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsUnknownKeys(t *testing.T) {
+	for name, content := range map[string]string{
+		"logana.yml":  "server:\n  prot: 9090\n",
+		"logana.toml": "[server]\nprot = 9090\n",
+	} {
+		if _, err := Load(writeFile(t, name, content)); err == nil || !strings.Contains(err.Error(), "prot") {
+			t.Errorf("%s: expected an error naming the unknown key, got %v", name, err)
+		}
+	}
+}
+
+func TestEnvOverridesFile(t *testing.T) {
+	t.Setenv("PORT", "7070")
+	t.Setenv("ELASTICSEARCH_URL", "http://a:9200, http://b:9200")
+	t.Setenv("JWT_ROLE_MAPPING", "ops=admin,dev=editor")
+	t.Setenv("HEALTH_CRITICAL_CHECKS", "elasticsearch, ingest_queue")
+	t.Setenv("OTEL_TRACES_EXPORTER", "stdout")
+
+	cfg, err := Load(writeFile(t, "logana.yml", "server:\n  port: 9090\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Port != 7070 {
+		t.Fatalf("expected PORT to win, got %d", cfg.Server.Port)
+	}
+	if got := strings.Join(cfg.Elasticsearch.Addresses, ","); got != "http://a:9200,http://b:9200" {
+		t.Fatalf("unexpected addresses %q", got)
+	}
+	if cfg.Auth.JWT.RoleMapping["ops"] != "admin" {
+		t.Fatalf("unexpected role mapping %v", cfg.Auth.JWT.RoleMapping)
+	}
+	if got := strings.Join(cfg.Health.CriticalChecks, ","); got != "elasticsearch,ingest_queue" {
+		t.Fatalf("unexpected critical checks %q", got)
+	}
+	if cfg.Tracing.Exporter != "stdout" || cfg.Tracing.ServiceName != "logana-backend" {
+		t.Fatalf("unexpected tracing settings %+v", cfg.Tracing)
+	}
+
+	t.Setenv("SHUTDOWN_TIMEOUT", "soon")
+	if _, err := Load(""); err == nil {
+		t.Fatal("expected an invalid duration to fail")
+	}
+}
+
+func TestAuthIsOnUnlessDisabledExplicitly(t *testing.T) {
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Auth.Enabled {
+		t.Fatal("expected authentication to be on by default")
+	}
+
+	t.Setenv("AUTH_ENABLED", "false")
+	if cfg, err = Load(""); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Auth.Enabled {
+		t.Fatal("expected AUTH_ENABLED=false to turn authentication off")
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := Default()
+	cfg.Server.Port = 0
+	cfg.Elasticsearch.Addresses = []string{"es:9200"}
+	cfg.Elasticsearch.Index = "Logs"
+	cfg.Elasticsearch.ClientCert = "client.pem"
+	cfg.Auth.JWT.DefaultRole = "owner"
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected validation to fail")
+	}
+	for _, want := range []string{"server.port", "elasticsearch.addresses", "elasticsearch.index", "client_key", "default_role"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	running := Default()
+	next := Default()
+	next.Server.CORSAllowedOrigins = []string{"https://logs.example.com"}
+	next.Ingestion.Pipelines = "pipelines.yml"
+	next.Ingestion.Multiline = "multiline.yml"
+	next.Elasticsearch.MaxRetries = 1
+	next.Health.FailOnDegraded = true
+	next.Health.QueueCapacity = 50
+	next.Tracing.Exporter = "otlp"
+
+	restart := running.Reload(next)
+	if strings.Join(restart, ",") != "elasticsearch,ingestion.multiline,health.queue_capacity,tracing" {
+		t.Fatalf("unexpected restart list %v", restart)
+	}
+	if running.Server.CORSAllowedOrigins[0] != "https://logs.example.com" || running.Ingestion.Pipelines != "pipelines.yml" || !running.Health.FailOnDegraded {
+		t.Fatal("expected reloadable settings to be applied")
+	}
+	if running.Ingestion.Multiline != "" || running.Elasticsearch.MaxRetries != 3 || running.Health.QueueCapacity != 10000 || running.Tracing.Exporter != "none" {
+		t.Fatal("expected settings needing a restart to be kept")
+	}
+}
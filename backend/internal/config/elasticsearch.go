@@ -2,42 +2,67 @@ package config
 
 import (
 	"crypto/tls"
+	"crypto/x509"
+	"fmt"
 	"net/http"
 	"os"
-	"time"
 
 	"github.com/elastic/go-elasticsearch/v8"
+	"go.opentelemetry.io/otel"
 )
 
 const (
-	defaultElasticsearchURL = "http://localhost:9200"
-	defaultIndexName        = "logs"
+	defaultElasticsearchURL     = "http://localhost:9200"
+	defaultIndexName            = "logs"
+	defaultAPIKeyIndexName      = "logana-api-keys"
+	defaultAuditIndexName       = "logana-audit"
+	defaultAlertRuleIndexName   = "logana-alert-rules"
+	defaultSilenceIndexName     = "logana-silences"
+	defaultSavedSearchIndexName = "logana-saved-searches"
+	defaultPatternIndexName     = "logana-patterns"
+	defaultAnomalyIndexName     = "logana-anomalies"
 )
 
 // ElasticsearchConfig holds the Elasticsearch client configuration
 type ElasticsearchConfig struct {
-	Client    *elasticsearch.Client
-	IndexName string
+	Client               *elasticsearch.Client
+	IndexName            string
+	APIKeyIndexName      string
+	AuditIndexName       string
+	AlertRuleIndexName   string
+	SilenceIndexName     string
+	SavedSearchIndexName string
+	PatternIndexName     string
+	AnomalyIndexName     string
+
+	transport *http.Transport
 }
 
 // NewElasticsearchClient creates and returns a new Elasticsearch client
-func NewElasticsearchClient() (*ElasticsearchConfig, error) {
-	url := getEnvOrDefault("ELASTICSEARCH_URL", defaultElasticsearchURL)
-	username := os.Getenv("ELASTICSEARCH_USERNAME")
-	password := os.Getenv("ELASTICSEARCH_PASSWORD")
-	indexName := getEnvOrDefault("ELASTICSEARCH_INDEX", defaultIndexName)
+func NewElasticsearchClient(settings ElasticsearchSettings) (*ElasticsearchConfig, error) {
+	tlsConf, err := tlsConfig(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := &http.Transport{
+		MaxIdleConnsPerHost:   settings.MaxIdleConnsPerHost,
+		ResponseHeaderTimeout: settings.RequestTimeout.Duration,
+		TLSClientConfig:       tlsConf,
+	}
 
 	cfg := elasticsearch.Config{
-		Addresses: []string{url},
-		Username:  username,
-		Password:  password,
-		Transport: &http.Transport{
-			MaxIdleConnsPerHost:   10,
-			ResponseHeaderTimeout: time.Second * 10,
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: getEnvOrDefault("ELASTICSEARCH_INSECURE", "false") == "true",
-			},
-		},
+		Addresses:     settings.Addresses,
+		Username:      settings.Username,
+		Password:      settings.Password,
+		APIKey:        settings.APIKey,
+		MaxRetries:    settings.MaxRetries,
+		RetryOnStatus: settings.RetryOnStatus,
+		DisableRetry:  settings.MaxRetries == 0,
+		Transport:     transport,
+		// Spans for each Elasticsearch call, reported through whichever
+		// tracer provider is installed globally.
+		Instrumentation: elasticsearch.NewOpenTelemetryInstrumentation(otel.GetTracerProvider(), false),
 	}
 
 	client, err := elasticsearch.NewClient(cfg)
@@ -46,14 +71,47 @@ func NewElasticsearchClient() (*ElasticsearchConfig, error) {
 	}
 
 	return &ElasticsearchConfig{
-		Client:    client,
-		IndexName: indexName,
+		Client:               client,
+		IndexName:            settings.Index,
+		APIKeyIndexName:      settings.APIKeyIndex,
+		AuditIndexName:       settings.AuditIndex,
+		AlertRuleIndexName:   settings.AlertRuleIndex,
+		SilenceIndexName:     settings.SilenceIndex,
+		SavedSearchIndexName: settings.SavedSearchIndex,
+		PatternIndexName:     settings.PatternIndex,
+		AnomalyIndexName:     settings.AnomalyIndex,
+		transport:            transport,
 	}, nil
 }
 
-func getEnvOrDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// Close releases the pooled Elasticsearch connections. The client has no
+// Close of its own, so this only works because we own the transport.
+func (c *ElasticsearchConfig) Close() {
+	c.transport.CloseIdleConnections()
+}
+
+func tlsConfig(settings ElasticsearchSettings) (*tls.Config, error) {
+	conf := &tls.Config{InsecureSkipVerify: settings.Insecure}
+
+	if settings.CACert != "" {
+		pem, err := os.ReadFile(settings.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", settings.CACert)
+		}
+		conf.RootCAs = pool
 	}
-	return defaultValue
+
+	if settings.ClientCert != "" && settings.ClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(settings.ClientCert, settings.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate: %w", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+
+	return conf, nil
 }
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/access"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/logmetrics"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/multiline"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/notify"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/pipeline"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/redact"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tenant"
+)
+
+// Rules are the rule files the configuration points at. Unset files leave
+// their field nil.
+type Rules struct {
+	Pipelines    *pipeline.Set
+	Redactor     *redact.Redactor
+	Multiline    *multiline.Config
+	Tenants      *tenant.Config
+	AccessPolicy *access.Policy
+	Notify       *notify.Config
+	LogMetrics   *logmetrics.Set
+}
+
+// LoadRules reads and validates every rule file.
+func (c *Config) LoadRules() (*Rules, error) {
+	rules := &Rules{}
+	var err error
+
+	if path := c.Ingestion.Pipelines; path != "" {
+		if rules.Pipelines, err = pipeline.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if path := c.Ingestion.Redaction; path != "" {
+		if rules.Redactor, err = redact.LoadFile(path, []byte(c.Ingestion.RedactionHMACKey)); err != nil {
+			return nil, err
+		}
+	}
+
+	if path := c.Ingestion.Multiline; path != "" {
+		cfg, err := multiline.LoadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		// Rules are only checked when an aggregator is built.
+		agg, err := multiline.New(cfg, func(models.Log) {})
+		if err != nil {
+			return nil, err
+		}
+		agg.Close()
+		rules.Multiline = &cfg
+	}
+
+	if path := c.Ingestion.Tenants; path != "" {
+		cfg, err := tenant.LoadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		rules.Tenants = &cfg
+	}
+
+	if path := c.Ingestion.LogMetrics; path != "" {
+		if rules.LogMetrics, err = logmetrics.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if path := c.Auth.AccessPolicy; path != "" {
+		if rules.AccessPolicy, err = access.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if path := c.Alerting.Notifications; path != "" {
+		if rules.Notify, err = notify.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return rules, nil
+}
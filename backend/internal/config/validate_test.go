@@ -0,0 +1,78 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// validationError returns the validation error of the defaults changed by
+// mutate, or "" if they are valid.
+func validationError(t *testing.T, mutate func(*Config)) string {
+	t.Helper()
+	cfg := Default()
+	mutate(cfg)
+	if err := cfg.Validate(); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+func TestValidateContextStreamKeys(t *testing.T) {
+	if err := validationError(t, func(c *Config) { c.Search.ContextStreamKeys = []string{"host", "pod_name"} }); err != "" {
+		t.Fatalf("expected metadata keys to be valid, got %s", err)
+	}
+	for _, key := range []string{"metadata.host", "", "pod name"} {
+		err := validationError(t, func(c *Config) { c.Search.ContextStreamKeys = []string{key} })
+		if !strings.Contains(err, "search.context_stream_keys") {
+			t.Errorf("%q: expected a context_stream_keys error, got %q", key, err)
+		}
+	}
+}
+
+func TestValidateAnomaly(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		mutate func(*Config)
+	}{
+		{"interval", func(c *Config) { c.Anomaly.Interval.Duration = 30 * time.Second }},
+		{"season", func(c *Config) { c.Anomaly.Season.Duration = 7 * time.Minute }},
+		{"periods", func(c *Config) { c.Anomaly.Periods = 2 }},
+		{"delay", func(c *Config) { c.Anomaly.Delay.Duration = -time.Second }},
+		{"threshold", func(c *Config) { c.Anomaly.Threshold = 0 }},
+		{"notify_threshold", func(c *Config) { c.Anomaly.NotifyThreshold = 1 }},
+		{"max_series", func(c *Config) { c.Anomaly.MaxSeries = 0 }},
+	} {
+		if err := validationError(t, tc.mutate); !strings.Contains(err, "anomaly."+tc.name) {
+			t.Errorf("%s: expected an anomaly.%s error, got %q", tc.name, tc.name, err)
+		}
+	}
+
+	if err := validationError(t, func(c *Config) { c.Anomaly.Season.Duration = 0 }); err != "" {
+		t.Errorf("expected no season to be valid, got %s", err)
+	}
+}
+
+func TestValidateCacheOnlyWhenEnabled(t *testing.T) {
+	// The rules themselves are tested with querycache.Config.Validate.
+	if err := validationError(t, func(c *Config) { c.Search.Cache.Policy = "fifo" }); !strings.Contains(err, "search.cache") {
+		t.Errorf("expected a search.cache error, got %q", err)
+	}
+	if err := validationError(t, func(c *Config) {
+		c.Search.Cache.Enabled = false
+		c.Search.Cache.Policy = "fifo"
+	}); err != "" {
+		t.Errorf("expected a disabled cache not to be checked, got %s", err)
+	}
+}
+
+func TestValidateGuardrailTimeout(t *testing.T) {
+	// The rules themselves are tested with guardrail.Config.Validate.
+	if err := validationError(t, func(c *Config) { c.Search.Guardrails.Wildcards = "allow" }); !strings.Contains(err, "search.guardrails") {
+		t.Errorf("expected a search.guardrails error, got %q", err)
+	}
+	err := validationError(t, func(c *Config) { c.Search.Guardrails.Timeout.Duration = c.Elasticsearch.RequestTimeout.Duration })
+	if !strings.Contains(err, "search.guardrails.timeout") {
+		t.Errorf("expected a timeout error, got %q", err)
+	}
+}
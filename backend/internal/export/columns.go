@@ -0,0 +1,102 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+const metadataPrefix = "metadata."
+
+// Column names a field of models.Log. "metadata" selects the whole map as a
+// JSON object and "metadata.<key>" selects a single metadata value.
+type Column string
+
+var DefaultColumns = []Column{"id", "timestamp", "level", "source", "message", "metadata"}
+
+var baseColumns = map[Column]bool{
+	"id":         true,
+	"timestamp":  true,
+	"level":      true,
+	"source":     true,
+	"message":    true,
+	"metadata":   true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+// ParseColumns parses a comma separated column list, falling back to
+// DefaultColumns when spec is empty. A column may only be listed once, since
+// CSV headers and Parquet schemas need distinct names.
+func ParseColumns(spec string) ([]Column, error) {
+	if strings.TrimSpace(spec) == "" {
+		return DefaultColumns, nil
+	}
+
+	var columns []Column
+	seen := make(map[Column]bool)
+	for _, name := range strings.Split(spec, ",") {
+		col := Column(strings.TrimSpace(name))
+		if col == "" {
+			continue
+		}
+		if !baseColumns[col] && !(strings.HasPrefix(string(col), metadataPrefix) && len(col) > len(metadataPrefix)) {
+			return nil, fmt.Errorf("unknown column %q", col)
+		}
+		if seen[col] {
+			return nil, fmt.Errorf("column %q is listed twice", col)
+		}
+		seen[col] = true
+		columns = append(columns, col)
+	}
+	if len(columns) == 0 {
+		return DefaultColumns, nil
+	}
+	return columns, nil
+}
+
+func (c Column) isTime() bool {
+	return c == "timestamp" || c == "created_at" || c == "updated_at"
+}
+
+func (c Column) timeValue(log *models.Log) time.Time {
+	switch c {
+	case "created_at":
+		return log.CreatedAt
+	case "updated_at":
+		return log.UpdatedAt
+	default:
+		return log.Timestamp
+	}
+}
+
+// Value renders the column of log as text.
+func (c Column) Value(log *models.Log) string {
+	switch c {
+	case "id":
+		return log.ID
+	case "level":
+		return log.Level
+	case "source":
+		return log.Source
+	case "message":
+		return log.Message
+	case "timestamp", "created_at", "updated_at":
+		t := c.timeValue(log)
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339Nano)
+	case "metadata":
+		if len(log.Metadata) == 0 {
+			return ""
+		}
+		b, _ := json.Marshal(log.Metadata)
+		return string(b)
+	default:
+		return log.Metadata[strings.TrimPrefix(string(c), metadataPrefix)]
+	}
+}
@@ -0,0 +1,58 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+type csvWriter struct {
+	w             *csv.Writer
+	columns       []Column
+	headerWritten bool
+}
+
+func newCSVWriter(w io.Writer, columns []Column) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w), columns: columns}
+}
+
+func (w *csvWriter) writeHeader() error {
+	if w.headerWritten {
+		return nil
+	}
+	w.headerWritten = true
+
+	header := make([]string, len(w.columns))
+	for i, col := range w.columns {
+		header[i] = string(col)
+	}
+	return w.w.Write(header)
+}
+
+func (w *csvWriter) WriteBatch(logs []models.Log) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+
+	record := make([]string, len(w.columns))
+	for i := range logs {
+		for j, col := range w.columns {
+			record[j] = col.Value(&logs[i])
+		}
+		if err := w.w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	w.w.Flush()
+	return w.w.Error()
+}
+
+func (w *csvWriter) Close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
@@ -0,0 +1,48 @@
+package export
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+const (
+	FormatNDJSON  = "ndjson"
+	FormatCSV     = "csv"
+	FormatParquet = "parquet"
+)
+
+// Writer encodes logs batch by batch so callers never hold the full result
+// set. Nothing is written to the underlying stream before the first
+// WriteBatch or Close call.
+type Writer interface {
+	WriteBatch(logs []models.Log) error
+	Close() error
+}
+
+// NewWriter returns a Writer for format. Columns are ignored by NDJSON, which
+// always emits whole documents.
+func NewWriter(format string, w io.Writer, columns []Column) (Writer, error) {
+	switch format {
+	case FormatNDJSON:
+		return newNDJSONWriter(w), nil
+	case FormatCSV:
+		return newCSVWriter(w, columns), nil
+	case FormatParquet:
+		return newParquetWriter(w, columns), nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "application/x-ndjson"
+	}
+}
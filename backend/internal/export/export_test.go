@@ -0,0 +1,190 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/reader"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+var testLogs = []models.Log{
+	{
+		ID:        "1",
+		Level:     "error",
+		Message:   "disk \"data\" full, retrying\nattempt 2",
+		Source:    "api",
+		Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 123e6, time.UTC),
+		Metadata:  map[string]string{"host": "web-1"},
+	},
+	{
+		ID:        "2",
+		Level:     "info",
+		Message:   "ok",
+		Source:    "worker",
+		Timestamp: time.Date(2024, 5, 1, 12, 0, 1, 0, time.UTC),
+	},
+}
+
+func write(t *testing.T, format string, columns []Column, batches ...[]models.Log) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(format, &buf, columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, batch := range batches {
+		if err := w.WriteBatch(batch); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestNDJSON(t *testing.T) {
+	out := write(t, FormatNDJSON, nil, testLogs[:1], testLogs[1:])
+
+	var got []models.Log
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		var log models.Log
+		if err := json.Unmarshal(scanner.Bytes(), &log); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		got = append(got, log)
+	}
+	if len(got) != 2 || got[0].Message != testLogs[0].Message || got[1].ID != "2" || got[0].Metadata["host"] != "web-1" {
+		t.Fatalf("unexpected logs %+v", got)
+	}
+}
+
+func TestCSVEscaping(t *testing.T) {
+	columns := []Column{"id", "timestamp", "message", "metadata.host"}
+	out := write(t, FormatCSV, columns, testLogs)
+
+	records, err := csv.NewReader(bytes.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"id", "timestamp", "message", "metadata.host"},
+		{"1", "2024-05-01T12:00:00.123Z", testLogs[0].Message, "web-1"},
+		{"2", "2024-05-01T12:00:01Z", "ok", ""},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("expected %d records, got %v", len(want), records)
+	}
+	for i := range want {
+		if strings.Join(records[i], "|") != strings.Join(want[i], "|") {
+			t.Errorf("record %d: expected %q, got %q", i, want[i], records[i])
+		}
+	}
+}
+
+func TestCSVHeaderWithoutRows(t *testing.T) {
+	out := write(t, FormatCSV, []Column{"id", "level"})
+	if string(out) != "id,level\n" {
+		t.Fatalf("unexpected output %q", out)
+	}
+}
+
+func TestParquetRoundTrip(t *testing.T) {
+	columns := []Column{"id", "timestamp", "message", "metadata", "metadata.host"}
+	out := write(t, FormatParquet, columns, testLogs[:1], testLogs[1:])
+	if !bytes.HasPrefix(out, []byte("PAR1")) || !bytes.HasSuffix(out, []byte("PAR1")) {
+		t.Fatal("expected the parquet magic at both ends")
+	}
+
+	file, err := buffer.NewBufferFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr, err := reader.NewParquetColumnReader(file, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.ReadStop()
+
+	if n := pr.GetNumRows(); n != 2 {
+		t.Fatalf("expected 2 rows, got %d", n)
+	}
+	if n := len(pr.Footer.RowGroups); n != 2 {
+		t.Errorf("expected one row group per batch, got %d", n)
+	}
+	// The reader renames columns to Go names; the file keeps the originals.
+	var names []string
+	for i := range columns {
+		names = append(names, pr.SchemaHandler.GetExName(i+1))
+	}
+	if strings.Join(names, ",") != "id,timestamp,message,metadata,metadata.host" {
+		t.Errorf("unexpected schema %v", names)
+	}
+
+	want := [][]interface{}{
+		{"1", "2"},
+		{testLogs[0].Timestamp.UnixMilli(), testLogs[1].Timestamp.UnixMilli()},
+		{testLogs[0].Message, "ok"},
+		{`{"host":"web-1"}`, ""},
+		{"web-1", ""},
+	}
+	for i, values := range want {
+		got, _, _, err := pr.ReadColumnByIndex(int64(i), 2)
+		if err != nil {
+			t.Fatalf("column %s: %v", columns[i], err)
+		}
+		if len(got) != len(values) {
+			t.Fatalf("column %s: expected %v, got %v", columns[i], values, got)
+		}
+		for j := range values {
+			if got[j] != values[j] {
+				t.Errorf("column %s row %d: expected %v, got %v", columns[i], j, values[j], got[j])
+			}
+		}
+	}
+}
+
+func TestParquetWithoutRows(t *testing.T) {
+	out := write(t, FormatParquet, DefaultColumns)
+	file, err := buffer.NewBufferFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr, err := reader.NewParquetColumnReader(file, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.ReadStop()
+	if n := pr.GetNumRows(); n != 0 {
+		t.Fatalf("expected no rows, got %d", n)
+	}
+}
+
+func TestParseColumns(t *testing.T) {
+	columns, err := ParseColumns(" level, metadata.host ,,message")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(columns) != 3 || columns[0] != "level" || columns[1] != "metadata.host" || columns[2] != "message" {
+		t.Errorf("unexpected columns %v", columns)
+	}
+
+	if columns, err := ParseColumns(""); err != nil || len(columns) != len(DefaultColumns) {
+		t.Errorf("expected the default columns, got %v, %v", columns, err)
+	}
+
+	for _, spec := range []string{"level,level", "metadata.host,metadata.host", "owner", "metadata."} {
+		if _, err := ParseColumns(spec); err == nil {
+			t.Errorf("expected %q to be rejected", spec)
+		}
+	}
+}
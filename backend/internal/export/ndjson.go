@@ -0,0 +1,29 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	return &ndjsonWriter{enc: json.NewEncoder(w)}
+}
+
+func (w *ndjsonWriter) WriteBatch(logs []models.Log) error {
+	for i := range logs {
+		if err := w.enc.Encode(&logs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *ndjsonWriter) Close() error {
+	return nil
+}
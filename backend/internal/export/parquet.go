@@ -0,0 +1,86 @@
+package export
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/xitongsys/parquet-go/writer"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+// Every batch is flushed as its own row group, which keeps memory bounded
+// by the batch size. Timestamps are INT64 milliseconds, everything else a
+// UTF-8 string; all columns are REQUIRED, with empty values as "".
+
+type parquetWriter struct {
+	out     io.Writer
+	columns []Column
+	pw      *writer.CSVWriter
+}
+
+func newParquetWriter(w io.Writer, columns []Column) *parquetWriter {
+	return &parquetWriter{out: w, columns: columns}
+}
+
+// start creates the library writer, which writes the file header, on first
+// use so nothing reaches the stream before WriteBatch or Close.
+func (w *parquetWriter) start() error {
+	if w.pw != nil {
+		return nil
+	}
+	schema := make([]string, len(w.columns))
+	for i, col := range w.columns {
+		schema[i] = parquetField(col)
+	}
+	pw, err := writer.NewCSVWriterFromWriter(schema, w.out, 1)
+	if err != nil {
+		return fmt.Errorf("error starting parquet file: %w", err)
+	}
+	w.pw = pw
+	return nil
+}
+
+func parquetField(col Column) string {
+	if col.isTime() {
+		return fmt.Sprintf("name=%s, type=INT64, convertedtype=TIMESTAMP_MILLIS, repetitiontype=REQUIRED", col)
+	}
+	return fmt.Sprintf("name=%s, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=REQUIRED", col)
+}
+
+func (w *parquetWriter) WriteBatch(logs []models.Log) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	if len(logs) == 0 {
+		return nil
+	}
+
+	row := make([]interface{}, len(w.columns))
+	for i := range logs {
+		for j, col := range w.columns {
+			if col.isTime() {
+				row[j] = col.timeValue(&logs[i]).UnixMilli()
+			} else {
+				row[j] = col.Value(&logs[i])
+			}
+		}
+		if err := w.pw.Write(row); err != nil {
+			return fmt.Errorf("error writing parquet row: %w", err)
+		}
+	}
+	if err := w.pw.Flush(true); err != nil {
+		return fmt.Errorf("error writing parquet row group: %w", err)
+	}
+	return nil
+}
+
+func (w *parquetWriter) Close() error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	if err := w.pw.WriteStop(); err != nil {
+		return fmt.Errorf("error writing parquet footer: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,333 @@
+// Package guardrail bounds the cost of the searches users run: how many
+// results a page may hold, how far back a search may reach, which wildcard
+// and regular expression terms it may use, how long Elasticsearch may spend
+// on it and how many searches one user may run at once.
+package guardrail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+// Guardrail names, reported with every rejected search.
+const (
+	PageSize        = "page_size"
+	TimeRange       = "time_range"
+	LeadingWildcard = "leading_wildcard"
+	Regex           = "regex"
+	Concurrency     = "concurrency"
+	Timeout         = "timeout"
+	TerminateAfter  = "terminate_after"
+)
+
+// Wildcard modes select what happens to terms starting with a wildcard.
+const (
+	WildcardsReject  = "reject"
+	WildcardsRewrite = "rewrite"
+)
+
+// MaxResultWindow is how deep into the results a page may reach; it is
+// Elasticsearch's default index.max_result_window.
+const MaxResultWindow = 10000
+
+// Config mirrors the search.guardrails section of the configuration file.
+// Zero values disable the guardrail they configure.
+type Config struct {
+	MaxPageSize int
+	// MaxRange bounds the time range searched, per role. A principal with
+	// several roles gets the widest of their ranges; roles missing from
+	// the map are not bounded.
+	MaxRange map[string]time.Duration
+	// DefaultMaxRange bounds principals without roles, such as API keys
+	// and the anonymous principal when authentication is off.
+	DefaultMaxRange time.Duration
+	// Wildcards is WildcardsReject or WildcardsRewrite, which drops the
+	// leading wildcards and leading ".*" of regular expressions.
+	Wildcards      string
+	MaxRegexLength int
+	// Timeout is passed to Elasticsearch with every search. TerminateAfter
+	// caps the documents collected per shard by aggregations; hit searches
+	// are sorted by time, so a cap would drop the newest matches.
+	Timeout        time.Duration
+	TerminateAfter int
+	// MaxConcurrent caps the searches one user runs at once.
+	MaxConcurrent int
+}
+
+func (c Config) Validate() error {
+	var errs []error
+	if c.MaxPageSize < 0 || c.MaxPageSize > MaxResultWindow {
+		errs = append(errs, fmt.Errorf("max_page_size: must be between 0 and %d", MaxResultWindow))
+	}
+	for role, d := range c.MaxRange {
+		if !auth.ValidRole(role) {
+			errs = append(errs, fmt.Errorf("max_range: unknown role %q", role))
+		} else if d <= 0 {
+			errs = append(errs, fmt.Errorf("max_range: %s must be positive", role))
+		}
+	}
+	if c.Wildcards != WildcardsReject && c.Wildcards != WildcardsRewrite {
+		errs = append(errs, fmt.Errorf("wildcards: must be %s or %s, not %q", WildcardsReject, WildcardsRewrite, c.Wildcards))
+	}
+	if c.DefaultMaxRange < 0 {
+		errs = append(errs, errors.New("default_max_range: must not be negative"))
+	}
+	if c.MaxRegexLength < 0 || c.Timeout < 0 || c.TerminateAfter < 0 || c.MaxConcurrent < 0 {
+		errs = append(errs, errors.New("max_regex_length, timeout, terminate_after and max_concurrent_queries: must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// Error reports which guardrail rejected a search and why.
+type Error struct {
+	Guardrail string
+	Message   string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s guardrail: %s", e.Guardrail, e.Message)
+}
+
+// Status returns the HTTP status to answer a rejected search with.
+func (e *Error) Status() int {
+	switch e.Guardrail {
+	case Concurrency:
+		return http.StatusTooManyRequests
+	case Timeout, TerminateAfter:
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func fail(guardrail, format string, args ...interface{}) *Error {
+	return &Error{Guardrail: guardrail, Message: fmt.Sprintf(format, args...)}
+}
+
+// Guard checks searches against a Config and tracks the searches each user
+// is running.
+type Guard struct {
+	cfg Config
+	now func() time.Time
+
+	mu       sync.Mutex
+	inflight map[string]int
+}
+
+func New(cfg Config) *Guard {
+	return &Guard{cfg: cfg, now: time.Now, inflight: make(map[string]int)}
+}
+
+// CheckPage rejects pages larger than the maximum page size or reaching
+// past the result window.
+func (g *Guard) CheckPage(page, limit int) error {
+	if max := g.cfg.MaxPageSize; max > 0 && limit > max {
+		return fail(PageSize, "limit %d exceeds the maximum page size of %d", limit, max)
+	}
+	if page*limit > MaxResultWindow {
+		return fail(PageSize, "page %d of %d results reaches past the first %d results; narrow the search instead", page, limit, MaxResultWindow)
+	}
+	return nil
+}
+
+// CheckFilter rejects filters whose time range is wider than the
+// principal may search or whose query has costly terms, and rewrites the
+// query if leading wildcards are rewritten.
+func (g *Guard) CheckFilter(p *auth.Principal, filter *models.LogFilter) error {
+	if err := g.checkRange(p, *filter); err != nil {
+		return err
+	}
+	// Free text has no terms that scan the index.
+	if !filter.Patterns {
+		return nil
+	}
+	query, err := g.checkQuery(filter.Query)
+	if err != nil {
+		return err
+	}
+	filter.Query = query
+	return nil
+}
+
+func (g *Guard) checkRange(p *auth.Principal, filter models.LogFilter) error {
+	max, role := g.maxRange(p)
+	if max == 0 {
+		return nil
+	}
+	if filter.From.IsZero() {
+		return fail(TimeRange, "searches by %s are limited to %s; set from", role, max)
+	}
+	to := filter.To
+	if to.IsZero() {
+		to = g.now()
+	}
+	if span := to.Sub(filter.From); span > max {
+		return fail(TimeRange, "the range of %s exceeds the %s allowed for %s", span.Round(time.Second), max, role)
+	}
+	return nil
+}
+
+// maxRange returns the widest range the principal's roles allow and the
+// role allowing it, or zero if any of them is unbounded.
+func (g *Guard) maxRange(p *auth.Principal) (time.Duration, string) {
+	if p == nil {
+		return 0, ""
+	}
+	if len(p.Roles) == 0 {
+		return g.cfg.DefaultMaxRange, "principals without a role"
+	}
+	if len(g.cfg.MaxRange) == 0 {
+		return 0, ""
+	}
+	roles := append([]string(nil), p.Roles...)
+	sort.Strings(roles)
+
+	var widest time.Duration
+	var widestRole string
+	for _, role := range roles {
+		d, ok := g.cfg.MaxRange[role]
+		if !ok {
+			return 0, ""
+		}
+		if d > widest {
+			widest, widestRole = d, role
+		}
+	}
+	return widest, widestRole
+}
+
+func (g *Guard) checkQuery(query string) (string, error) {
+	text, terms := SplitQuery(query)
+	if len(terms) == 0 {
+		return query, nil
+	}
+
+	rewritten := false
+	words := strings.Fields(text)
+	for _, term := range terms {
+		value, err := g.checkTerm(term)
+		if err != nil {
+			return "", err
+		}
+		if value != term.Value {
+			rewritten = true
+		}
+		if value == "" {
+			continue
+		}
+		if term.Kind == KindRegexp {
+			value = "/" + value + "/"
+		}
+		words = append(words, value)
+	}
+	if !rewritten {
+		return query, nil
+	}
+	return strings.Join(words, " "), nil
+}
+
+// checkTerm returns the term's value, rewritten if allowed, or an empty
+// value if rewriting left nothing to match.
+func (g *Guard) checkTerm(term Term) (string, error) {
+	rewrite := g.cfg.Wildcards == WildcardsRewrite
+	switch term.Kind {
+	case KindWildcard:
+		if !strings.ContainsAny(term.Value[:1], wildcardChars) {
+			return term.Value, nil
+		}
+		if !rewrite {
+			return "", fail(LeadingWildcard, "%q starts with a wildcard, which scans every indexed term; anchor it with a prefix", term.Value)
+		}
+		value := strings.TrimLeft(term.Value, wildcardChars)
+		if strings.Trim(value, wildcardChars) == "" {
+			return "", nil
+		}
+		return value, nil
+	default:
+		value := term.Value
+		if leadingAnyRegex(value) {
+			if !rewrite {
+				return "", fail(Regex, "/%s/ starts with .* or .+, which scans every indexed term; anchor it with a prefix", value)
+			}
+			for leadingAnyRegex(value) {
+				value = value[2:]
+			}
+			if value == "" {
+				return "", nil
+			}
+		}
+		if max := g.cfg.MaxRegexLength; max > 0 && len(value) > max {
+			return "", fail(Regex, "/%s/ is longer than %d characters", value, max)
+		}
+		if nestedQuantifier(value) {
+			return "", fail(Regex, "/%s/ repeats a group that already repeats, which can backtrack without bound", value)
+		}
+		return value, nil
+	}
+}
+
+// Limits are the Elasticsearch limits applied to a search.
+type Limits struct {
+	Timeout        time.Duration
+	TerminateAfter int
+}
+
+type limitsKey struct{}
+
+// WithLimits returns a context whose searches run under limits.
+func (g *Guard) WithLimits(ctx context.Context) context.Context {
+	return context.WithValue(ctx, limitsKey{}, Limits{Timeout: g.cfg.Timeout, TerminateAfter: g.cfg.TerminateAfter})
+}
+
+// LimitsFromContext returns the limits set with WithLimits; searches run by
+// logana itself, such as alert evaluation, have none.
+func LimitsFromContext(ctx context.Context) Limits {
+	limits, _ := ctx.Value(limitsKey{}).(Limits)
+	return limits
+}
+
+// TimedOut is returned for searches Elasticsearch stopped at the timeout.
+func TimedOut(limits Limits) error {
+	return fail(Timeout, "the search did not finish within %s; narrow the time range or the query", limits.Timeout)
+}
+
+// TerminatedEarly is returned for aggregations Elasticsearch stopped at
+// terminate_after, whose counts would be short.
+func TerminatedEarly(limits Limits) error {
+	return fail(TerminateAfter, "the search reached the limit of %d documents per shard; narrow the time range or the query", limits.TerminateAfter)
+}
+
+// Acquire takes one of user's concurrent search slots; release returns it.
+func (g *Guard) Acquire(user string) (release func(), err error) {
+	max := g.cfg.MaxConcurrent
+	if max == 0 {
+		return func() {}, nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.inflight[user] >= max {
+		return nil, fail(Concurrency, "%d searches are already running for you; wait for one to finish", max)
+	}
+	g.inflight[user]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			if g.inflight[user]--; g.inflight[user] == 0 {
+				delete(g.inflight, user)
+			}
+		})
+	}, nil
+}
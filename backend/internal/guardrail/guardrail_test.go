@@ -0,0 +1,216 @@
+package guardrail
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+var testConfig = Config{
+	MaxPageSize:     100,
+	MaxRange:        map[string]time.Duration{auth.RoleViewer: 24 * time.Hour, auth.RoleEditor: 7 * 24 * time.Hour},
+	DefaultMaxRange: 72 * time.Hour,
+	Wildcards:       WildcardsReject,
+	MaxRegexLength:  20,
+	Timeout:         5 * time.Second,
+	TerminateAfter:  1000,
+	MaxConcurrent:   2,
+}
+
+var now = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func newGuard(cfg Config) *Guard {
+	g := New(cfg)
+	g.now = func() time.Time { return now }
+	return g
+}
+
+func guardrailOf(t *testing.T, err error) string {
+	t.Helper()
+	if err == nil {
+		return ""
+	}
+	var rejected *Error
+	if !errors.As(err, &rejected) {
+		t.Fatalf("expected a guardrail error, got %v", err)
+	}
+	return rejected.Guardrail
+}
+
+func TestCheckPage(t *testing.T) {
+	g := newGuard(testConfig)
+	for _, tc := range []struct {
+		page, limit int
+		want        string
+	}{
+		{1, 100, ""},
+		{1, 101, PageSize},
+		{100, 100, ""},
+		{101, 100, PageSize},
+	} {
+		if got := guardrailOf(t, g.CheckPage(tc.page, tc.limit)); got != tc.want {
+			t.Errorf("page %d of %d: expected %q, got %q", tc.page, tc.limit, tc.want, got)
+		}
+	}
+}
+
+func TestCheckRange(t *testing.T) {
+	g := newGuard(testConfig)
+	viewer := &auth.Principal{Roles: []string{auth.RoleViewer}}
+	both := &auth.Principal{Roles: []string{auth.RoleViewer, auth.RoleEditor}}
+	admin := &auth.Principal{Roles: []string{auth.RoleViewer, auth.RoleAdmin}}
+	key := &auth.Principal{Kind: "api_key", Scopes: []string{auth.ScopeLogsRead}}
+
+	for _, tc := range []struct {
+		name      string
+		principal *auth.Principal
+		filter    models.LogFilter
+		want      string
+	}{
+		{"within range", viewer, models.LogFilter{From: now.Add(-time.Hour)}, ""},
+		{"too wide", viewer, models.LogFilter{From: now.Add(-48 * time.Hour)}, TimeRange},
+		{"closed range", viewer, models.LogFilter{From: now.Add(-72 * time.Hour), To: now.Add(-60 * time.Hour)}, ""},
+		{"no start", viewer, models.LogFilter{}, TimeRange},
+		{"widest role wins", both, models.LogFilter{From: now.Add(-48 * time.Hour)}, ""},
+		{"unbounded role", admin, models.LogFilter{}, ""},
+		{"no principal", nil, models.LogFilter{}, ""},
+		{"no role within default", key, models.LogFilter{From: now.Add(-48 * time.Hour)}, ""},
+		{"no role too wide", key, models.LogFilter{From: now.Add(-96 * time.Hour)}, TimeRange},
+		{"auth off", auth.Anonymous, models.LogFilter{}, TimeRange},
+	} {
+		if got := guardrailOf(t, g.CheckFilter(tc.principal, &tc.filter)); got != tc.want {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestCheckQuery(t *testing.T) {
+	rewrite := testConfig
+	rewrite.Wildcards = WildcardsRewrite
+
+	for _, tc := range []struct {
+		cfg   Config
+		query string
+		want  string
+		err   string
+	}{
+		{testConfig, "timeout conn*", "timeout conn*", ""},
+		{testConfig, "timeout *conn", "", LeadingWildcard},
+		{testConfig, "/err(or)?/", "/err(or)?/", ""},
+		{testConfig, "/.*error/", "", Regex},
+		{testConfig, "/(a+)+b/", "", Regex},
+		{testConfig, "/abcdefghijklmnopqrstuvwxyz/", "", Regex},
+		{rewrite, "timeout *conn", "timeout conn", ""},
+		{rewrite, "timeout ** other", "timeout other", ""},
+		{rewrite, "/.*.+error/ x", "x /error/", ""},
+		{rewrite, "/.*(a+)+/", "", Regex},
+	} {
+		filter := models.LogFilter{Query: tc.query, Patterns: true}
+		err := newGuard(tc.cfg).CheckFilter(nil, &filter)
+		if got := guardrailOf(t, err); got != tc.err {
+			t.Errorf("%q: expected guardrail %q, got %q", tc.query, tc.err, got)
+			continue
+		}
+		if err == nil && filter.Query != tc.want {
+			t.Errorf("%q: expected %q, got %q", tc.query, tc.want, filter.Query)
+		}
+	}
+}
+
+func TestPlainQueriesAreNotChecked(t *testing.T) {
+	for _, query := range []string{"what?", "*", "file.*", "/.*error/"} {
+		filter := models.LogFilter{Query: query}
+		if err := newGuard(testConfig).CheckFilter(nil, &filter); err != nil {
+			t.Errorf("%q: expected free text to pass, got %v", query, err)
+		}
+		if filter.Query != query {
+			t.Errorf("%q: expected the query unchanged, got %q", query, filter.Query)
+		}
+	}
+}
+
+func TestSplitQuery(t *testing.T) {
+	text, terms := SplitQuery("disk f?ll /ab+c/ on host-*")
+	if text != "disk on" {
+		t.Errorf("unexpected text %q", text)
+	}
+	want := []Term{{KindWildcard, "f?ll"}, {KindRegexp, "ab+c"}, {KindWildcard, "host-*"}}
+	if len(terms) != len(want) {
+		t.Fatalf("expected %v, got %v", want, terms)
+	}
+	for i := range want {
+		if terms[i] != want[i] {
+			t.Errorf("expected %v, got %v", want[i], terms[i])
+		}
+	}
+}
+
+func TestAcquire(t *testing.T) {
+	g := newGuard(testConfig)
+	release1, err := g.Acquire("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release2, err := g.Acquire("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Acquire("alice"); guardrailOf(t, err) != Concurrency {
+		t.Fatalf("expected the third search to be rejected, got %v", err)
+	}
+	if _, err := g.Acquire("bob"); err != nil {
+		t.Fatalf("expected other users to be unaffected, got %v", err)
+	}
+
+	release1()
+	release1()
+	if _, err := g.Acquire("alice"); err != nil {
+		t.Fatalf("expected a released slot to be reusable, got %v", err)
+	}
+	release2()
+}
+
+func TestLimitsAndStatus(t *testing.T) {
+	g := newGuard(testConfig)
+	if limits := LimitsFromContext(context.Background()); limits != (Limits{}) {
+		t.Errorf("expected no limits, got %+v", limits)
+	}
+	limits := LimitsFromContext(g.WithLimits(context.Background()))
+	if limits.Timeout != 5*time.Second || limits.TerminateAfter != 1000 {
+		t.Errorf("unexpected limits %+v", limits)
+	}
+
+	var rejected *Error
+	if !errors.As(TimedOut(limits), &rejected) || rejected.Status() != http.StatusUnprocessableEntity {
+		t.Errorf("unexpected timeout error %v", rejected)
+	}
+	if !errors.As(TerminatedEarly(limits), &rejected) || rejected.Status() != http.StatusUnprocessableEntity {
+		t.Errorf("unexpected terminate_after error %v", rejected)
+	}
+	if status := (&Error{Guardrail: Concurrency}).Status(); status != http.StatusTooManyRequests {
+		t.Errorf("expected 429, got %d", status)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := testConfig.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, cfg := range []Config{
+		{Wildcards: "allow"},
+		{Wildcards: WildcardsReject, MaxPageSize: MaxResultWindow + 1},
+		{Wildcards: WildcardsReject, MaxRange: map[string]time.Duration{"owner": time.Hour}},
+		{Wildcards: WildcardsReject, MaxRange: map[string]time.Duration{auth.RoleViewer: 0}},
+		{Wildcards: WildcardsReject, MaxConcurrent: -1},
+		{Wildcards: WildcardsReject, DefaultMaxRange: -time.Hour},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", cfg)
+		}
+	}
+}
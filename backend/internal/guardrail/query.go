@@ -0,0 +1,52 @@
+package guardrail
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Kinds of pattern terms in a search query.
+const (
+	KindWildcard = "wildcard"
+	KindRegexp   = "regexp"
+)
+
+const wildcardChars = "*?"
+
+// Term is a word of a search query matched as a pattern rather than as
+// text: words containing * or ? are wildcards, words written /like this/
+// regular expressions. Only queries that ask for patterns have terms.
+type Term struct {
+	Kind  string
+	Value string
+}
+
+// SplitQuery separates the pattern terms of a search query from its free
+// text.
+func SplitQuery(query string) (string, []Term) {
+	var text []string
+	var terms []Term
+	for _, word := range strings.Fields(query) {
+		switch {
+		case len(word) > 2 && strings.HasPrefix(word, "/") && strings.HasSuffix(word, "/"):
+			terms = append(terms, Term{Kind: KindRegexp, Value: word[1 : len(word)-1]})
+		case strings.ContainsAny(word, wildcardChars):
+			terms = append(terms, Term{Kind: KindWildcard, Value: word})
+		default:
+			text = append(text, word)
+		}
+	}
+	return strings.Join(text, " "), terms
+}
+
+func leadingAnyRegex(re string) bool {
+	return strings.HasPrefix(re, ".*") || strings.HasPrefix(re, ".+")
+}
+
+// nestedRepeat matches a group holding a quantifier that is itself
+// quantified, such as (a+)+ or (\w*)*.
+var nestedRepeat = regexp.MustCompile(`\([^()]*[*+}][^()]*\)[*+{]`)
+
+func nestedQuantifier(re string) bool {
+	return nestedRepeat.MatchString(re)
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/alerting"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/notify"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/service"
+)
+
+type AlertHandler struct {
+	alertService service.AlertService
+}
+
+func NewAlertHandler(alertService service.AlertService) *AlertHandler {
+	return &AlertHandler{alertService: alertService}
+}
+
+func (h *AlertHandler) RegisterRoutes(r *gin.Engine) {
+	api := r.Group("/api/alerts")
+	{
+		api.GET("", auth.Require(auth.ScopeLogsRead), h.ListAlerts)
+		api.GET("/rules", auth.Require(auth.ScopeLogsRead), h.ListRules)
+		api.GET("/rules/:id", auth.Require(auth.ScopeLogsRead), h.GetRule)
+		api.POST("/rules", auth.Require(auth.ScopeAdmin), h.CreateRule)
+		api.PUT("/rules/:id", auth.Require(auth.ScopeAdmin), h.UpdateRule)
+		api.DELETE("/rules/:id", auth.Require(auth.ScopeAdmin), h.DeleteRule)
+		api.GET("/silences", auth.Require(auth.ScopeLogsRead), h.ListSilences)
+		api.POST("/silences", auth.Require(auth.ScopeAdmin), h.CreateSilence)
+		api.DELETE("/silences/:id", auth.Require(auth.ScopeAdmin), h.DeleteSilence)
+		api.GET("/channels", auth.Require(auth.ScopeLogsRead), h.ListChannels)
+		api.POST("/channels/:name/test", auth.Require(auth.ScopeAdmin), h.TestChannel)
+	}
+}
+
+func (h *AlertHandler) ListAlerts(c *gin.Context) {
+	c.JSON(http.StatusOK, h.alertService.ListAlerts(c.Request.Context()))
+}
+
+func (h *AlertHandler) ListRules(c *gin.Context) {
+	rules, err := h.alertService.ListRules(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+func (h *AlertHandler) GetRule(c *gin.Context) {
+	rule, err := h.alertService.GetRule(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func (h *AlertHandler) CreateRule(c *gin.Context) {
+	var req models.AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.alertService.CreateRule(c.Request.Context(), req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+func (h *AlertHandler) UpdateRule(c *gin.Context) {
+	var req models.AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.alertService.UpdateRule(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func (h *AlertHandler) DeleteRule(c *gin.Context) {
+	if err := h.alertService.DeleteRule(c.Request.Context(), c.Param("id")); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AlertHandler) ListSilences(c *gin.Context) {
+	silences, err := h.alertService.ListSilences(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, silences)
+}
+
+func (h *AlertHandler) CreateSilence(c *gin.Context) {
+	var req models.SilenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	silence, err := h.alertService.CreateSilence(c.Request.Context(), req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, silence)
+}
+
+func (h *AlertHandler) DeleteSilence(c *gin.Context) {
+	if err := h.alertService.DeleteSilence(c.Request.Context(), c.Param("id")); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AlertHandler) ListChannels(c *gin.Context) {
+	c.JSON(http.StatusOK, h.alertService.ListChannels())
+}
+
+// TestChannel reports a failed send as a bad gateway, with the channel's
+// error.
+func (h *AlertHandler) TestChannel(c *gin.Context) {
+	err := h.alertService.TestChannel(c.Request.Context(), c.Param("name"))
+	switch {
+	case errors.Is(err, notify.ErrUnknownChannel):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, gin.H{"status": "sent"})
+	}
+}
+
+func (h *AlertHandler) respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrAlertRuleNotFound), errors.Is(err, service.ErrSilenceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, alerting.ErrInvalidRule), errors.Is(err, notify.ErrInvalidSilence):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/service"
+)
+
+type AnomalyHandler struct {
+	anomalyService service.AnomalyService
+}
+
+func NewAnomalyHandler(anomalyService service.AnomalyService) *AnomalyHandler {
+	return &AnomalyHandler{anomalyService: anomalyService}
+}
+
+func (h *AnomalyHandler) RegisterRoutes(r *gin.Engine) {
+	api := r.Group("/api")
+	{
+		api.GET("/anomalies", auth.Require(auth.ScopeLogsRead), h.ListAnomalies)
+	}
+}
+
+// ListAnomalies returns anomalies whose bucket starts between from and to,
+// the latest first.
+func (h *AnomalyHandler) ListAnomalies(c *gin.Context) {
+	filter := models.AnomalyFilter{
+		Source: c.Query("source"),
+		Level:  c.Query("level"),
+	}
+	for name, dst := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if v := c.Query(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid %s: %v", name, err)})
+				return
+			}
+			*dst = t
+		}
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+
+	anomalies, err := h.anomalyService.List(c.Request.Context(), filter, limit)
+	switch {
+	case errors.Is(err, service.ErrAnomalyRestricted):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, anomalies)
+	}
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/access"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/service"
+)
+
+type fakeAnomalies struct{}
+
+func (fakeAnomalies) EnsureIndex(context.Context) error { return nil }
+
+func (fakeAnomalies) Save(context.Context, *models.Anomaly) error { return nil }
+
+func (fakeAnomalies) List(_ context.Context, tenantID string, _ models.AnomalyFilter, _ int) ([]models.Anomaly, error) {
+	return []models.Anomaly{{ID: "a1", Tenant: tenantID, Source: "api", Level: "ERROR"}}, nil
+}
+
+func TestListAnomaliesRespectsFieldPolicy(t *testing.T) {
+	policy := &access.Policy{Rules: []access.Rule{{Fields: []string{"source"}, Action: access.ActionHide, AllowRoles: []string{auth.RoleSecurity}}}}
+	r := newRouter(NewAnomalyHandler(service.NewAnomalyService(fakeAnomalies{}, func() *access.Policy { return policy })).RegisterRoutes)
+
+	if w := do(r, http.MethodGet, "/api/anomalies", auth.ScopeLogsRead, ""); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a caller restricted on source, got %d: %s", w.Code, w.Body)
+	}
+	if w := do(r, http.MethodGet, "/api/anomalies", auth.ScopeAdmin, ""); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for admins, got %d: %s", w.Code, w.Body)
+	}
+
+	policy = &access.Policy{Rules: []access.Rule{{Fields: []string{"metadata.user_id"}, Action: access.ActionHide, AllowRoles: []string{auth.RoleSecurity}}}}
+	if w := do(r, http.MethodGet, "/api/anomalies", auth.ScopeLogsRead, ""); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 when only metadata is restricted, got %d: %s", w.Code, w.Body)
+	}
+}
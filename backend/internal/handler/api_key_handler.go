@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/service"
+)
+
+type APIKeyHandler struct {
+	apiKeyService service.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService}
+}
+
+func (h *APIKeyHandler) RegisterRoutes(r *gin.Engine) {
+	admin := r.Group("/api/admin", auth.Require(auth.ScopeAdmin))
+	{
+		admin.POST("/keys", h.CreateKey)
+		admin.GET("/keys", h.ListKeys)
+		admin.POST("/keys/:id/rotate", h.RotateKey)
+		admin.DELETE("/keys/:id", h.RevokeKey)
+	}
+}
+
+func (h *APIKeyHandler) CreateKey(c *gin.Context) {
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, plaintext, err := h.apiKeyService.CreateKey(c.Request.Context(), req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidScope) || errors.Is(err, service.ErrInvalidTenant) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"key": plaintext, "api_key": key})
+}
+
+func (h *APIKeyHandler) ListKeys(c *gin.Context) {
+	keys, err := h.apiKeyService.ListKeys(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+func (h *APIKeyHandler) RotateKey(c *gin.Context) {
+	var grace time.Duration
+	if g := c.Query("grace"); g != "" {
+		var err error
+		grace, err = time.ParseDuration(g)
+		if err != nil || grace < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid grace duration"})
+			return
+		}
+	}
+
+	key, plaintext, err := h.apiKeyService.RotateKey(c.Request.Context(), c.Param("id"), grace)
+	if err != nil {
+		if errors.Is(err, service.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"key": plaintext, "api_key": key})
+}
+
+func (h *APIKeyHandler) RevokeKey(c *gin.Context) {
+	if err := h.apiKeyService.RevokeKey(c.Request.Context(), c.Param("id")); err != nil {
+		if errors.Is(err, service.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/service"
+)
+
+func TestCreateKeyRejectsBadScopes(t *testing.T) {
+	// Requests are refused before the key store is touched.
+	r := newRouter(NewAPIKeyHandler(service.NewAPIKeyService(nil, nil)).RegisterRoutes)
+
+	for _, body := range []string{
+		`{"name":"agent","scopes":[]}`,
+		`{"name":"agent","scopes":["logs:everything"]}`,
+		`{"name":"agent"}`,
+	} {
+		if w := do(r, http.MethodPost, "/api/admin/keys", auth.ScopeAdmin, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", body, w.Code, w.Body)
+		}
+	}
+}
+
+func TestAuthenticateRejectsMalformedKeyIDs(t *testing.T) {
+	// A nil store proves malformed ids never reach a lookup.
+	keys := service.NewAPIKeyService(nil, nil)
+
+	for _, credential := range []string{
+		"lgn_abc_secret",
+		"lgn_0123456789ABCDEF_secret",
+		"lgn_0123456789abcdef0_secret",
+		"lgn_../../_search_secret",
+		"lgn_0123456789abcdef_",
+	} {
+		if _, err := keys.Authenticate(context.Background(), credential); !errors.Is(err, auth.ErrInvalidCredential) {
+			t.Errorf("%s: expected ErrInvalidCredential, got %v", credential, err)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/service"
+)
+
+type AuditHandler struct {
+	auditService service.AuditService
+}
+
+func NewAuditHandler(auditService service.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+func (h *AuditHandler) RegisterRoutes(r *gin.Engine) {
+	api := r.Group("/api")
+	{
+		api.GET("/audit", auth.Require(auth.ScopeAdmin), h.SearchEvents)
+	}
+}
+
+func (h *AuditHandler) SearchEvents(c *gin.Context) {
+	filter := models.AuditFilter{
+		Action:     c.Query("action"),
+		ActorID:    c.Query("actor"),
+		Resource:   c.Query("resource"),
+		ResourceID: c.Query("resource_id"),
+		Tenant:     c.Query("tenant"),
+		Outcome:    c.Query("outcome"),
+	}
+	for name, dst := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if v := c.Query(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid %s: %v", name, err)})
+				return
+			}
+			*dst = t
+		}
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	events, err := h.auditService.Search(c.Request.Context(), filter, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// RequestInfo records the client address and user agent in the request
+// context for the audit trail.
+func RequestInfo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := service.WithRequestInfo(c.Request.Context(), service.RequestInfo{
+			RemoteAddr: c.ClientIP(),
+			UserAgent:  c.Request.UserAgent(),
+		})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
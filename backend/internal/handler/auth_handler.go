@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+)
+
+type AuthHandler struct{}
+
+func NewAuthHandler() *AuthHandler {
+	return &AuthHandler{}
+}
+
+func (h *AuthHandler) RegisterRoutes(r *gin.Engine) {
+	api := r.Group("/api")
+	{
+		api.GET("/me", h.GetPrincipal)
+	}
+}
+
+// GetPrincipal lets clients discover who they are authenticated as and which
+// operations they may perform.
+func (h *AuthHandler) GetPrincipal(c *gin.Context) {
+	c.JSON(http.StatusOK, auth.PrincipalFromContext(c.Request.Context()))
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tenant"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// testKeys authenticates the credential "<scope>" as a key holding only
+// that scope, and "<scope>@<tenant>" as such a key of another tenant.
+type testKeys struct{}
+
+func (testKeys) Authenticate(_ context.Context, credential string) (*auth.Principal, error) {
+	scope, tenantID, ok := strings.Cut(credential, "@")
+	if !ok {
+		tenantID = tenant.Default
+	}
+	if !auth.ValidScope(scope) {
+		return nil, auth.ErrInvalidCredential
+	}
+	return &auth.Principal{ID: credential, Kind: "api_key", Tenant: tenantID, Scopes: []string{scope}}, nil
+}
+
+func newRouter(register func(*gin.Engine)) *gin.Engine {
+	r := gin.New()
+	r.Use(auth.Middleware(testKeys{}))
+	register(r)
+	return r
+}
+
+func do(r http.Handler, method, path, scope, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", scope)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/health"
+)
+
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{checker: checker}
+}
+
+// RegisterRoutes adds the probes. They must be registered before the
+// authentication middleware so orchestrators never need credentials.
+func (h *HealthHandler) RegisterRoutes(r *gin.Engine) {
+	r.GET("/health", h.Live)
+	r.GET("/livez", h.Live)
+	r.GET("/readyz", h.Ready)
+}
+
+// Live only reports that the process is serving requests; dependencies are
+// left to Ready so an Elasticsearch outage does not get the backend
+// restarted.
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// RegisterAdminRoutes adds the detailed report. It must be registered after
+// the authentication middleware.
+func (h *HealthHandler) RegisterAdminRoutes(r *gin.Engine) {
+	api := r.Group("/api")
+	{
+		api.GET("/health", auth.Require(auth.ScopeAdmin), h.Details)
+	}
+}
+
+// Ready answers probes with the status of each component only.
+func (h *HealthHandler) Ready(c *gin.Context) {
+	report := h.checker.Cached(c.Request.Context())
+	c.JSON(h.status(report), report.Summary())
+}
+
+// Details is Ready with the messages and details of each component.
+func (h *HealthHandler) Details(c *gin.Context) {
+	report := h.checker.Cached(c.Request.Context())
+	c.JSON(h.status(report), report)
+}
+
+func (h *HealthHandler) status(report health.Report) int {
+	if !report.Ready(h.checker.Policy()) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/health"
+)
+
+type failingCheck struct{}
+
+func (failingCheck) Name() string { return "elasticsearch" }
+
+func (failingCheck) Check(context.Context) health.Result {
+	return health.Result{
+		Status:  health.StatusDown,
+		Message: "cluster unreachable: dial tcp 10.0.0.5:9200: connection refused",
+		Details: map[string]interface{}{"cluster_name": "es-prod"},
+	}
+}
+
+func TestReadinessHidesDetailsFromProbes(t *testing.T) {
+	checker := health.NewChecker(health.Policy{Critical: map[string]bool{"elasticsearch": true}}, failingCheck{})
+	h := NewHealthHandler(checker)
+	r := gin.New()
+	h.RegisterRoutes(r)
+	r.Use(auth.Middleware(testKeys{}))
+	h.RegisterAdminRoutes(r)
+
+	w := do(r, http.MethodGet, "/readyz", "", "")
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), `"elasticsearch":{"status":"down"`) {
+		t.Fatalf("expected a per-component 503, got %d: %s", w.Code, w.Body)
+	}
+	if strings.Contains(w.Body.String(), "10.0.0.5") || strings.Contains(w.Body.String(), "es-prod") {
+		t.Fatalf("probe response leaks details: %s", w.Body)
+	}
+
+	if w := do(r, http.MethodGet, "/api/health", auth.ScopeLogsRead, ""); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 without the admin scope, got %d", w.Code)
+	}
+	w = do(r, http.MethodGet, "/api/health", auth.ScopeAdmin, "")
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "es-prod") {
+		t.Fatalf("expected details for admins, got %d: %s", w.Code, w.Body)
+	}
+}
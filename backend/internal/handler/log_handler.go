@@ -1,12 +1,35 @@
 package handler
 
 import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/export"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/guardrail"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/logmetrics"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/metrics"
 	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/savedsearch"
 	"github.com/sanjeevmurmu/logana/logana-backend/internal/service"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tenant"
+)
+
+const (
+	// maxContextLogs caps how many logs GetLogContext returns on either side.
+	maxContextLogs = 500
+	maxPatterns    = 1000
+	// defaultMetricRange is the range QueryLogMetric covers without from.
+	defaultMetricRange = time.Hour
+	metricLabelPrefix  = "label."
 )
 
 type LogHandler struct {
@@ -20,23 +43,43 @@ func NewLogHandler(logService service.LogService) *LogHandler {
 func (h *LogHandler) RegisterRoutes(r *gin.Engine) {
 	api := r.Group("/api")
 	{
-		api.POST("/logs", h.CreateLog)
-		api.GET("/logs", h.GetLogs)
-		api.GET("/logs/search", h.SearchLogs)
-		api.GET("/logs/:id", h.GetLogByID)
-		api.PUT("/logs/:id", h.UpdateLog)
-		api.DELETE("/logs/:id", h.DeleteLog)
+		api.POST("/logs", auth.Require(auth.ScopeLogsWrite), h.CreateLog)
+		api.GET("/logs", auth.Require(auth.ScopeLogsRead), h.GetLogs)
+		api.GET("/logs/search", auth.Require(auth.ScopeLogsRead), h.SearchLogs)
+		api.GET("/logs/export", auth.Require(auth.ScopeLogsRead), h.ExportLogs)
+		api.GET("/logs/:id", auth.Require(auth.ScopeLogsRead), h.GetLogByID)
+		api.GET("/logs/:id/context", auth.Require(auth.ScopeLogsRead), h.GetLogContext)
+		// Rewriting a stored log destroys it as surely as deleting it
+		api.PUT("/logs/:id", auth.Require(auth.ScopeLogsDelete), h.UpdateLog)
+		api.DELETE("/logs/:id", auth.Require(auth.ScopeLogsDelete), h.DeleteLog)
+		api.GET("/patterns", auth.Require(auth.ScopeLogsRead), h.ListPatterns)
+		api.GET("/log-metrics", auth.Require(auth.ScopeLogsRead), h.ListLogMetrics)
+		api.GET("/log-metrics/:name/query", auth.Require(auth.ScopeLogsRead), h.QueryLogMetric)
 	}
 }
 
 func (h *LogHandler) CreateLog(c *gin.Context) {
 	var log models.Log
 	if err := c.ShouldBindJSON(&log); err != nil {
+		metrics.IngestedLog(log.Source, log.Level, metrics.OutcomeRejected)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	if err := h.logService.CreateLog(c.Request.Context(), &log); err != nil {
+		if errors.Is(err, service.ErrLogDropped) {
+			c.JSON(http.StatusAccepted, gin.H{"status": "dropped"})
+			return
+		}
+		if errors.Is(err, service.ErrLogBuffered) {
+			c.JSON(http.StatusAccepted, gin.H{"status": "buffered"})
+			return
+		}
+		if errors.Is(err, tenant.ErrQuotaExceeded) {
+			c.Header("Retry-After", "1")
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -49,6 +92,9 @@ func (h *LogHandler) GetLogs(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 
 	logs, err := h.logService.GetLogs(c.Request.Context(), page, limit)
+	if guardrailError(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -73,6 +119,29 @@ func (h *LogHandler) GetLogByID(c *gin.Context) {
 	c.JSON(http.StatusOK, log)
 }
 
+// GetLogContext returns the logs of the same stream around a log, like
+// grep -B and -A.
+func (h *LogHandler) GetLogContext(c *gin.Context) {
+	before, errBefore := strconv.Atoi(c.DefaultQuery("before", "50"))
+	after, errAfter := strconv.Atoi(c.DefaultQuery("after", "50"))
+	if errBefore != nil || errAfter != nil || before < 0 || after < 0 || before > maxContextLogs || after > maxContextLogs {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("before and after must be between 0 and %d", maxContextLogs)})
+		return
+	}
+
+	logContext, err := h.logService.GetLogContext(c.Request.Context(), c.Param("id"), before, after)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if logContext == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "log not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, logContext)
+}
+
 func (h *LogHandler) UpdateLog(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
@@ -110,9 +179,15 @@ func (h *LogHandler) DeleteLog(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// SearchLogs needs a query or at least one of the level, source, pattern and
+// time filters.
 func (h *LogHandler) SearchLogs(c *gin.Context) {
-	query := c.Query("q")
-	if query == "" {
+	filter, err := parseLogFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.Query == "" && filter.Level == "" && filter.Source == "" && filter.PatternID == "" && filter.From.IsZero() && filter.To.IsZero() {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "search query is required"})
 		return
 	}
@@ -120,7 +195,24 @@ func (h *LogHandler) SearchLogs(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 
-	logs, err := h.logService.SearchLogs(c.Request.Context(), query, page, limit)
+	var opts models.SearchOptions
+	for name, dst := range map[string]*bool{"highlight": &opts.Highlight, "explain": &opts.Explain} {
+		if v := c.Query(name); v != "" {
+			if *dst, err = strconv.ParseBool(v); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid %s: %v", name, err)})
+				return
+			}
+		}
+	}
+	if opts.Highlight || opts.Explain {
+		h.searchLogHits(c, filter, page, limit, opts)
+		return
+	}
+
+	logs, err := h.logService.SearchLogs(c.Request.Context(), filter, page, limit)
+	if guardrailError(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -128,3 +220,246 @@ func (h *LogHandler) SearchLogs(c *gin.Context) {
 
 	c.JSON(http.StatusOK, logs)
 }
+
+// searchLogHits answers a search asking why logs matched. Hits keep the
+// log fields and add _score and highlights; explain wraps them with the
+// compiled query.
+func (h *LogHandler) searchLogHits(c *gin.Context, filter models.LogFilter, page, limit int, opts models.SearchOptions) {
+	result, err := h.logService.SearchLogHits(c.Request.Context(), filter, page, limit, opts)
+	if guardrailError(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if opts.Explain {
+		c.JSON(http.StatusOK, result)
+		return
+	}
+	c.JSON(http.StatusOK, result.Hits)
+}
+
+// ListPatterns takes the same filters as SearchLogs. With new_since, only
+// patterns first seen since then are counted, e.g. the message shapes that
+// appeared after a deploy.
+func (h *LogHandler) ListPatterns(c *gin.Context) {
+	filter, err := parseLogFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var newSince time.Time
+	if since := c.Query("new_since"); since != "" {
+		if newSince, err = time.Parse(time.RFC3339, since); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid new_since: %v", err)})
+			return
+		}
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > maxPatterns {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxPatterns)})
+		return
+	}
+
+	counts, err := h.logService.ListPatterns(c.Request.Context(), filter, newSince, limit)
+	if guardrailError(c, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, service.ErrPatternsDisabled):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrMessageRestricted):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, counts)
+	}
+}
+
+func (h *LogHandler) ListLogMetrics(c *gin.Context) {
+	c.JSON(http.StatusOK, h.logService.ListLogMetrics(c.Request.Context()))
+}
+
+// QueryLogMetric returns a log metric's series between from and to, by
+// default the last hour, with one point per step. Parameters named
+// "label.<name>" select series by label value.
+func (h *LogHandler) QueryLogMetric(c *gin.Context) {
+	query := logmetrics.Query{
+		To:     time.Now().UTC(),
+		Stat:   c.Query("stat"),
+		Labels: make(map[string]string),
+	}
+	for name, dst := range map[string]*time.Time{"from": &query.From, "to": &query.To} {
+		if v := c.Query(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid %s: %v", name, err)})
+				return
+			}
+			*dst = t
+		}
+	}
+	if query.From.IsZero() {
+		query.From = query.To.Add(-defaultMetricRange)
+	}
+	if v := c.Query("step"); v != "" {
+		step, err := time.ParseDuration(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid step: %v", err)})
+			return
+		}
+		query.Step = step
+	}
+	for key, values := range c.Request.URL.Query() {
+		if label, ok := strings.CutPrefix(key, metricLabelPrefix); ok && len(values) > 0 {
+			query.Labels[label] = values[0]
+		}
+	}
+
+	series, err := h.logService.QueryLogMetric(c.Request.Context(), c.Param("name"), query)
+	switch {
+	case errors.Is(err, logmetrics.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, logmetrics.ErrInvalidQuery):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrLogMetricRestricted):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, series)
+	}
+}
+
+func (h *LogHandler) ExportLogs(c *gin.Context) {
+	format := c.DefaultQuery("format", export.FormatNDJSON)
+
+	columns, err := export.ParseColumns(c.Query("columns"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := parseLogFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var out io.Writer = c.Writer
+	var gz *gzip.Writer
+	if strings.Contains(c.GetHeader("Accept-Encoding"), "gzip") {
+		gz = gzip.NewWriter(c.Writer)
+		out = gz
+	}
+
+	writer, err := export.NewWriter(format, out, columns)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="logs.%s"`, format))
+	if gz != nil {
+		c.Header("Content-Encoding", "gzip")
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+	}
+
+	ctx := c.Request.Context()
+	err = h.logService.ExportLogs(ctx, filter, func(logs []models.Log) error {
+		if err := writer.WriteBatch(logs); err != nil {
+			return err
+		}
+		if gz != nil {
+			if err := gz.Flush(); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			// Client went away; the repository has already released the PIT.
+			return
+		}
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Encoding")
+			c.Writer.Header().Del("Content-Disposition")
+			c.Writer.Header().Del("Content-Type")
+			if !guardrailError(c, err) {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+		// The status line is already on the wire; all we can do is cut the
+		// stream short so the client sees a truncated body.
+		log.Printf("export aborted: %v", err)
+		return
+	}
+
+	if err := writer.Close(); err != nil {
+		log.Printf("export aborted: %v", err)
+		return
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			log.Printf("export aborted: %v", err)
+		}
+	}
+}
+
+// guardrailError answers a search rejected by a guardrail, naming the
+// guardrail, and reports whether err was such a rejection.
+func guardrailError(c *gin.Context, err error) bool {
+	var rejected *guardrail.Error
+	if !errors.As(err, &rejected) {
+		return false
+	}
+	if rejected.Guardrail == guardrail.Concurrency {
+		c.Header("Retry-After", "1")
+	}
+	c.JSON(rejected.Status(), gin.H{"error": rejected.Error(), "guardrail": rejected.Guardrail})
+	return true
+}
+
+func parseLogFilter(c *gin.Context) (models.LogFilter, error) {
+	filter := models.LogFilter{
+		Query:     c.Query("q"),
+		Level:     c.Query("level"),
+		Source:    c.Query("source"),
+		PatternID: c.Query("pattern_id"),
+	}
+	if patterns := c.Query("patterns"); patterns != "" {
+		var err error
+		if filter.Patterns, err = strconv.ParseBool(patterns); err != nil {
+			return filter, fmt.Errorf("invalid patterns: %w", err)
+		}
+	}
+
+	now := time.Now().UTC()
+	for _, bound := range []struct {
+		name     string
+		time     *time.Time
+		relative *string
+	}{
+		{"from", &filter.From, &filter.RelativeFrom},
+		{"to", &filter.To, &filter.RelativeTo},
+	} {
+		spec := c.Query(bound.name)
+		t, err := savedsearch.ParseTime(spec, now)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s: %w", bound.name, err)
+		}
+		*bound.time = t
+		if strings.HasPrefix(spec, "now") {
+			*bound.relative = spec
+		}
+	}
+
+	return filter, nil
+}
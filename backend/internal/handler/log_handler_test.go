@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/service"
+)
+
+type updateOnlyService struct {
+	service.LogService
+	updated []string
+}
+
+func (s *updateOnlyService) UpdateLog(_ context.Context, log *models.Log) error {
+	s.updated = append(s.updated, log.ID)
+	return nil
+}
+
+func TestUpdateLogRequiresDeleteScope(t *testing.T) {
+	svc := &updateOnlyService{}
+	r := newRouter(NewLogHandler(svc).RegisterRoutes)
+	body := `{"level":"INFO","message":"rewritten","source":"api"}`
+
+	if w := do(r, http.MethodPut, "/api/logs/1", auth.ScopeLogsWrite, body); w.Code != http.StatusForbidden {
+		t.Fatalf("expected a write-only key to get 403, got %d: %s", w.Code, w.Body)
+	}
+	if len(svc.updated) != 0 {
+		t.Fatal("expected the log to be left alone")
+	}
+
+	for _, scope := range []string{auth.ScopeLogsDelete, auth.ScopeAdmin} {
+		if w := do(r, http.MethodPut, "/api/logs/1", scope, body); w.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d: %s", scope, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/logmetrics"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/metrics"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tenant"
+)
+
+// MetricsHandler serves the series derived from logs. Their labels carry
+// log field values, so unlike logana's own metrics on /metrics they need
+// credentials.
+type MetricsHandler struct {
+	logMetrics *logmetrics.Registry
+}
+
+func NewMetricsHandler(logMetrics *logmetrics.Registry) *MetricsHandler {
+	return &MetricsHandler{logMetrics: logMetrics}
+}
+
+func (h *MetricsHandler) RegisterRoutes(r *gin.Engine) {
+	api := r.Group("/api")
+	{
+		api.GET("/metrics", auth.Require(auth.ScopeAdmin), metrics.HandlerFor(h.collector))
+	}
+}
+
+// collector picks the series the caller may scrape. Admins of the default
+// tenant operate the deployment and see every tenant; other admins only
+// see their own.
+func (h *MetricsHandler) collector(c *gin.Context) prometheus.Collector {
+	if caller := tenant.FromContext(c.Request.Context()); caller != tenant.Default {
+		return h.logMetrics.ForTenant(caller)
+	}
+	return h.logMetrics
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/logmetrics"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func TestLogMetricsNeedAdminAndAreScopedToTenant(t *testing.T) {
+	set, err := logmetrics.Load([]byte(`
+metrics:
+  - name: logins_total
+    type: counter
+    labels: [metadata.user]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := logmetrics.NewRegistry(set)
+	registry.Observe("default", &models.Log{Metadata: map[string]string{"user": "alice"}}, time.Now())
+	registry.Observe("payments", &models.Log{Metadata: map[string]string{"user": "bob"}}, time.Now())
+	r := newRouter(NewMetricsHandler(registry).RegisterRoutes)
+
+	if w := do(r, http.MethodGet, "/api/metrics", auth.ScopeLogsRead, ""); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 without the admin scope, got %d", w.Code)
+	}
+
+	w := do(r, http.MethodGet, "/api/metrics", auth.ScopeAdmin, "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `user="alice"`) || !strings.Contains(w.Body.String(), `user="bob"`) {
+		t.Fatalf("expected every tenant for default admins, got %d: %s", w.Code, w.Body)
+	}
+
+	w = do(r, http.MethodGet, "/api/metrics", auth.ScopeAdmin+"@payments", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `user="bob"`) || strings.Contains(w.Body.String(), `user="alice"`) {
+		t.Fatalf("expected only the payments series, got %d: %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/redact"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tenant"
+)
+
+type RedactionHandler struct {
+	counter *redact.Counter
+}
+
+// NewRedactionHandler reports on counter, which every redactor loaded since
+// startup counts into.
+func NewRedactionHandler(counter *redact.Counter) *RedactionHandler {
+	return &RedactionHandler{counter: counter}
+}
+
+func (h *RedactionHandler) RegisterRoutes(r *gin.Engine) {
+	api := r.Group("/api")
+	{
+		api.GET("/redactions", auth.Require(auth.ScopeAdmin), h.GetStats)
+	}
+}
+
+// GetStats reports redactions per tenant and source. Admins of the default
+// tenant operate the deployment and see every tenant; other admins only
+// see their own.
+func (h *RedactionHandler) GetStats(c *gin.Context) {
+	stats := h.counter.Stats()
+	if caller := tenant.FromContext(c.Request.Context()); caller != tenant.Default {
+		own := stats[caller]
+		stats = map[string]map[string]map[string]int64{}
+		if own != nil {
+			stats[caller] = own
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"tenants": stats})
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/redact"
+)
+
+func TestRedactionStatsAreScopedToTenant(t *testing.T) {
+	counter := redact.NewCounter()
+	redactor, err := redact.New(redact.Config{Detectors: map[string]redact.DetectorConfig{"email": {}}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	redactor.SetCounter(counter)
+	redactor.Redact("default", &models.Log{Source: "api", Message: "a@example.com"})
+	redactor.Redact("payments", &models.Log{Source: "billing", Message: "b@example.com"})
+	r := newRouter(NewRedactionHandler(counter).RegisterRoutes)
+
+	tests := map[string][]string{
+		auth.ScopeAdmin:               {"default", "payments"},
+		auth.ScopeAdmin + "@payments": {"payments"},
+		auth.ScopeAdmin + "@search":   {},
+	}
+	for credential, want := range tests {
+		w := do(r, http.MethodGet, "/api/redactions", credential, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", credential, w.Code, w.Body)
+		}
+		var body struct {
+			Tenants map[string]map[string]map[string]int64 `json:"tenants"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if len(body.Tenants) != len(want) {
+			t.Errorf("%s: expected tenants %v, got %v", credential, want, body.Tenants)
+		}
+		for _, id := range want {
+			if _, ok := body.Tenants[id]; !ok {
+				t.Errorf("%s: missing tenant %s in %v", credential, id, body.Tenants)
+			}
+		}
+	}
+}
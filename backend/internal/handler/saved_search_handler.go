@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/savedsearch"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/service"
+)
+
+type SavedSearchHandler struct {
+	savedSearchService service.SavedSearchService
+}
+
+func NewSavedSearchHandler(savedSearchService service.SavedSearchService) *SavedSearchHandler {
+	return &SavedSearchHandler{savedSearchService: savedSearchService}
+}
+
+// RegisterRoutes only requires logs:read; saving a search does not change
+// any logs.
+func (h *SavedSearchHandler) RegisterRoutes(r *gin.Engine) {
+	api := r.Group("/api/saved-searches", auth.Require(auth.ScopeLogsRead))
+	{
+		api.GET("", h.List)
+		api.POST("", h.Create)
+		api.GET("/:id", h.Open)
+		api.PUT("/:id", h.Update)
+		api.DELETE("/:id", h.Delete)
+	}
+}
+
+func (h *SavedSearchHandler) List(c *gin.Context) {
+	searches, err := h.savedSearchService.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, searches)
+}
+
+func (h *SavedSearchHandler) Create(c *gin.Context) {
+	var req models.SavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	search, err := h.savedSearchService.Create(c.Request.Context(), req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, search)
+}
+
+// Open resolves a permalink.
+func (h *SavedSearchHandler) Open(c *gin.Context) {
+	search, err := h.savedSearchService.Open(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, search)
+}
+
+func (h *SavedSearchHandler) Update(c *gin.Context) {
+	var req models.SavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	search, err := h.savedSearchService.Update(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, search)
+}
+
+func (h *SavedSearchHandler) Delete(c *gin.Context) {
+	if err := h.savedSearchService.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *SavedSearchHandler) respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrSavedSearchNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNotSearchOwner):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, savedsearch.ErrInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,134 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/config"
+)
+
+type elasticsearchCheck struct {
+	es *config.ElasticsearchConfig
+}
+
+// NewElasticsearchCheck checks that the cluster answers and is not red.
+func NewElasticsearchCheck(es *config.ElasticsearchConfig) Check {
+	return &elasticsearchCheck{es: es}
+}
+
+func (c *elasticsearchCheck) Name() string {
+	return "elasticsearch"
+}
+
+func (c *elasticsearchCheck) Check(ctx context.Context) Result {
+	res, err := c.es.Client.Cluster.Health(c.es.Client.Cluster.Health.WithContext(ctx))
+	if err != nil {
+		return Result{Status: StatusDown, Message: fmt.Sprintf("cluster unreachable: %v", err)}
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return Result{Status: StatusDown, Message: fmt.Sprintf("cluster health failed: %s", res.Status())}
+	}
+
+	var health struct {
+		ClusterName      string `json:"cluster_name"`
+		Status           string `json:"status"`
+		NumberOfNodes    int    `json:"number_of_nodes"`
+		UnassignedShards int    `json:"unassigned_shards"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&health); err != nil {
+		return Result{Status: StatusDown, Message: fmt.Sprintf("error parsing cluster health: %v", err)}
+	}
+
+	result := Result{
+		Status: StatusUp,
+		Details: map[string]interface{}{
+			"cluster_name":      health.ClusterName,
+			"cluster_status":    health.Status,
+			"nodes":             health.NumberOfNodes,
+			"unassigned_shards": health.UnassignedShards,
+		},
+	}
+	switch health.Status {
+	case "yellow":
+		result.Status = StatusDegraded
+		result.Message = "cluster status is yellow"
+	case "red":
+		result.Status = StatusDown
+		result.Message = "cluster status is red"
+	}
+	return result
+}
+
+type indexCheck struct {
+	es *config.ElasticsearchConfig
+}
+
+// NewIndexCheck checks the log indices of every tenant for write blocks,
+// such as the read_only_allow_delete block Elasticsearch sets when a disk
+// fills up. A missing index only degrades readiness, since it is created by
+// the first write.
+func NewIndexCheck(es *config.ElasticsearchConfig) Check {
+	return &indexCheck{es: es}
+}
+
+func (c *indexCheck) Name() string {
+	return "log_index"
+}
+
+func (c *indexCheck) Check(ctx context.Context) Result {
+	pattern := c.es.IndexName + "," + c.es.IndexName + "-*"
+	res, err := c.es.Client.Indices.GetSettings(
+		c.es.Client.Indices.GetSettings.WithContext(ctx),
+		c.es.Client.Indices.GetSettings.WithIndex(pattern),
+		c.es.Client.Indices.GetSettings.WithName("index.blocks.*"),
+		c.es.Client.Indices.GetSettings.WithIgnoreUnavailable(true),
+		c.es.Client.Indices.GetSettings.WithAllowNoIndices(true),
+	)
+	if err != nil {
+		return Result{Status: StatusDown, Message: fmt.Sprintf("cluster unreachable: %v", err)}
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return Result{Status: StatusDown, Message: fmt.Sprintf("index settings failed: %s", res.Status())}
+	}
+
+	var settings map[string]struct {
+		Settings struct {
+			Index struct {
+				Blocks map[string]string `json:"blocks"`
+			} `json:"index"`
+		} `json:"settings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&settings); err != nil {
+		return Result{Status: StatusDown, Message: fmt.Sprintf("error parsing index settings: %v", err)}
+	}
+
+	var blocked []string
+	for index, s := range settings {
+		for block, value := range s.Settings.Index.Blocks {
+			if value == "true" && (block == "write" || block == "read_only" || block == "read_only_allow_delete") {
+				blocked = append(blocked, index+" ("+block+")")
+			}
+		}
+	}
+
+	result := Result{
+		Status:  StatusUp,
+		Details: map[string]interface{}{"indices": len(settings)},
+	}
+	if _, ok := settings[c.es.IndexName]; !ok {
+		result.Status = StatusDegraded
+		result.Message = fmt.Sprintf("index %s does not exist yet", c.es.IndexName)
+	}
+	if len(blocked) > 0 {
+		result.Status = StatusDown
+		result.Message = "writes are blocked on " + strings.Join(blocked, ", ")
+		result.Details["blocked"] = blocked
+	}
+	return result
+}
@@ -0,0 +1,164 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusUp       Status = "up"
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+// checkTimeout bounds each check so a hung dependency fails the probe
+// instead of hanging it.
+const checkTimeout = 2 * time.Second
+
+// reportTTL is how long Cached reuses a report, so a burst of probes or
+// unauthenticated callers runs the checks against Elasticsearch only once.
+const reportTTL = time.Second
+
+// Result is the state of one component.
+type Result struct {
+	Status     Status                 `json:"status"`
+	Message    string                 `json:"message,omitempty"`
+	Details    map[string]interface{} `json:"details,omitempty"`
+	DurationMS int64                  `json:"duration_ms"`
+}
+
+type Check interface {
+	Name() string
+	Check(ctx context.Context) Result
+}
+
+// Policy decides how component failures roll up. A critical component that
+// is down takes the whole backend down; any other failure only degrades it.
+type Policy struct {
+	Critical map[string]bool
+	// FailOnDegraded makes readiness fail while the backend is degraded.
+	FailOnDegraded bool
+}
+
+type Report struct {
+	Status     Status            `json:"status"`
+	Components map[string]Result `json:"components"`
+}
+
+// Summary returns the report with only the status of each component. The
+// messages and details name clusters and indices and carry raw errors, so
+// they are kept from unauthenticated callers.
+func (r Report) Summary() Report {
+	summary := Report{Status: r.Status, Components: make(map[string]Result, len(r.Components))}
+	for name, result := range r.Components {
+		summary.Components[name] = Result{Status: result.Status, DurationMS: result.DurationMS}
+	}
+	return summary
+}
+
+// Ready reports whether the backend should receive traffic.
+func (r Report) Ready(policy Policy) bool {
+	return r.Status == StatusUp || (r.Status == StatusDegraded && !policy.FailOnDegraded)
+}
+
+type Checker struct {
+	checks   []Check
+	policy   atomic.Pointer[Policy]
+	draining atomic.Bool
+
+	mu       sync.Mutex
+	last     *Report
+	lastTime time.Time
+}
+
+func NewChecker(policy Policy, checks ...Check) *Checker {
+	c := &Checker{checks: checks}
+	c.policy.Store(&policy)
+	return c
+}
+
+func (c *Checker) Policy() Policy {
+	return *c.policy.Load()
+}
+
+// SetPolicy replaces the policy for the reports that follow.
+func (c *Checker) SetPolicy(policy Policy) {
+	c.policy.Store(&policy)
+	c.mu.Lock()
+	c.last = nil
+	c.mu.Unlock()
+}
+
+// Drain marks the backend as shutting down. From then on every report is
+// down, so load balancers stop routing new traffic before the server stops
+// accepting it.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Cached returns the last report while it is younger than reportTTL and
+// runs the checks otherwise. Concurrent callers wait for a single run.
+func (c *Checker) Cached(ctx context.Context) Report {
+	if c.draining.Load() {
+		return c.Run(ctx)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.last != nil && time.Since(c.lastTime) < reportTTL {
+		return *c.last
+	}
+	report := c.Run(ctx)
+	c.last, c.lastTime = &report, time.Now()
+	return report
+}
+
+// Run executes every check concurrently and rolls the results up.
+func (c *Checker) Run(ctx context.Context) Report {
+	if c.draining.Load() {
+		return Report{
+			Status:     StatusDown,
+			Components: map[string]Result{"shutdown": {Status: StatusDown, Message: "server is shutting down"}},
+		}
+	}
+
+	report := Report{Status: StatusUp, Components: make(map[string]Result, len(c.checks))}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+
+			start := time.Now()
+			result := check.Check(checkCtx)
+			result.DurationMS = time.Since(start).Milliseconds()
+
+			mu.Lock()
+			report.Components[check.Name()] = result
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	policy := c.Policy()
+	for name, result := range report.Components {
+		switch {
+		case result.Status == StatusDown && policy.Critical[name]:
+			report.Status = StatusDown
+		case result.Status != StatusUp && report.Status == StatusUp:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
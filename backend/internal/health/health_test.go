@@ -0,0 +1,129 @@
+package health
+
+import (
+	"context"
+	"testing"
+)
+
+type staticCheck struct {
+	name   string
+	status Status
+}
+
+func (c staticCheck) Name() string { return c.name }
+
+func (c staticCheck) Check(ctx context.Context) Result { return Result{Status: c.status} }
+
+func TestPolicyRollup(t *testing.T) {
+	policy := Policy{Critical: map[string]bool{"elasticsearch": true}}
+
+	tests := []struct {
+		name   string
+		checks []Check
+		want   Status
+		ready  bool
+	}{
+		{"all up", []Check{staticCheck{"elasticsearch", StatusUp}, staticCheck{"queue", StatusUp}}, StatusUp, true},
+		{"non-critical down", []Check{staticCheck{"elasticsearch", StatusUp}, staticCheck{"queue", StatusDown}}, StatusDegraded, true},
+		{"critical degraded", []Check{staticCheck{"elasticsearch", StatusDegraded}}, StatusDegraded, true},
+		{"critical down", []Check{staticCheck{"elasticsearch", StatusDown}, staticCheck{"queue", StatusUp}}, StatusDown, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewChecker(policy, tt.checks...).Run(context.Background())
+			if report.Status != tt.want {
+				t.Fatalf("expected %s, got %s", tt.want, report.Status)
+			}
+			if report.Ready(policy) != tt.ready {
+				t.Fatalf("expected ready=%v", tt.ready)
+			}
+		})
+	}
+
+	strict := Policy{Critical: policy.Critical, FailOnDegraded: true}
+	report := NewChecker(strict, staticCheck{"queue", StatusDown}).Run(context.Background())
+	if report.Ready(strict) {
+		t.Fatal("expected a degraded backend to be unready with FailOnDegraded")
+	}
+}
+
+func TestQueueCheck(t *testing.T) {
+	depth := 0
+	check := NewQueueCheck("queue", func() int { return depth }, 100)
+
+	for _, tt := range []struct {
+		depth int
+		want  Status
+	}{{10, StatusUp}, {80, StatusDegraded}, {100, StatusDown}} {
+		depth = tt.depth
+		if got := check.Check(context.Background()).Status; got != tt.want {
+			t.Errorf("depth %d: expected %s, got %s", tt.depth, tt.want, got)
+		}
+	}
+}
+
+func TestDrain(t *testing.T) {
+	policy := Policy{Critical: map[string]bool{"elasticsearch": true}}
+	checker := NewChecker(policy, staticCheck{"elasticsearch", StatusUp})
+	if !checker.Run(context.Background()).Ready(policy) {
+		t.Fatal("expected ready before draining")
+	}
+
+	checker.Drain()
+	if report := checker.Run(context.Background()); report.Ready(policy) {
+		t.Fatalf("expected unready while draining, got %s", report.Status)
+	}
+}
+
+func TestSetPolicy(t *testing.T) {
+	checker := NewChecker(Policy{}, staticCheck{"elasticsearch", StatusDown})
+	if got := checker.Run(context.Background()).Status; got != StatusDegraded {
+		t.Fatalf("expected degraded, got %s", got)
+	}
+
+	checker.SetPolicy(Policy{Critical: map[string]bool{"elasticsearch": true}})
+	if got := checker.Run(context.Background()).Status; got != StatusDown {
+		t.Fatalf("expected down once elasticsearch is critical, got %s", got)
+	}
+}
+
+type countingCheck struct {
+	runs *int
+}
+
+func (c countingCheck) Name() string { return "elasticsearch" }
+
+func (c countingCheck) Check(ctx context.Context) Result {
+	*c.runs++
+	return Result{Status: StatusUp, Message: "cluster es-prod is green"}
+}
+
+func TestCachedReport(t *testing.T) {
+	runs := 0
+	checker := NewChecker(Policy{}, countingCheck{&runs})
+
+	checker.Cached(context.Background())
+	report := checker.Cached(context.Background())
+	if runs != 1 {
+		t.Fatalf("expected one run within the TTL, got %d", runs)
+	}
+
+	summary := report.Summary()
+	if got := summary.Components["elasticsearch"]; got.Status != StatusUp || got.Message != "" {
+		t.Fatalf("expected only the status in the summary, got %+v", got)
+	}
+	if report.Components["elasticsearch"].Message == "" {
+		t.Fatal("summary modified the cached report")
+	}
+
+	checker.SetPolicy(Policy{FailOnDegraded: true})
+	checker.Cached(context.Background())
+	if runs != 2 {
+		t.Fatalf("expected a policy change to rerun the checks, got %d runs", runs)
+	}
+
+	checker.Drain()
+	if got := checker.Cached(context.Background()).Status; got != StatusDown {
+		t.Fatalf("expected draining to bypass the cache, got %s", got)
+	}
+}
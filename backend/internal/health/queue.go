@@ -0,0 +1,43 @@
+package health
+
+import (
+	"context"
+	"fmt"
+)
+
+type queueCheck struct {
+	name     string
+	depth    func() int
+	capacity int
+}
+
+// NewQueueCheck reports a queue as degraded once it is 80% full and down
+// when it reaches capacity.
+func NewQueueCheck(name string, depth func() int, capacity int) Check {
+	return &queueCheck{name: name, depth: depth, capacity: capacity}
+}
+
+func (c *queueCheck) Name() string {
+	return c.name
+}
+
+func (c *queueCheck) Check(ctx context.Context) Result {
+	depth := c.depth()
+	result := Result{
+		Status:  StatusUp,
+		Details: map[string]interface{}{"depth": depth, "capacity": c.capacity},
+	}
+	if c.capacity <= 0 {
+		return result
+	}
+
+	switch {
+	case depth >= c.capacity:
+		result.Status = StatusDown
+		result.Message = fmt.Sprintf("queue is full (%d/%d)", depth, c.capacity)
+	case depth*5 >= c.capacity*4:
+		result.Status = StatusDegraded
+		result.Message = fmt.Sprintf("queue is nearly full (%d/%d)", depth, c.capacity)
+	}
+	return result
+}
@@ -0,0 +1,114 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Checkpoint records, per input file, how many leading lines have been fully
+// processed. Batches finish out of order across workers, so the committed
+// offset only advances over a contiguous run of finished batches.
+type Checkpoint struct {
+	path string
+
+	mu      sync.Mutex
+	Files   map[string]int64 `json:"files"`
+	pending map[string]map[int64]int64
+}
+
+// LoadCheckpoint reads the checkpoint at path. A missing file yields an empty
+// checkpoint; an empty path disables persistence.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	cp := &Checkpoint{
+		path:    path,
+		Files:   make(map[string]int64),
+		pending: make(map[string]map[int64]int64),
+	}
+	if path == "" {
+		return cp, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading checkpoint: %w", err)
+	}
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, fmt.Errorf("error parsing checkpoint: %w", err)
+	}
+	if cp.Files == nil {
+		cp.Files = make(map[string]int64)
+	}
+	return cp, nil
+}
+
+// Offset returns the number of lines of file already imported.
+func (cp *Checkpoint) Offset(file string) int64 {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return cp.Files[file]
+}
+
+// Done marks lines [start, end) of file as processed and persists the
+// checkpoint if the committed offset moved.
+func (cp *Checkpoint) Done(file string, start, end int64) error {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	pending := cp.pending[file]
+	if pending == nil {
+		pending = make(map[int64]int64)
+		cp.pending[file] = pending
+	}
+	pending[start] = end
+
+	offset := cp.Files[file]
+	advanced := false
+	for {
+		next, ok := pending[offset]
+		if !ok {
+			break
+		}
+		delete(pending, offset)
+		offset = next
+		advanced = true
+	}
+	if !advanced {
+		return nil
+	}
+
+	cp.Files[file] = offset
+	return cp.save()
+}
+
+func (cp *Checkpoint) save() error {
+	if cp.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(cp.path), ".checkpoint-*")
+	if err != nil {
+		return fmt.Errorf("error writing checkpoint: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("error writing checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("error writing checkpoint: %w", err)
+	}
+	return os.Rename(tmp.Name(), cp.path)
+}
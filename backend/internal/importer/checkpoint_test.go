@@ -0,0 +1,58 @@
+package importer
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckpointAdvancesOverContiguousBatches(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "import.ckpt")
+	cp, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Batches finish out of order; the offset only moves over a gap-free run.
+	steps := []struct {
+		start, end int64
+		want       int64
+	}{
+		{10, 20, 0},
+		{20, 30, 0},
+		{0, 10, 30},
+		{40, 50, 30},
+		{30, 40, 50},
+	}
+	for _, step := range steps {
+		if err := cp.Done("a.log", step.start, step.end); err != nil {
+			t.Fatal(err)
+		}
+		if got := cp.Offset("a.log"); got != step.want {
+			t.Fatalf("after [%d, %d): expected offset %d, got %d", step.start, step.end, step.want, got)
+		}
+	}
+	if got := cp.Offset("b.log"); got != 0 {
+		t.Errorf("expected other files to start at 0, got %d", got)
+	}
+
+	reloaded, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.Offset("a.log"); got != 50 {
+		t.Errorf("expected the saved offset 50, got %d", got)
+	}
+}
+
+func TestCheckpointWithoutPath(t *testing.T) {
+	cp, err := LoadCheckpoint("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cp.Done("a.log", 0, 5); err != nil {
+		t.Fatal(err)
+	}
+	if got := cp.Offset("a.log"); got != 5 {
+		t.Errorf("expected offset 5, got %d", got)
+	}
+}
@@ -0,0 +1,346 @@
+package importer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/pipeline"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/redact"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/repository"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tenant"
+)
+
+const (
+	defaultWorkers      = 4
+	defaultBatchSize    = 500
+	maxBulkAttempts     = 3
+	maxRejectionSamples = 100
+)
+
+type Options struct {
+	Parser    LineParser
+	Workers   int
+	BatchSize int
+	// DryRun parses and validates every line without writing to
+	// Elasticsearch or advancing the checkpoint.
+	DryRun     bool
+	Checkpoint *Checkpoint
+	// Pipelines, when set, applies the same ingest pipelines as the API.
+	Pipelines *pipeline.Set
+	// Redactor, when set, scrubs sensitive values after the pipelines ran.
+	Redactor *redact.Redactor
+}
+
+type Rejection struct {
+	File   string `json:"file"`
+	Line   int64  `json:"line"`
+	Reason string `json:"reason"`
+}
+
+// FileReport counts the lines of one file. Existing lines were already
+// imported by an earlier, interrupted run.
+type FileReport struct {
+	File     string `json:"file"`
+	Skipped  int64  `json:"skipped"`
+	Accepted int64  `json:"accepted"`
+	Existing int64  `json:"existing"`
+	Rejected int64  `json:"rejected"`
+}
+
+// Report summarises an import run. Rejections holds at most the first
+// maxRejectionSamples rejected lines.
+type Report struct {
+	DryRun     bool          `json:"dry_run"`
+	Accepted   int64         `json:"accepted"`
+	Existing   int64         `json:"existing"`
+	Rejected   int64         `json:"rejected"`
+	Files      []*FileReport `json:"files"`
+	Rejections []Rejection   `json:"rejections,omitempty"`
+
+	mu sync.Mutex
+}
+
+func (r *Report) reject(fr *FileReport, file string, line int64, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fr.Rejected++
+	r.Rejected++
+	if len(r.Rejections) < maxRejectionSamples {
+		r.Rejections = append(r.Rejections, Rejection{File: file, Line: line, Reason: reason})
+	}
+}
+
+func (r *Report) accept(fr *FileReport, accepted, existing int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fr.Accepted += accepted
+	r.Accepted += accepted
+	fr.Existing += existing
+	r.Existing += existing
+}
+
+type batch struct {
+	file   string
+	report *FileReport
+	start  int64
+	end    int64
+	logs   []models.Log
+	lines  []int64
+}
+
+type Importer struct {
+	repo repository.LogRepository
+	opts Options
+	// backoff is the wait before the first retry; later ones wait longer.
+	backoff time.Duration
+}
+
+func New(repo repository.LogRepository, opts Options) *Importer {
+	if opts.Workers < 1 {
+		opts.Workers = defaultWorkers
+	}
+	if opts.BatchSize < 1 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.Checkpoint == nil {
+		opts.Checkpoint, _ = LoadCheckpoint("")
+	}
+	return &Importer{repo: repo, opts: opts, backoff: time.Second}
+}
+
+// Run imports files in order. Lines are parsed on a single reader goroutine
+// and written by opts.Workers bulk writers. If the run is interrupted, the
+// checkpoint lets the next run resume after the last fully written batch.
+// Every line is indexed under an ID derived from its file and line number, so
+// lines written again after an interruption or a retry are not duplicated.
+func (im *Importer) Run(ctx context.Context, files []string) (*Report, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	report := &Report{DryRun: im.opts.DryRun}
+	batches := make(chan *batch, im.opts.Workers)
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	for i := 0; i < im.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range batches {
+				if err := im.writeBatch(ctx, b, report); err != nil {
+					fail(err)
+				}
+			}
+		}()
+	}
+
+	for _, file := range files {
+		if err := im.readFile(ctx, file, report, batches); err != nil {
+			fail(err)
+			break
+		}
+	}
+	close(batches)
+	wg.Wait()
+
+	return report, firstErr
+}
+
+func (im *Importer) readFile(ctx context.Context, file string, report *Report, batches chan<- *batch) error {
+	r, err := openFile(file)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %w", file, err)
+	}
+	defer r.Close()
+
+	identity, err := filepath.Abs(file)
+	if err != nil {
+		return fmt.Errorf("error resolving %s: %w", file, err)
+	}
+
+	tenantID := tenant.FromContext(ctx)
+	fr := &FileReport{File: file}
+	report.mu.Lock()
+	report.Files = append(report.Files, fr)
+	report.mu.Unlock()
+
+	offset := int64(0)
+	if !im.opts.DryRun {
+		offset = im.opts.Checkpoint.Offset(file)
+	}
+
+	scanner := newLineScanner(r)
+	var lineNo int64
+	current := &batch{file: file, report: fr, start: offset}
+
+	send := func() error {
+		current.end = lineNo
+		select {
+		case batches <- current:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		current = &batch{file: file, report: fr, start: lineNo}
+		return nil
+	}
+
+	for scanner.Scan() {
+		lineNo++
+		if lineNo <= offset {
+			fr.Skipped++
+			continue
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		log, err := im.opts.Parser.Parse(line)
+		if err != nil {
+			report.reject(fr, file, lineNo, err.Error())
+			continue
+		}
+		if im.opts.Pipelines != nil && !im.opts.Pipelines.Process(&log) {
+			report.reject(fr, file, lineNo, pipeline.ErrDrop.Error())
+			continue
+		}
+		if im.opts.Redactor != nil && !im.opts.Redactor.Redact(tenantID, &log) {
+			report.reject(fr, file, lineNo, "dropped by redaction rule")
+			continue
+		}
+
+		log.ID = lineID(identity, lineNo)
+		current.logs = append(current.logs, log)
+		current.lines = append(current.lines, lineNo)
+		if len(current.logs) >= im.opts.BatchSize {
+			if err := send(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading %s at line %d: %w", file, lineNo+1, err)
+	}
+
+	if lineNo > current.start {
+		return send()
+	}
+	return nil
+}
+
+// writeBatch marks the batch done in the checkpoint once every line was
+// indexed, found already indexed, or rejected for its content. Lines that
+// Elasticsearch keeps turning away under load fail the batch instead, so the
+// next run retries them.
+func (im *Importer) writeBatch(ctx context.Context, b *batch, report *Report) error {
+	if im.opts.DryRun {
+		report.accept(b.report, int64(len(b.logs)), 0)
+		return nil
+	}
+
+	pending := make([]int, len(b.logs))
+	for i := range pending {
+		pending[i] = i
+	}
+	for attempt := 1; len(pending) > 0; attempt++ {
+		logs := make([]models.Log, len(pending))
+		for i, idx := range pending {
+			logs[i] = b.logs[idx]
+		}
+		itemErrs, err := im.bulkCreate(ctx, logs)
+		if err != nil {
+			return fmt.Errorf("error importing %s lines %d-%d: %w", b.file, b.start+1, b.end, err)
+		}
+
+		var accepted, existing int64
+		var retry []int
+		var lastErr error
+		for i, itemErr := range itemErrs {
+			var bulkErr *repository.BulkItemError
+			switch {
+			case itemErr == nil:
+				accepted++
+			case errors.As(itemErr, &bulkErr) && bulkErr.Conflict():
+				existing++
+			case errors.As(itemErr, &bulkErr) && bulkErr.Retryable():
+				retry = append(retry, pending[i])
+				lastErr = itemErr
+			default:
+				report.reject(b.report, b.file, b.lines[pending[i]], itemErr.Error())
+			}
+		}
+		report.accept(b.report, accepted, existing)
+
+		pending = retry
+		if len(pending) == 0 {
+			break
+		}
+		if attempt == maxBulkAttempts {
+			return fmt.Errorf("error importing %s lines %d-%d: %d lines still turned away after %d attempts: %w", b.file, b.start+1, b.end, len(pending), attempt, lastErr)
+		}
+		log.Printf("%d lines turned away (attempt %d/%d): %v", len(pending), attempt, maxBulkAttempts, lastErr)
+		if err := im.wait(ctx, attempt); err != nil {
+			return err
+		}
+	}
+
+	return im.opts.Checkpoint.Done(b.file, b.start, b.end)
+}
+
+func (im *Importer) bulkCreate(ctx context.Context, logs []models.Log) ([]error, error) {
+	var err error
+	for attempt := 1; attempt <= maxBulkAttempts; attempt++ {
+		var itemErrs []error
+		itemErrs, err = im.repo.BulkCreate(ctx, logs)
+		if err == nil {
+			return itemErrs, nil
+		}
+		if attempt == maxBulkAttempts {
+			break
+		}
+
+		log.Printf("bulk request failed (attempt %d/%d): %v", attempt, maxBulkAttempts, err)
+		if err := im.wait(ctx, attempt); err != nil {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+func (im *Importer) wait(ctx context.Context, attempt int) error {
+	select {
+	case <-time.After(time.Duration(attempt) * im.backoff):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// lineID identifies a line of a file across runs.
+func lineID(file string, line int64) string {
+	sum := sha256.Sum256([]byte(file + "\x00" + strconv.FormatInt(line, 10)))
+	return hex.EncodeToString(sum[:20])
+}
@@ -0,0 +1,260 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/repository"
+)
+
+// fakeRepo stores bulk created logs by ID like Elasticsearch does with
+// create actions.
+type fakeRepo struct {
+	repository.LogRepository
+
+	mu    sync.Mutex
+	docs  map[string]models.Log
+	calls int
+	// fail, when set, decides the outcome of a call before any log is
+	// stored: a request error, or item errors per log.
+	fail func(call int, logs []models.Log) (itemErr func(models.Log) error, err error)
+}
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{docs: make(map[string]models.Log)}
+}
+
+func (r *fakeRepo) BulkCreate(ctx context.Context, logs []models.Log) ([]error, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls++
+
+	var itemErr func(models.Log) error
+	if r.fail != nil {
+		var err error
+		if itemErr, err = r.fail(r.calls, logs); err != nil {
+			return nil, err
+		}
+	}
+	errs := make([]error, len(logs))
+	for i, log := range logs {
+		if itemErr != nil {
+			if errs[i] = itemErr(log); errs[i] != nil {
+				continue
+			}
+		}
+		if _, ok := r.docs[log.ID]; ok {
+			errs[i] = &repository.BulkItemError{Status: 409, Type: "version_conflict_engine_exception", Reason: "document already exists"}
+			continue
+		}
+		r.docs[log.ID] = log
+	}
+	return errs, nil
+}
+
+func writeLines(t *testing.T, n int, invalid ...int) string {
+	t.Helper()
+	bad := make(map[int]bool)
+	for _, i := range invalid {
+		bad[i] = true
+	}
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		if bad[i] {
+			b.WriteString("not json\n")
+			continue
+		}
+		fmt.Fprintf(&b, `{"timestamp":"2024-05-01T12:00:%02dZ","message":"line %d"}`+"\n", i%60, i)
+	}
+	path := filepath.Join(t.TempDir(), "app.ndjson")
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newImporter(repo *fakeRepo, cp *Checkpoint, dryRun bool) *Importer {
+	im := New(repo, Options{
+		Parser:     NewNDJSONParser(Defaults{Source: "app", Level: "INFO"}),
+		Workers:    2,
+		BatchSize:  3,
+		DryRun:     dryRun,
+		Checkpoint: cp,
+	})
+	im.backoff = time.Millisecond
+	return im
+}
+
+func TestResumeDoesNotDuplicate(t *testing.T) {
+	file := writeLines(t, 20)
+	cpPath := filepath.Join(t.TempDir(), "import.ckpt")
+	cp, err := LoadCheckpoint(cpPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first run stores some batches, then the batch holding line 10
+	// keeps failing after Elasticsearch already took part of it.
+	repo := newFakeRepo()
+	repo.fail = func(_ int, logs []models.Log) (func(models.Log) error, error) {
+		for _, log := range logs {
+			if log.Message == "line 10" {
+				repo.docs[logs[0].ID] = logs[0]
+				return nil, errors.New("connection reset")
+			}
+		}
+		return nil, nil
+	}
+	if _, err := newImporter(repo, cp, false).Run(context.Background(), []string{file}); err == nil {
+		t.Fatal("expected the first run to fail")
+	}
+	offset := cp.Offset(file)
+	if offset >= 10 {
+		t.Fatalf("expected the checkpoint to stop before line 10, got %d", offset)
+	}
+
+	cp, err = LoadCheckpoint(cpPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo.fail = nil
+	report, err := newImporter(repo, cp, false).Run(context.Background(), []string{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(repo.docs) != 20 {
+		t.Fatalf("expected 20 logs without duplicates, got %d", len(repo.docs))
+	}
+	if report.Files[0].Skipped != offset || report.Accepted+report.Existing != 20-offset {
+		t.Errorf("unexpected report %+v", report.Files[0])
+	}
+	if report.Existing == 0 {
+		t.Error("expected lines written by the first run to count as existing")
+	}
+	if cp.Offset(file) != 20 {
+		t.Errorf("expected the checkpoint at the end of the file, got %d", cp.Offset(file))
+	}
+}
+
+func TestRetryableItemsAreRetried(t *testing.T) {
+	file := writeLines(t, 6)
+	repo := newFakeRepo()
+	// Two logs are turned away on their first two attempts.
+	seen := make(map[string]int)
+	repo.fail = func(int, []models.Log) (func(models.Log) error, error) {
+		return func(log models.Log) error {
+			if log.Message != "line 1" && log.Message != "line 4" {
+				return nil
+			}
+			if seen[log.ID]++; seen[log.ID] <= 2 {
+				return &repository.BulkItemError{Status: 429, Type: "es_rejected_execution_exception", Reason: "queue full"}
+			}
+			return nil
+		}, nil
+	}
+	cp, _ := LoadCheckpoint("")
+	report, err := newImporter(repo, cp, false).Run(context.Background(), []string{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.docs) != 6 || report.Accepted != 6 || report.Rejected != 0 {
+		t.Fatalf("expected every line imported, got %d logs and %+v", len(repo.docs), report)
+	}
+}
+
+func TestPersistentBackpressureKeepsCheckpoint(t *testing.T) {
+	file := writeLines(t, 3)
+	repo := newFakeRepo()
+	repo.fail = func(int, []models.Log) (func(models.Log) error, error) {
+		return func(log models.Log) error {
+			if log.Message == "line 2" {
+				return &repository.BulkItemError{Status: 429, Type: "es_rejected_execution_exception", Reason: "queue full"}
+			}
+			return nil
+		}, nil
+	}
+	cp, _ := LoadCheckpoint("")
+	report, err := newImporter(repo, cp, false).Run(context.Background(), []string{file})
+	if err == nil {
+		t.Fatal("expected the run to fail")
+	}
+	if report.Rejected != 0 || cp.Offset(file) != 0 {
+		t.Fatalf("expected no rejection and no checkpoint progress, got %+v at %d", report, cp.Offset(file))
+	}
+}
+
+func TestContentRejectionsAreReported(t *testing.T) {
+	file := writeLines(t, 4)
+	repo := newFakeRepo()
+	repo.fail = func(int, []models.Log) (func(models.Log) error, error) {
+		return func(log models.Log) error {
+			if log.Message == "line 3" {
+				return &repository.BulkItemError{Status: 400, Type: "mapper_parsing_exception", Reason: "bad field"}
+			}
+			return nil
+		}, nil
+	}
+	cp, _ := LoadCheckpoint("")
+	report, err := newImporter(repo, cp, false).Run(context.Background(), []string{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Accepted != 3 || report.Rejected != 1 || report.Rejections[0].Line != 3 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if cp.Offset(file) != 4 {
+		t.Errorf("expected the checkpoint past the rejected line, got %d", cp.Offset(file))
+	}
+}
+
+func TestDryRunReport(t *testing.T) {
+	file := writeLines(t, 7, 2, 5)
+	cpPath := filepath.Join(t.TempDir(), "import.ckpt")
+	cp, err := LoadCheckpoint(cpPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newFakeRepo()
+
+	report, err := newImporter(repo, cp, true).Run(context.Background(), []string{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.DryRun || report.Accepted != 5 || report.Rejected != 2 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	var lines []int64
+	for _, r := range report.Rejections {
+		lines = append(lines, r.Line)
+		if !strings.HasPrefix(r.Reason, "invalid json") {
+			t.Errorf("unexpected reason %q", r.Reason)
+		}
+	}
+	if len(lines) != 2 || lines[0] != 2 || lines[1] != 5 {
+		t.Errorf("expected lines 2 and 5 rejected, got %v", lines)
+	}
+	if repo.calls != 0 || len(repo.docs) != 0 {
+		t.Error("expected a dry run to write nothing")
+	}
+	if _, err := os.Stat(cpPath); !os.IsNotExist(err) {
+		t.Error("expected a dry run to leave the checkpoint alone")
+	}
+}
+
+func TestLineIDIsStable(t *testing.T) {
+	if lineID("/data/a.log", 1) != lineID("/data/a.log", 1) {
+		t.Fatal("expected the same line to get the same id")
+	}
+	if lineID("/data/a.log", 1) == lineID("/data/a.log", 2) || lineID("/data/a.log", 1) == lineID("/data/b.log", 1) {
+		t.Fatal("expected different lines to get different ids")
+	}
+}
@@ -0,0 +1,127 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+// LineParser turns one input line into a log. Returning an error rejects the
+// line without aborting the import.
+type LineParser interface {
+	Parse(line string) (models.Log, error)
+}
+
+// Defaults fills fields the input does not carry.
+type Defaults struct {
+	Source string
+	Level  string
+}
+
+func (d Defaults) apply(log *models.Log) {
+	if log.Source == "" {
+		log.Source = d.Source
+	}
+	if log.Level == "" {
+		log.Level = d.Level
+	}
+}
+
+type ndjsonParser struct {
+	defaults Defaults
+}
+
+func NewNDJSONParser(defaults Defaults) LineParser {
+	return &ndjsonParser{defaults: defaults}
+}
+
+func (p *ndjsonParser) Parse(line string) (models.Log, error) {
+	var log models.Log
+	if err := json.Unmarshal([]byte(line), &log); err != nil {
+		return log, fmt.Errorf("invalid json: %w", err)
+	}
+	log.ID = ""
+	p.defaults.apply(&log)
+	return log, validate(&log)
+}
+
+// textParser matches each line against a regular expression whose named
+// groups map onto log fields: timestamp, level, source and message. Any other
+// named group is stored as a metadata key.
+type textParser struct {
+	re         *regexp.Regexp
+	timeLayout string
+	defaults   Defaults
+}
+
+// NewTextParser compiles pattern for plain text input. timeLayout is a Go
+// reference layout used for the timestamp group.
+func NewTextParser(pattern, timeLayout string, defaults Defaults) (LineParser, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid line pattern: %w", err)
+	}
+	if re.SubexpIndex("message") < 0 {
+		return nil, errors.New("line pattern must have a (?P<message>...) group")
+	}
+	if timeLayout == "" {
+		timeLayout = time.RFC3339
+	}
+	return &textParser{re: re, timeLayout: timeLayout, defaults: defaults}, nil
+}
+
+func (p *textParser) Parse(line string) (models.Log, error) {
+	var log models.Log
+
+	match := p.re.FindStringSubmatch(line)
+	if match == nil {
+		return log, errors.New("line does not match pattern")
+	}
+
+	for i, name := range p.re.SubexpNames() {
+		if name == "" || i >= len(match) {
+			continue
+		}
+		value := match[i]
+		switch name {
+		case "timestamp":
+			t, err := time.Parse(p.timeLayout, value)
+			if err != nil {
+				return log, fmt.Errorf("invalid timestamp %q: %w", value, err)
+			}
+			log.Timestamp = t
+		case "level":
+			log.Level = strings.ToUpper(value)
+		case "source":
+			log.Source = value
+		case "message":
+			log.Message = value
+		default:
+			if value == "" {
+				continue
+			}
+			if log.Metadata == nil {
+				log.Metadata = make(map[string]string)
+			}
+			log.Metadata[name] = value
+		}
+	}
+
+	p.defaults.apply(&log)
+	return log, validate(&log)
+}
+
+func validate(log *models.Log) error {
+	if strings.TrimSpace(log.Message) == "" {
+		return errors.New("missing message")
+	}
+	if log.Timestamp.IsZero() {
+		return errors.New("missing timestamp")
+	}
+	return nil
+}
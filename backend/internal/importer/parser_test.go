@@ -0,0 +1,54 @@
+package importer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNDJSONParser(t *testing.T) {
+	p := NewNDJSONParser(Defaults{Source: "app", Level: "INFO"})
+
+	log, err := p.Parse(`{"id":"x","timestamp":"2024-05-01T12:00:00Z","message":"started","metadata":{"host":"web-1"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log.ID != "" || log.Source != "app" || log.Level != "INFO" || log.Metadata["host"] != "web-1" {
+		t.Errorf("unexpected log %+v", log)
+	}
+
+	for _, line := range []string{
+		`not json`,
+		`{"timestamp":"2024-05-01T12:00:00Z"}`,
+		`{"message":"no time"}`,
+	} {
+		if _, err := p.Parse(line); err == nil {
+			t.Errorf("expected %q to be rejected", line)
+		}
+	}
+}
+
+func TestTextParser(t *testing.T) {
+	p, err := NewTextParser(`^(?P<timestamp>\S+) (?P<level>\S+) (?P<host>\S+) (?P<message>.*)$`, "", Defaults{Source: "app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log, err := p.Parse("2024-05-01T12:00:00Z warn web-1 disk almost full")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !log.Timestamp.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) || log.Level != "WARN" ||
+		log.Source != "app" || log.Message != "disk almost full" || log.Metadata["host"] != "web-1" {
+		t.Errorf("unexpected log %+v", log)
+	}
+
+	for _, line := range []string{"garbage", "yesterday warn web-1 disk full"} {
+		if _, err := p.Parse(line); err == nil {
+			t.Errorf("expected %q to be rejected", line)
+		}
+	}
+
+	if _, err := NewTextParser(`(?P<level>\S+)`, "", Defaults{}); err == nil {
+		t.Error("expected a pattern without a message group to be refused")
+	}
+}
@@ -0,0 +1,51 @@
+package importer
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+)
+
+const maxLineSize = 1 << 20
+
+type fileReader struct {
+	file *os.File
+	gz   *gzip.Reader
+	io.Reader
+}
+
+// openFile opens path for reading, transparently decompressing gzip input
+// detected by its magic bytes rather than the file extension.
+func openFile(path string) (*fileReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(f)
+	magic, _ := br.Peek(2)
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &fileReader{file: f, gz: gz, Reader: gz}, nil
+	}
+
+	return &fileReader{file: f, Reader: br}, nil
+}
+
+func (r *fileReader) Close() error {
+	if r.gz != nil {
+		r.gz.Close()
+	}
+	return r.file.Close()
+}
+
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	return scanner
+}
@@ -0,0 +1,51 @@
+package importer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenFileDetectsGzipByContent(t *testing.T) {
+	const content = "first\nsecond\n"
+	dir := t.TempDir()
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(content))
+	zw.Close()
+
+	// The extensions lie on purpose: only the magic bytes count.
+	files := map[string][]byte{
+		filepath.Join(dir, "plain.gz"):   []byte(content),
+		filepath.Join(dir, "packed.log"): gz.Bytes(),
+		filepath.Join(dir, "short.log"):  []byte("x"),
+	}
+	for path, data := range files {
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for path, want := range map[string]string{
+		filepath.Join(dir, "plain.gz"):   content,
+		filepath.Join(dir, "packed.log"): content,
+		filepath.Join(dir, "short.log"):  "x",
+	} {
+		r, err := openFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s: expected %q, got %q", filepath.Base(path), want, got)
+		}
+	}
+}
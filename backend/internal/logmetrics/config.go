@@ -0,0 +1,282 @@
+// Package logmetrics derives metrics from logs as they are ingested.
+// Definitions select logs with pipeline conditions, optionally extract a
+// number from a field and label their series with log fields. The series
+// are exposed to Prometheus and kept in memory at a fixed resolution for
+// range queries.
+package logmetrics
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/pipeline"
+	"gopkg.in/yaml.v3"
+)
+
+// Metric types.
+const (
+	TypeCounter   = "counter"
+	TypeHistogram = "histogram"
+)
+
+// TenantLabel is added to every series.
+const TenantLabel = "tenant"
+
+const (
+	defaultResolution = time.Minute
+	defaultRetention  = 6 * time.Hour
+	defaultMaxSeries  = 1000
+)
+
+var (
+	metricName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+	// reservedPrefixes belong to the metrics logana and the Go client
+	// export themselves.
+	reservedPrefixes = []string{"logana_", "go_", "process_", "promhttp_"}
+)
+
+// Config is the on-disk definition of the log metrics:
+//
+//	resolution: 1m
+//	retention: 6h
+//	max_series: 1000
+//	metrics:
+//	  - name: http_request_duration_ms
+//	    type: histogram
+//	    help: Request latency reported by the web servers.
+//	    match:
+//	      - field: source
+//	        equals: web-server
+//	    value:
+//	      field: message
+//	      pattern: 'processed in (\d+)ms'
+//	    buckets: [10, 50, 100, 250, 500, 1000]
+//	    labels: [level, metadata.region]
+//
+// Resolution and retention apply to the samples kept for range queries;
+// max_series bounds the label sets of each metric.
+type Config struct {
+	Resolution time.Duration `yaml:"resolution"`
+	Retention  time.Duration `yaml:"retention"`
+	MaxSeries  int           `yaml:"max_series"`
+	Metrics    []Definition  `yaml:"metrics"`
+}
+
+// Definition is one metric. A counter counts the matching logs, or adds up
+// their value if one is set; a histogram observes the value of each
+// matching log and requires one.
+type Definition struct {
+	Name    string               `yaml:"name"`
+	Type    string               `yaml:"type"`
+	Help    string               `yaml:"help"`
+	Match   []pipeline.Condition `yaml:"match"`
+	Value   *Value               `yaml:"value"`
+	Buckets []float64            `yaml:"buckets"`
+	// Labels are log fields; the label is named after the field without
+	// its "metadata." prefix.
+	Labels []string `yaml:"labels"`
+}
+
+// Value extracts a number from a field. With a pattern, the number is its
+// first capture group. Logs without a number are not counted.
+type Value struct {
+	Field   string `yaml:"field"`
+	Pattern string `yaml:"pattern"`
+}
+
+// Set is a validated set of definitions.
+type Set struct {
+	resolution time.Duration
+	retention  time.Duration
+	maxSeries  int
+	metrics    []*definition
+}
+
+type definition struct {
+	Definition
+	valueRe    *regexp.Regexp
+	labelNames []string
+	desc       *prometheus.Desc
+}
+
+// LoadFile reads and validates the log metrics defined in path.
+func LoadFile(path string) (*Set, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading log metrics config: %w", err)
+	}
+	return Load(data)
+}
+
+// Load validates log metrics defined in YAML.
+func Load(data []byte) (*Set, error) {
+	var cfg Config
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("error parsing log metrics config: %w", err)
+	}
+	return Build(cfg)
+}
+
+func Build(cfg Config) (*Set, error) {
+	set := &Set{
+		resolution: cfg.Resolution,
+		retention:  cfg.Retention,
+		maxSeries:  cfg.MaxSeries,
+	}
+	if set.resolution == 0 {
+		set.resolution = defaultResolution
+	}
+	if set.retention == 0 {
+		set.retention = defaultRetention
+	}
+	if set.maxSeries == 0 {
+		set.maxSeries = defaultMaxSeries
+	}
+	switch {
+	case set.resolution < time.Second || set.resolution%time.Second != 0:
+		return nil, errors.New("log metrics: resolution must be a whole number of seconds")
+	case set.retention < set.resolution:
+		return nil, errors.New("log metrics: retention must be at least the resolution")
+	case set.maxSeries < 0:
+		return nil, errors.New("log metrics: max_series must not be negative")
+	}
+
+	seen := make(map[string]bool)
+	for i := range cfg.Metrics {
+		def, err := build(cfg.Metrics[i])
+		if err != nil {
+			return nil, fmt.Errorf("log metric %d (%s): %w", i, cfg.Metrics[i].Name, err)
+		}
+		if seen[def.Name] {
+			return nil, fmt.Errorf("log metric %s is defined twice", def.Name)
+		}
+		seen[def.Name] = true
+		set.metrics = append(set.metrics, def)
+	}
+	return set, nil
+}
+
+func build(d Definition) (*definition, error) {
+	if !metricName.MatchString(d.Name) {
+		return nil, fmt.Errorf("invalid metric name %q", d.Name)
+	}
+	for _, prefix := range reservedPrefixes {
+		if strings.HasPrefix(d.Name, prefix) {
+			return nil, fmt.Errorf("metric names must not start with %q", prefix)
+		}
+	}
+
+	def := &definition{Definition: d}
+	switch d.Type {
+	case TypeCounter:
+		if len(d.Buckets) > 0 {
+			return nil, errors.New("buckets are only used by histograms")
+		}
+	case TypeHistogram:
+		if d.Value == nil {
+			return nil, errors.New("histograms require a value")
+		}
+		if len(def.Buckets) == 0 {
+			def.Buckets = prometheus.DefBuckets
+		}
+		if !sort.Float64sAreSorted(def.Buckets) {
+			return nil, errors.New("buckets must be in increasing order")
+		}
+		for i := 1; i < len(def.Buckets); i++ {
+			if def.Buckets[i] == def.Buckets[i-1] {
+				return nil, fmt.Errorf("bucket %g is listed twice", def.Buckets[i])
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown metric type %q", d.Type)
+	}
+
+	def.Match = append([]pipeline.Condition(nil), d.Match...)
+	for i := range def.Match {
+		if err := def.Match[i].Compile(); err != nil {
+			return nil, err
+		}
+	}
+
+	if v := d.Value; v != nil {
+		if v.Field == "" {
+			return nil, errors.New("value requires a field")
+		}
+		if v.Pattern != "" {
+			re, err := regexp.Compile(v.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value pattern: %w", err)
+			}
+			if re.NumSubexp() < 1 {
+				return nil, errors.New("value pattern needs a capture group")
+			}
+			def.valueRe = re
+		}
+	}
+
+	names := make(map[string]bool)
+	for _, field := range d.Labels {
+		name := labelName(field)
+		switch {
+		case name == "" || name[0] >= '0' && name[0] <= '9' || strings.HasPrefix(name, "__"):
+			return nil, fmt.Errorf("field %q does not make a valid label name", field)
+		case name == TenantLabel || name == "le":
+			return nil, fmt.Errorf("label %q is reserved", name)
+		case names[name]:
+			return nil, fmt.Errorf("label %q is used twice", name)
+		}
+		names[name] = true
+		def.labelNames = append(def.labelNames, name)
+	}
+
+	help := d.Help
+	if help == "" {
+		help = fmt.Sprintf("Log metric %s.", d.Name)
+	}
+	def.desc = prometheus.NewDesc(d.Name, help, append(append([]string(nil), def.labelNames...), TenantLabel), nil)
+	return def, nil
+}
+
+// labelName names the label of a field: "metadata.http-status" becomes
+// "http_status".
+func labelName(field string) string {
+	return labelChars.ReplaceAllString(strings.TrimPrefix(field, "metadata."), "_")
+}
+
+// fieldName qualifies metadata keys the way access policies name them.
+func fieldName(field string) string {
+	switch field {
+	case "message", "level", "source", "timestamp":
+		return field
+	}
+	if strings.HasPrefix(field, "metadata.") {
+		return field
+	}
+	return "metadata." + field
+}
+
+// fields lists the log fields the definition reads.
+func (d *definition) fields() []string {
+	var fields []string
+	for _, c := range d.Match {
+		fields = append(fields, fieldName(c.Field))
+	}
+	if d.Value != nil {
+		fields = append(fields, fieldName(d.Value.Field))
+	}
+	for _, field := range d.Labels {
+		fields = append(fields, fieldName(field))
+	}
+	return fields
+}
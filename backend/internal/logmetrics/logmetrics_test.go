@@ -0,0 +1,187 @@
+package logmetrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+const testConfig = `
+resolution: 1m
+retention: 1h
+metrics:
+  - name: auth_failures_total
+    type: counter
+    match:
+      - field: message
+        matches: 'Authentication failed'
+    labels: [metadata.region]
+  - name: request_duration_ms
+    type: histogram
+    help: Request latency reported by the web servers.
+    match:
+      - field: source
+        equals: web-server
+    value:
+      field: message
+      pattern: 'processed in (\d+)ms'
+    buckets: [50, 100, 500]
+    labels: [level]
+`
+
+func mustLoad(t *testing.T, data string) *Set {
+	t.Helper()
+	set, err := Load([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return set
+}
+
+func TestObserveAndExpose(t *testing.T) {
+	r := NewRegistry(mustLoad(t, testConfig))
+	now := time.Date(2024, 5, 1, 12, 0, 30, 0, time.UTC)
+
+	r.Observe("default", &models.Log{Message: "Authentication failed for alice", Metadata: map[string]string{"region": "eu"}}, now)
+	r.Observe("default", &models.Log{Message: "Authentication failed for bob", Metadata: map[string]string{"region": "eu"}}, now)
+	r.Observe("acme", &models.Log{Message: "Authentication failed for carol"}, now)
+	r.Observe("default", &models.Log{Source: "web-server", Level: "INFO", Message: "Request processed in 80ms"}, now)
+	r.Observe("default", &models.Log{Source: "web-server", Level: "INFO", Message: "Request processed in 700ms"}, now)
+	// Logs without a value are not observed.
+	r.Observe("default", &models.Log{Source: "web-server", Level: "INFO", Message: "Request aborted"}, now)
+
+	expected := `
+# HELP auth_failures_total Log metric auth_failures_total.
+# TYPE auth_failures_total counter
+auth_failures_total{region="",tenant="acme"} 1
+auth_failures_total{region="eu",tenant="default"} 2
+# HELP request_duration_ms Request latency reported by the web servers.
+# TYPE request_duration_ms histogram
+request_duration_ms_bucket{level="INFO",tenant="default",le="50"} 0
+request_duration_ms_bucket{level="INFO",tenant="default",le="100"} 1
+request_duration_ms_bucket{level="INFO",tenant="default",le="500"} 1
+request_duration_ms_bucket{level="INFO",tenant="default",le="+Inf"} 2
+request_duration_ms_sum{level="INFO",tenant="default"} 780
+request_duration_ms_count{level="INFO",tenant="default"} 2
+`
+	if err := testutil.CollectAndCompare(r, strings.NewReader(expected)); err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `
+# HELP auth_failures_total Log metric auth_failures_total.
+# TYPE auth_failures_total counter
+auth_failures_total{region="",tenant="acme"} 1
+`
+	if err := testutil.CollectAndCompare(r.ForTenant("acme"), strings.NewReader(expected)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	r := NewRegistry(mustLoad(t, testConfig))
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i, ms := range []string{"20", "80", "90", "400"} {
+		r.Observe("default", &models.Log{Source: "web-server", Level: "INFO", Message: "Request processed in " + ms + "ms"}, start.Add(time.Duration(i)*time.Minute))
+	}
+
+	series, err := r.Query("request_duration_ms", Query{Tenant: "default", From: start, To: start.Add(4 * time.Minute), Step: 2 * time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 1 || series[0].Labels["level"] != "INFO" {
+		t.Fatalf("unexpected series %+v", series)
+	}
+	points := series[0].Points
+	if len(points) != 2 || points[0].Value != 2 || points[1].Value != 2 || !points[1].Time.Equal(start.Add(2*time.Minute)) {
+		t.Fatalf("unexpected counts %+v", points)
+	}
+
+	series, err = r.Query("request_duration_ms", Query{Tenant: "default", From: start, To: start.Add(2 * time.Minute), Step: 2 * time.Minute, Stat: "avg"})
+	if err != nil || series[0].Points[0].Value != 50 {
+		t.Fatalf("expected an average of 50, got %+v, %v", series, err)
+	}
+	series, err = r.Query("request_duration_ms", Query{Tenant: "default", From: start, To: start.Add(4 * time.Minute), Step: 4 * time.Minute, Stat: "p50"})
+	// The median is halfway through the two observations in (50, 100].
+	if err != nil || series[0].Points[0].Value != 75 {
+		t.Fatalf("expected a median of 75, got %+v, %v", series, err)
+	}
+
+	// Other tenants and label values are not selected.
+	if series, _ := r.Query("request_duration_ms", Query{Tenant: "acme", From: start, To: start.Add(time.Hour)}); len(series) != 0 {
+		t.Fatalf("expected no series for another tenant, got %+v", series)
+	}
+	if series, _ := r.Query("request_duration_ms", Query{Tenant: "default", From: start, To: start.Add(time.Hour), Labels: map[string]string{"level": "ERROR"}}); len(series) != 0 {
+		t.Fatalf("expected no ERROR series, got %+v", series)
+	}
+
+	for _, q := range []Query{
+		{From: start, To: start.Add(time.Hour), Stat: StatIncrease},
+		{From: start, To: start.Add(time.Hour), Step: 90 * time.Second},
+		{From: start, To: start},
+		{From: start, To: start.Add(time.Hour), Labels: map[string]string{"region": "eu"}},
+	} {
+		if _, err := r.Query("request_duration_ms", q); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("expected %+v to be invalid, got %v", q, err)
+		}
+	}
+	if _, err := r.Query("unknown", Query{}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestReloadKeepsUnchangedSeries(t *testing.T) {
+	r := NewRegistry(mustLoad(t, testConfig))
+	now := time.Now()
+	r.Observe("default", &models.Log{Message: "Authentication failed", Source: "web-server", Level: "INFO"}, now)
+	r.Observe("default", &models.Log{Source: "web-server", Level: "INFO", Message: "Request processed in 80ms"}, now)
+
+	r.Reload(mustLoad(t, strings.Replace(testConfig, "buckets: [50, 100, 500]", "buckets: [10, 100]", 1)))
+	if got := testutil.CollectAndCount(r, "auth_failures_total"); got != 1 {
+		t.Fatalf("expected the counter to keep its series, got %d", got)
+	}
+	if got := testutil.CollectAndCount(r, "request_duration_ms"); got != 0 {
+		t.Fatalf("expected the histogram to start over, got %d series", got)
+	}
+
+	r.Reload(nil)
+	if got := len(r.Metrics()); got != 0 {
+		t.Fatalf("expected no metrics, got %d", got)
+	}
+}
+
+func TestMaxSeries(t *testing.T) {
+	r := NewRegistry(mustLoad(t, "max_series: 1\n"+testConfig[strings.Index(testConfig, "metrics:"):]))
+	for _, region := range []string{"eu", "us", "eu"} {
+		r.Observe("default", &models.Log{Message: "Authentication failed", Metadata: map[string]string{"region": region}}, time.Now())
+	}
+	if err := testutil.CollectAndCompare(r, strings.NewReader(`
+# HELP auth_failures_total Log metric auth_failures_total.
+# TYPE auth_failures_total counter
+auth_failures_total{region="eu",tenant="default"} 2
+`), "auth_failures_total"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInvalidDefinitions(t *testing.T) {
+	for _, tc := range []struct{ name, def string }{
+		{"type", "{name: a, type: gauge}"},
+		{"name", "{name: a-b, type: counter}"},
+		{"reserved name", "{name: logana_errors, type: counter}"},
+		{"histogram value", "{name: a, type: histogram}"},
+		{"bucket order", "{name: a, type: histogram, value: {field: duration}, buckets: [5, 1]}"},
+		{"capture group", "{name: a, type: counter, value: {field: message, pattern: '\\d+'}}"},
+		{"condition", "{name: a, type: counter, match: [{matches: x}]}"},
+		{"reserved label", "{name: a, type: counter, labels: [tenant]}"},
+		{"duplicate label", "{name: a, type: counter, labels: [region, metadata.region]}"},
+	} {
+		if _, err := Load([]byte("metrics:\n  - " + tc.def + "\n")); err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+		}
+	}
+}
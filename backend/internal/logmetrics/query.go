@@ -0,0 +1,229 @@
+package logmetrics
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+var (
+	ErrNotFound     = errors.New("log metric not found")
+	ErrInvalidQuery = errors.New("invalid log metric query")
+)
+
+// Query statistics. Counters support increase and rate, histograms count,
+// rate, sum, avg and quantiles written as p50, p90, p99.9 and so on.
+const (
+	StatIncrease = "increase"
+	StatRate     = "rate"
+	StatCount    = "count"
+	StatSum      = "sum"
+	StatAvg      = "avg"
+)
+
+// maxPoints bounds the points per series of one query.
+const maxPoints = 11000
+
+// Query selects a tenant's series of a metric over [From, To).
+type Query struct {
+	Tenant string
+	From   time.Time
+	To     time.Time
+	// Step is the width of each point; zero uses the resolution.
+	Step time.Duration
+	// Stat is computed per step; empty uses increase for counters and
+	// count for histograms.
+	Stat string
+	// Labels only selects series with these label values.
+	Labels map[string]string
+}
+
+type window struct {
+	count   float64
+	sum     float64
+	buckets []uint64
+}
+
+// Query returns the series of the named metric matching q, one point per
+// step. Steps without logs are zero for counts and left out for averages
+// and quantiles.
+func (r *Registry) Query(name string, q Query) ([]models.MetricSeries, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m, ok := r.metrics[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	def := m.def
+
+	stat := q.Stat
+	if stat == "" {
+		stat = StatIncrease
+		if def.Type == TypeHistogram {
+			stat = StatCount
+		}
+	}
+	value, err := statFunc(def, stat)
+	if err != nil {
+		return nil, err
+	}
+
+	step := q.Step
+	if step == 0 {
+		step = r.set.resolution
+	}
+	switch {
+	case !q.From.Before(q.To):
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidQuery)
+	case step < r.set.resolution || step%r.set.resolution != 0:
+		return nil, fmt.Errorf("%w: step must be a multiple of the %s resolution", ErrInvalidQuery, r.set.resolution)
+	}
+	first := q.From.Truncate(step)
+	windows := int((q.To.Sub(first) + step - 1) / step)
+	if windows > maxPoints {
+		return nil, fmt.Errorf("%w: the range holds more than %d steps", ErrInvalidQuery, maxPoints)
+	}
+
+	index := make(map[string]int, len(def.labelNames))
+	for i, label := range def.labelNames {
+		index[label] = i
+	}
+	for label := range q.Labels {
+		if _, ok := index[label]; !ok {
+			return nil, fmt.Errorf("%w: metric %s has no label %q", ErrInvalidQuery, name, label)
+		}
+	}
+
+	from, to, stepSeconds := first.Unix(), q.To.Unix(), int64(step/time.Second)
+	result := []models.MetricSeries{}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.series {
+		if s.tenant != q.Tenant || !selected(s, index, q.Labels) {
+			continue
+		}
+
+		aggregated := make([]window, windows)
+		for _, sample := range s.samples {
+			if sample.start < from || sample.start >= to {
+				continue
+			}
+			w := &aggregated[(sample.start-from)/stepSeconds]
+			w.count += sample.count
+			w.sum += sample.sum
+			if sample.buckets != nil {
+				if w.buckets == nil {
+					w.buckets = make([]uint64, len(sample.buckets))
+				}
+				for i, n := range sample.buckets {
+					w.buckets[i] += n
+				}
+			}
+		}
+
+		out := models.MetricSeries{Labels: make(map[string]string, len(def.labelNames)), Points: []models.MetricPoint{}}
+		for i, label := range def.labelNames {
+			out.Labels[label] = s.labels[i]
+		}
+		for i, w := range aggregated {
+			if v, ok := value(w, step); ok {
+				out.Points = append(out.Points, models.MetricPoint{Time: first.Add(time.Duration(i) * step), Value: v})
+			}
+		}
+		result = append(result, out)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return seriesKey(def, result[i]) < seriesKey(def, result[j])
+	})
+	return result, nil
+}
+
+func selected(s *series, index map[string]int, labels map[string]string) bool {
+	for label, value := range labels {
+		if s.labels[index[label]] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func seriesKey(def *definition, s models.MetricSeries) string {
+	values := make([]string, len(def.labelNames))
+	for i, label := range def.labelNames {
+		values[i] = s.Labels[label]
+	}
+	return strings.Join(values, "\xff")
+}
+
+// statFunc returns the function computing stat from one step.
+func statFunc(def *definition, stat string) (func(window, time.Duration) (float64, bool), error) {
+	switch {
+	case stat == StatRate:
+		return func(w window, step time.Duration) (float64, bool) {
+			return w.count / step.Seconds(), true
+		}, nil
+	case def.Type == TypeCounter && stat == StatIncrease,
+		def.Type == TypeHistogram && stat == StatCount:
+		return func(w window, _ time.Duration) (float64, bool) {
+			return w.count, true
+		}, nil
+	case def.Type == TypeHistogram && stat == StatSum:
+		return func(w window, _ time.Duration) (float64, bool) {
+			return w.sum, true
+		}, nil
+	case def.Type == TypeHistogram && stat == StatAvg:
+		return func(w window, _ time.Duration) (float64, bool) {
+			if w.count == 0 {
+				return 0, false
+			}
+			return w.sum / w.count, true
+		}, nil
+	case def.Type == TypeHistogram && strings.HasPrefix(stat, "p"):
+		p, err := strconv.ParseFloat(stat[1:], 64)
+		if err != nil || p <= 0 || p >= 100 {
+			return nil, fmt.Errorf("%w: invalid quantile %q", ErrInvalidQuery, stat)
+		}
+		return func(w window, _ time.Duration) (float64, bool) {
+			if w.count == 0 {
+				return 0, false
+			}
+			return quantile(p/100, def.Buckets, w.buckets, w.count), true
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s metrics do not support %q", ErrInvalidQuery, def.Type, stat)
+}
+
+// quantile estimates the q-quantile of bucketed observations by linear
+// interpolation within the bucket it falls into, like Prometheus'
+// histogram_quantile. Observations above the highest bound count as that
+// bound.
+func quantile(q float64, upper []float64, buckets []uint64, count float64) float64 {
+	rank := q * count
+	var cumulative float64
+	for i, n := range buckets {
+		below := cumulative
+		cumulative += float64(n)
+		if n == 0 || cumulative < rank {
+			continue
+		}
+		if i == len(upper) {
+			break
+		}
+		lower := 0.0
+		if i > 0 {
+			lower = upper[i-1]
+		} else if upper[0] <= 0 {
+			return upper[0]
+		}
+		return lower + (upper[i]-lower)*(rank-below)/float64(n)
+	}
+	return upper[len(upper)-1]
+}
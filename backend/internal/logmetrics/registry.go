@@ -0,0 +1,289 @@
+package logmetrics
+
+import (
+	"log"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/pipeline"
+)
+
+// Registry evaluates the definitions against ingested logs and holds the
+// resulting series. It is a prometheus.Collector of every tenant's series;
+// ForTenant collects one tenant's.
+type Registry struct {
+	mu      sync.RWMutex
+	set     *Set
+	metrics map[string]*metric
+}
+
+type metric struct {
+	mu     sync.Mutex
+	def    *definition
+	series map[string]*series
+	// full is set once the metric reached the series limit, so that is
+	// logged once.
+	full bool
+}
+
+type series struct {
+	tenant string
+	labels []string
+
+	// Totals since the series was created, as exported to Prometheus.
+	count   float64
+	sum     float64
+	buckets []uint64
+
+	// samples are the changes per resolution step, oldest first.
+	samples []sample
+}
+
+type sample struct {
+	start   int64 // Unix seconds
+	count   float64
+	sum     float64
+	buckets []uint64
+}
+
+// NewRegistry returns a registry evaluating set, which may be nil.
+func NewRegistry(set *Set) *Registry {
+	r := &Registry{metrics: make(map[string]*metric)}
+	r.Reload(set)
+	return r
+}
+
+// Reload swaps in new definitions. Metrics that keep their name, type,
+// labels and buckets keep their series; the others start over.
+func (r *Registry) Reload(set *Set) {
+	if set == nil {
+		set, _ = Build(Config{})
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	metrics := make(map[string]*metric, len(set.metrics))
+	for _, def := range set.metrics {
+		if m, ok := r.metrics[def.Name]; ok && sameShape(m.def, def) {
+			m.mu.Lock()
+			m.def = def
+			m.full = false
+			m.mu.Unlock()
+			metrics[def.Name] = m
+			continue
+		}
+		metrics[def.Name] = &metric{def: def, series: make(map[string]*series)}
+	}
+	r.set = set
+	r.metrics = metrics
+}
+
+func sameShape(a, b *definition) bool {
+	return a.Type == b.Type && slices.Equal(a.labelNames, b.labelNames) && slices.Equal(a.Buckets, b.Buckets)
+}
+
+// Observe evaluates every definition against a log of tenantID ingested at
+// now.
+func (r *Registry) Observe(tenantID string, event *models.Log, now time.Time) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	slot := now.Truncate(r.set.resolution).Unix()
+	oldest := now.Add(-r.set.retention).Truncate(r.set.resolution).Unix()
+	for _, m := range r.metrics {
+		def := m.def
+		if !matches(def, event) {
+			continue
+		}
+		value := 1.0
+		if def.Value != nil {
+			var ok bool
+			if value, ok = extract(def, event); !ok {
+				continue
+			}
+		}
+		labels := make([]string, len(def.Labels))
+		for i, field := range def.Labels {
+			labels[i], _ = pipeline.Field(event, field)
+		}
+		m.observe(tenantID, labels, value, slot, oldest, r.set.maxSeries)
+	}
+}
+
+func matches(def *definition, event *models.Log) bool {
+	for i := range def.Match {
+		if !def.Match[i].Match(event) {
+			return false
+		}
+	}
+	return true
+}
+
+// extract returns the value of a log. Counters only add non-negative
+// values.
+func extract(def *definition, event *models.Log) (float64, bool) {
+	raw, ok := pipeline.Field(event, def.Value.Field)
+	if !ok {
+		return 0, false
+	}
+	if def.valueRe != nil {
+		match := def.valueRe.FindStringSubmatch(raw)
+		if match == nil {
+			return 0, false
+		}
+		raw = match[1]
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, false
+	}
+	if def.Type == TypeCounter && value < 0 {
+		return 0, false
+	}
+	return value, true
+}
+
+func (m *metric) observe(tenantID string, labels []string, value float64, slot, oldest int64, maxSeries int) {
+	key := tenantID + "\xff" + strings.Join(labels, "\xff")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.series[key]
+	if !ok {
+		if maxSeries > 0 && len(m.series) >= maxSeries {
+			if !m.full {
+				log.Printf("Log metric %s reached %d series; new label values are not counted", m.def.Name, maxSeries)
+				m.full = true
+			}
+			return
+		}
+		s = &series{tenant: tenantID, labels: labels}
+		if m.def.Type == TypeHistogram {
+			s.buckets = make([]uint64, len(m.def.Buckets)+1)
+		}
+		m.series[key] = s
+	}
+
+	if n := len(s.samples); n == 0 || s.samples[n-1].start != slot {
+		drop := 0
+		for drop < n && s.samples[drop].start < oldest {
+			drop++
+		}
+		s.samples = append(s.samples[drop:], sample{start: slot})
+		if s.buckets != nil {
+			s.samples[len(s.samples)-1].buckets = make([]uint64, len(s.buckets))
+		}
+	}
+	current := &s.samples[len(s.samples)-1]
+
+	if m.def.Type == TypeCounter {
+		s.count += value
+		current.count += value
+		return
+	}
+	bucket, _ := slices.BinarySearch(m.def.Buckets, value)
+	s.count++
+	s.sum += value
+	s.buckets[bucket]++
+	current.count++
+	current.sum += value
+	current.buckets[bucket]++
+}
+
+// Metrics describes the current definitions.
+func (r *Registry) Metrics() []models.LogMetric {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	metrics := make([]models.LogMetric, 0, len(r.set.metrics))
+	for _, def := range r.set.metrics {
+		metrics = append(metrics, describe(def))
+	}
+	return metrics
+}
+
+func describe(def *definition) models.LogMetric {
+	m := models.LogMetric{
+		Name:   def.Name,
+		Type:   def.Type,
+		Help:   def.Help,
+		Labels: append([]string{}, def.labelNames...),
+	}
+	if def.Type == TypeHistogram {
+		m.Buckets = def.Buckets
+	}
+	return m
+}
+
+// Fields returns the log fields the named metric reads, with metadata keys
+// prefixed by "metadata.", and whether the metric exists.
+func (r *Registry) Fields(name string) ([]string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m, ok := r.metrics[name]
+	if !ok {
+		return nil, false
+	}
+	return m.def.fields(), true
+}
+
+// Describe sends no descriptors, which makes the registry an unchecked
+// collector: its metrics change when the definitions are reloaded.
+func (r *Registry) Describe(chan<- *prometheus.Desc) {}
+
+func (r *Registry) Collect(ch chan<- prometheus.Metric) {
+	r.collect(ch, "")
+}
+
+// ForTenant returns a collector of tenantID's series only.
+func (r *Registry) ForTenant(tenantID string) prometheus.Collector {
+	return tenantCollector{registry: r, tenant: tenantID}
+}
+
+type tenantCollector struct {
+	registry *Registry
+	tenant   string
+}
+
+func (c tenantCollector) Describe(chan<- *prometheus.Desc) {}
+
+func (c tenantCollector) Collect(ch chan<- prometheus.Metric) {
+	c.registry.collect(ch, c.tenant)
+}
+
+// collect sends the series of tenantID, or of every tenant when it is empty.
+func (r *Registry) collect(ch chan<- prometheus.Metric, tenantID string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, m := range r.metrics {
+		m.mu.Lock()
+		for _, s := range m.series {
+			if tenantID != "" && s.tenant != tenantID {
+				continue
+			}
+			labels := append(append([]string(nil), s.labels...), s.tenant)
+			if m.def.Type == TypeCounter {
+				ch <- prometheus.MustNewConstMetric(m.def.desc, prometheus.CounterValue, s.count, labels...)
+				continue
+			}
+			cumulative := make(map[float64]uint64, len(m.def.Buckets))
+			var total uint64
+			for i, upper := range m.def.Buckets {
+				total += s.buckets[i]
+				cumulative[upper] = total
+			}
+			ch <- prometheus.MustNewConstHistogram(m.def.desc, uint64(s.count), s.sum, cumulative, labels...)
+		}
+		m.mu.Unlock()
+	}
+}
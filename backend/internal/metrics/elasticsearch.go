@@ -0,0 +1,17 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ObserveElasticsearch records the time one repository operation spent in
+// Elasticsearch and counts it as failed when err is set. Cancellations by the
+// caller are not Elasticsearch errors and are not counted.
+func ObserveElasticsearch(operation string, elapsed time.Duration, err error) {
+	esDuration.WithLabelValues(operation).Observe(elapsed.Seconds())
+	if err != nil && !errors.Is(err, context.Canceled) {
+		esErrors.WithLabelValues(operation).Inc()
+	}
+}
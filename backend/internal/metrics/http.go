@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Middleware records request counts and latency per route template, so
+// /api/logs/:id is one series no matter how many ids are requested.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		httpDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Handler serves the Prometheus exposition format.
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
+
+// HandlerFor serves the metrics of the collector returned for each request,
+// on a registry of its own rather than the default one.
+func HandlerFor(collector func(c *gin.Context) prometheus.Collector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(collector(c))
+		promhttp.HandlerFor(reg, promhttp.HandlerOpts{}).ServeHTTP(c.Writer, c.Request)
+	}
+}
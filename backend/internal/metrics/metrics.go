@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metrics are registered with the default Prometheus registry, which also
+// carries the Go runtime and process collectors.
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "logana_http_requests_total",
+		Help: "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "logana_http_request_duration_seconds",
+		Help:    "HTTP request latency by method and route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	ingestedLogs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "logana_ingest_logs_total",
+		Help: "Logs received for ingestion by source, level and outcome.",
+	}, []string{"source", "level", "outcome"})
+
+	esDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "logana_elasticsearch_request_duration_seconds",
+		Help:    "Elasticsearch request latency by repository operation.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	esErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "logana_elasticsearch_request_errors_total",
+		Help: "Failed Elasticsearch requests by repository operation.",
+	}, []string{"operation"})
+
+	notifications = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "logana_notifications_total",
+		Help: "Alert notifications by channel and outcome.",
+	}, []string{"channel", "outcome"})
+
+	queryCache = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "logana_query_cache_requests_total",
+		Help: "Cacheable queries by repository operation and cache outcome.",
+	}, []string{"operation", "outcome"})
+)
+
+// Ingest outcomes.
+const (
+	OutcomeIndexed  = "indexed"
+	OutcomeBuffered = "buffered"
+	OutcomeDropped  = "dropped"
+	OutcomeRejected = "rejected"
+	OutcomeFailed   = "failed"
+)
+
+// Notification outcomes; failures use OutcomeFailed.
+const OutcomeSent = "sent"
+
+// maxSources bounds the cardinality of the source label; sources seen after
+// the limit is reached are counted as "other".
+const maxSources = 200
+
+var knownLevels = map[string]bool{
+	"TRACE": true,
+	"DEBUG": true,
+	"INFO":  true,
+	"WARN":  true,
+	"ERROR": true,
+	"FATAL": true,
+}
+
+var (
+	sourcesMu sync.Mutex
+	sources   = make(map[string]bool)
+)
+
+func IngestedLog(source, level, outcome string) {
+	ingestedLogs.WithLabelValues(sourceLabel(source), levelLabel(level), outcome).Inc()
+}
+
+func sourceLabel(source string) string {
+	if source == "" {
+		return "unknown"
+	}
+
+	sourcesMu.Lock()
+	defer sourcesMu.Unlock()
+	if sources[source] {
+		return source
+	}
+	if len(sources) >= maxSources {
+		return "other"
+	}
+	sources[source] = true
+	return source
+}
+
+func levelLabel(level string) string {
+	level = strings.ToUpper(level)
+	if knownLevels[level] {
+		return level
+	}
+	return "other"
+}
+
+// Notification counts a send to a configured channel, after any retries.
+func Notification(channel, outcome string) {
+	notifications.WithLabelValues(channel, outcome).Inc()
+}
+
+// QueryCache counts a query answered by the query cache, with outcome hit,
+// miss, coalesced or error.
+func QueryCache(operation, outcome string) {
+	queryCache.WithLabelValues(operation, outcome).Inc()
+}
+
+// RegisterQueue exposes the depth of an in-memory queue, such as the
+// multiline buffers, as logana_queue_depth{queue="<name>"}.
+func RegisterQueue(name string, depth func() float64) {
+	prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name:        "logana_queue_depth",
+		Help:        "Items waiting in logana's in-memory queues.",
+		ConstLabels: prometheus.Labels{"queue": name},
+	}, depth))
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+)
+
+// AlertRule counts the logs matching Query, Level and Source over the last
+// Window and compares the count with Condition every Interval. The alert
+// fires once the condition has held for For.
+type AlertRule struct {
+	ID          string `json:"id"`
+	Tenant      string `json:"tenant"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	Query  string `json:"query,omitempty"`
+	Level  string `json:"level,omitempty"`
+	Source string `json:"source,omitempty"`
+
+	Condition AlertCondition    `json:"condition"`
+	Window    Duration          `json:"window"`
+	For       Duration          `json:"for"`
+	Interval  Duration          `json:"interval"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Disabled  bool              `json:"disabled,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AlertCondition compares the count with Threshold. "no logs in 10m" is
+// written as operator "==" and threshold 0.
+type AlertCondition struct {
+	Operator  string  `json:"operator"`
+	Threshold float64 `json:"threshold"`
+}
+
+type AlertRuleRequest struct {
+	Name        string            `json:"name" binding:"required"`
+	Description string            `json:"description"`
+	Query       string            `json:"query"`
+	Level       string            `json:"level"`
+	Source      string            `json:"source"`
+	Condition   AlertCondition    `json:"condition"`
+	Window      Duration          `json:"window"`
+	For         Duration          `json:"for"`
+	Interval    Duration          `json:"interval"`
+	Labels      map[string]string `json:"labels"`
+	Disabled    bool              `json:"disabled"`
+}
+
+type AlertState string
+
+const (
+	AlertInactive AlertState = "inactive"
+	// AlertPending means the condition holds but not yet for the rule's For.
+	AlertPending  AlertState = "pending"
+	AlertFiring   AlertState = "firing"
+	AlertResolved AlertState = "resolved"
+)
+
+// Alert is the evaluation state of one rule.
+type Alert struct {
+	RuleID   string            `json:"rule_id"`
+	RuleName string            `json:"rule_name"`
+	Tenant   string            `json:"tenant"`
+	Labels   map[string]string `json:"labels,omitempty"`
+
+	State AlertState `json:"state"`
+	// Value is the count seen by the last successful evaluation.
+	Value          float64    `json:"value"`
+	ActiveSince    *time.Time `json:"active_since,omitempty"`
+	FiredAt        *time.Time `json:"fired_at,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	LastEvaluation time.Time  `json:"last_evaluation"`
+	LastError      string     `json:"last_error,omitempty"`
+}
+
+// Silence mutes notifications for the alerts of Tenant whose labels match
+// every matcher between StartsAt and EndsAt. The rule name is matched as
+// "alertname".
+type Silence struct {
+	ID        string            `json:"id"`
+	Tenant    string            `json:"tenant"`
+	Matchers  map[string]string `json:"matchers"`
+	StartsAt  time.Time         `json:"starts_at"`
+	EndsAt    time.Time         `json:"ends_at"`
+	Comment   string            `json:"comment,omitempty"`
+	CreatedBy string            `json:"created_by,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+type SilenceRequest struct {
+	Matchers map[string]string `json:"matchers" binding:"required"`
+	// StartsAt defaults to now.
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   time.Time  `json:"ends_at" binding:"required"`
+	Comment  string     `json:"comment"`
+}
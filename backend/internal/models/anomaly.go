@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Anomaly directions.
+const (
+	AnomalySpike = "spike"
+	AnomalyDrop  = "drop"
+)
+
+// Anomaly is a time bucket in which the volume of one source's logs at one
+// level deviated from its baseline. Score is the deviation in standard
+// deviations, negative for drops.
+type Anomaly struct {
+	ID         string    `json:"id"`
+	Tenant     string    `json:"tenant"`
+	Source     string    `json:"source"`
+	Level      string    `json:"level"`
+	Direction  string    `json:"direction"`
+	Score      float64   `json:"score"`
+	Count      int64     `json:"count"`
+	Expected   float64   `json:"expected"`
+	StdDev     float64   `json:"stddev"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// AnomalyFilter narrows a listing of anomalies. Zero values mean "no bound".
+type AnomalyFilter struct {
+	Source string
+	Level  string
+	From   time.Time
+	To     time.Time
+}
+
+// VolumeBucket is the number of logs of one source and level in the time
+// window starting at Start.
+type VolumeBucket struct {
+	Source string
+	Level  string
+	Start  time.Time
+	Count  int64
+}
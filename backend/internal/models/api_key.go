@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// APIKey is a stored API key. Only a SHA-256 hash of the secret is kept; the
+// plaintext key is returned once, when the key is created or rotated.
+type APIKey struct {
+	ID                   string     `json:"id"`
+	Name                 string     `json:"name"`
+	Tenant               string     `json:"tenant"`
+	Scopes               []string   `json:"scopes"`
+	KeyHash              string     `json:"key_hash,omitempty"`
+	PreviousKeyHash      string     `json:"previous_key_hash,omitempty"`
+	PreviousKeyExpiresAt *time.Time `json:"previous_key_expires_at,omitempty"`
+	ExpiresAt            *time.Time `json:"expires_at,omitempty"`
+	RevokedAt            *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name string `json:"name" binding:"required"`
+	// Tenant defaults to the tenant of the caller creating the key.
+	Tenant    string     `json:"tenant"`
+	Scopes    []string   `json:"scopes" binding:"required"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
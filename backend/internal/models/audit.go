@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// AuditEvent records one administrative or destructive operation. Before and
+// After hold snapshots of the affected resource where it makes sense.
+type AuditEvent struct {
+	ID         string            `json:"id,omitempty"`
+	Timestamp  time.Time         `json:"timestamp"`
+	Action     string            `json:"action"`
+	Outcome    string            `json:"outcome"`
+	Error      string            `json:"error,omitempty"`
+	ActorID    string            `json:"actor_id"`
+	ActorName  string            `json:"actor_name"`
+	ActorKind  string            `json:"actor_kind"`
+	Tenant     string            `json:"tenant"`
+	RemoteAddr string            `json:"remote_addr,omitempty"`
+	UserAgent  string            `json:"user_agent,omitempty"`
+	Resource   string            `json:"resource"`
+	ResourceID string            `json:"resource_id,omitempty"`
+	Before     interface{}       `json:"before,omitempty"`
+	After      interface{}       `json:"after,omitempty"`
+	Details    map[string]string `json:"details,omitempty"`
+}
+
+// AuditFilter narrows an audit query. Zero values mean "no bound".
+type AuditFilter struct {
+	Action     string
+	ActorID    string
+	Resource   string
+	ResourceID string
+	Tenant     string
+	Outcome    string
+	From       time.Time
+	To         time.Time
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -11,6 +12,64 @@ type Log struct {
 	Source    string            `json:"source"`
 	Timestamp time.Time         `json:"timestamp"`
 	Metadata  map[string]string `json:"metadata,omitempty"`
+	PatternID string            `json:"pattern_id,omitempty"`
 	CreatedAt time.Time         `json:"created_at"`
 	UpdatedAt time.Time         `json:"updated_at"`
 }
+
+// LogFilter narrows a scan over the logs index. Zero values mean "no bound".
+type LogFilter struct {
+	Query string
+	// Patterns reads words of Query containing * or ? as wildcards and
+	// words written /like this/ as regular expressions; otherwise the
+	// whole query is free text.
+	Patterns bool
+	// Level matches case-insensitively, Source exactly.
+	Level  string
+	Source string
+	// PatternID matches logs tagged with the pattern.
+	PatternID string
+	From      time.Time
+	To        time.Time
+	// RelativeFrom and RelativeTo keep the specs From and To were resolved
+	// from, such as "now-15m"; they are empty for absolute times.
+	RelativeFrom string
+	RelativeTo   string
+	// RestrictedFields may not be matched by Query, so callers cannot
+	// probe values of fields they are not allowed to see.
+	RestrictedFields []string
+}
+
+// SearchOptions ask a search to say why each log matched.
+type SearchOptions struct {
+	// Highlight marks the matched words of the message and metadata values.
+	Highlight bool
+	// Explain adds the compiled query and each hit's scoring explanation.
+	Explain bool
+}
+
+// LogHit is a log returned by a search with SearchOptions. Highlights are
+// keyed by field, like "message" or "metadata.host", with the matched words
+// wrapped in <em> tags.
+type LogHit struct {
+	Log
+	Score       *float64            `json:"_score"`
+	Highlights  map[string][]string `json:"highlights,omitempty"`
+	Explanation json.RawMessage     `json:"_explanation,omitempty"`
+}
+
+// SearchResult holds the hits of a search and, when explained, the query
+// sent to Elasticsearch.
+type SearchResult struct {
+	Query json.RawMessage `json:"query,omitempty"`
+	Hits  []LogHit        `json:"hits"`
+}
+
+// LogContext is a log together with its neighbours in the same stream, both
+// oldest first. Stream holds the field values that identify the stream.
+type LogContext struct {
+	Log    Log               `json:"log"`
+	Stream map[string]string `json:"stream"`
+	Before []Log             `json:"before"`
+	After  []Log             `json:"after"`
+}
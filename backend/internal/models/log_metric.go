@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// LogMetric describes a metric derived from logs as they are ingested.
+// Every series also carries a tenant label.
+type LogMetric struct {
+	Name    string    `json:"name"`
+	Type    string    `json:"type"`
+	Help    string    `json:"help,omitempty"`
+	Labels  []string  `json:"labels"`
+	Buckets []float64 `json:"buckets,omitempty"`
+}
+
+// MetricSeries is one label set of a log metric over a queried range.
+type MetricSeries struct {
+	Labels map[string]string `json:"labels"`
+	Points []MetricPoint     `json:"points"`
+}
+
+// MetricPoint is the value of a series over the step starting at Time.
+type MetricPoint struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Pattern is a message template mined from ingested logs. Variable tokens
+// of the template are replaced by <*>.
+type Pattern struct {
+	ID        string    `json:"id"`
+	Tenant    string    `json:"tenant"`
+	Template  string    `json:"template"`
+	FirstSeen time.Time `json:"first_seen"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PatternCount is a pattern with the number of logs tagged with it in the
+// requested range.
+type PatternCount struct {
+	Pattern
+	Count int64 `json:"count"`
+}
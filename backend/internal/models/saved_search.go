@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// Saved search visibilities.
+const (
+	VisibilityPrivate = "private"
+	VisibilityTenant  = "tenant"
+)
+
+// SavedSearch is a named search whose ID doubles as a permalink. From and To
+// are RFC 3339 times or relative to the moment the search is opened, like
+// "now-15m" or "now".
+type SavedSearch struct {
+	ID          string      `json:"id"`
+	Tenant      string      `json:"tenant"`
+	Owner       string      `json:"owner"`
+	OwnerName   string      `json:"owner_name,omitempty"`
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Visibility  string      `json:"visibility"`
+	Query       string      `json:"query,omitempty"`
+	Level       string      `json:"level,omitempty"`
+	Source      string      `json:"source,omitempty"`
+	From        string      `json:"from,omitempty"`
+	To          string      `json:"to,omitempty"`
+	Columns     []string    `json:"columns,omitempty"`
+	Sort        []SortField `json:"sort,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+type SortField struct {
+	Field string `json:"field"`
+	// Order is asc or desc.
+	Order string `json:"order"`
+}
+
+type SavedSearchRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	// Visibility defaults to private.
+	Visibility string      `json:"visibility"`
+	Query      string      `json:"query"`
+	Level      string      `json:"level"`
+	Source     string      `json:"source"`
+	From       string      `json:"from"`
+	To         string      `json:"to"`
+	Columns    []string    `json:"columns"`
+	Sort       []SortField `json:"sort"`
+}
+
+// QueryState is a saved search with its time range resolved. Params holds
+// the same state as query parameters for /api/logs/search and
+// /api/logs/export.
+type QueryState struct {
+	Query   string      `json:"query,omitempty"`
+	Level   string      `json:"level,omitempty"`
+	Source  string      `json:"source,omitempty"`
+	From    *time.Time  `json:"from,omitempty"`
+	To      *time.Time  `json:"to,omitempty"`
+	Columns []string    `json:"columns,omitempty"`
+	Sort    []SortField `json:"sort,omitempty"`
+	Params  string      `json:"params"`
+}
+
+// ResolvedSearch is what opening a permalink returns.
+type ResolvedSearch struct {
+	SavedSearch
+	State QueryState `json:"state"`
+}
@@ -0,0 +1,298 @@
+package multiline
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+const (
+	defaultFlushTimeout = 2 * time.Second
+	defaultMaxLines     = 500
+
+	// MetadataLines records how many lines were merged into an event.
+	MetadataLines = "multiline_lines"
+)
+
+// Rule decides which lines belong together for the sources it lists. Set
+// Preset, Start or Continuation: with Start, every line not matching it is
+// appended to the current event; with Continuation, only matching lines are.
+type Rule struct {
+	Sources      []string      `yaml:"sources"`
+	Preset       string        `yaml:"preset"`
+	Start        string        `yaml:"start"`
+	Continuation string        `yaml:"continuation"`
+	FlushTimeout time.Duration `yaml:"flush_timeout"`
+	MaxLines     int           `yaml:"max_lines"`
+
+	start        *regexp.Regexp
+	continuation *regexp.Regexp
+}
+
+func (r *Rule) compile(defaults Config) error {
+	if r.Preset != "" {
+		preset, ok := presets[r.Preset]
+		if !ok {
+			return fmt.Errorf("unknown preset %q", r.Preset)
+		}
+		if r.Start == "" && r.Continuation == "" {
+			r.Start, r.Continuation = preset.Start, preset.Continuation
+		}
+	}
+	if (r.Start == "") == (r.Continuation == "") {
+		return errors.New("exactly one of preset, start or continuation is required")
+	}
+
+	var err error
+	if r.Start != "" {
+		if r.start, err = regexp.Compile(r.Start); err != nil {
+			return fmt.Errorf("invalid start pattern: %w", err)
+		}
+	} else {
+		if r.continuation, err = regexp.Compile(r.Continuation); err != nil {
+			return fmt.Errorf("invalid continuation pattern: %w", err)
+		}
+	}
+
+	if r.FlushTimeout <= 0 {
+		r.FlushTimeout = defaults.FlushTimeout
+	}
+	if r.MaxLines <= 0 {
+		r.MaxLines = defaults.MaxLines
+	}
+	return nil
+}
+
+func (r *Rule) continues(line string) bool {
+	if r.start != nil {
+		return !r.start.MatchString(line)
+	}
+	return r.continuation.MatchString(line)
+}
+
+// Config is the multiline section loaded from MULTILINE_CONFIG. StreamKeys
+// are the metadata keys that, together with the source, identify one stream
+// of lines (for example one host's output).
+type Config struct {
+	FlushTimeout time.Duration `yaml:"flush_timeout"`
+	MaxLines     int           `yaml:"max_lines"`
+	StreamKeys   []string      `yaml:"stream_keys"`
+	Rules        []*Rule       `yaml:"rules"`
+}
+
+func LoadFile(path string) (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("error reading multiline config: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("error parsing multiline config: %w", err)
+	}
+	return cfg, nil
+}
+
+type pending struct {
+	log      models.Log
+	lines    []string
+	rule     *Rule
+	lastSeen time.Time
+}
+
+// Aggregator merges consecutive lines of a stream into single events. Merged
+// events keep the first line's timestamp, level and metadata and are handed
+// to emit when the next event starts, MaxLines is reached, the stream has been
+// idle for FlushTimeout, or the aggregator is closed.
+type Aggregator struct {
+	rules      map[string]*Rule
+	streamKeys []string
+	emit       func(models.Log)
+
+	mu      sync.Mutex
+	streams map[string]*pending
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func New(cfg Config, emit func(models.Log)) (*Aggregator, error) {
+	if cfg.FlushTimeout <= 0 {
+		cfg.FlushTimeout = defaultFlushTimeout
+	}
+	if cfg.MaxLines <= 0 {
+		cfg.MaxLines = defaultMaxLines
+	}
+	if len(cfg.StreamKeys) == 0 {
+		cfg.StreamKeys = []string{"host"}
+	}
+
+	a := &Aggregator{
+		rules:      make(map[string]*Rule),
+		streamKeys: cfg.StreamKeys,
+		emit:       emit,
+		streams:    make(map[string]*pending),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	tick := cfg.FlushTimeout
+	for i, rule := range cfg.Rules {
+		if err := rule.compile(cfg); err != nil {
+			return nil, fmt.Errorf("multiline rule %d: %w", i, err)
+		}
+		if len(rule.Sources) == 0 {
+			return nil, fmt.Errorf("multiline rule %d: sources is required", i)
+		}
+		for _, source := range rule.Sources {
+			if _, dup := a.rules[source]; dup {
+				return nil, fmt.Errorf("multiline rule %d: source %s already has a rule", i, source)
+			}
+			a.rules[source] = rule
+		}
+		if rule.FlushTimeout < tick {
+			tick = rule.FlushTimeout
+		}
+	}
+
+	go a.flushLoop(tick / 2)
+	return a, nil
+}
+
+// Handles reports whether logs from source go through the aggregator.
+func (a *Aggregator) Handles(source string) bool {
+	_, ok := a.rules[source]
+	return ok
+}
+
+// Add buffers log. It returns false, without buffering, when no rule applies
+// to the log's source.
+func (a *Aggregator) Add(log models.Log) bool {
+	rule, ok := a.rules[log.Source]
+	if !ok {
+		return false
+	}
+
+	key := a.streamKey(&log)
+	now := time.Now()
+
+	var ready []models.Log
+	a.mu.Lock()
+	p := a.streams[key]
+	if p != nil && rule.continues(log.Message) {
+		p.lines = append(p.lines, log.Message)
+		p.lastSeen = now
+		if len(p.lines) >= rule.MaxLines {
+			ready = append(ready, p.merge())
+			delete(a.streams, key)
+		}
+	} else {
+		if p != nil {
+			ready = append(ready, p.merge())
+		}
+		a.streams[key] = &pending{log: log, lines: []string{log.Message}, rule: rule, lastSeen: now}
+	}
+	a.mu.Unlock()
+
+	for _, event := range ready {
+		a.emit(event)
+	}
+	return true
+}
+
+// Pending returns the number of streams holding an unflushed event.
+func (a *Aggregator) Pending() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.streams)
+}
+
+// Close stops the flush loop and emits every buffered event.
+func (a *Aggregator) Close() {
+	select {
+	case <-a.stop:
+		return
+	default:
+	}
+	close(a.stop)
+	<-a.done
+
+	a.mu.Lock()
+	ready := make([]models.Log, 0, len(a.streams))
+	for key, p := range a.streams {
+		ready = append(ready, p.merge())
+		delete(a.streams, key)
+	}
+	a.mu.Unlock()
+
+	for _, event := range ready {
+		a.emit(event)
+	}
+}
+
+func (a *Aggregator) flushLoop(interval time.Duration) {
+	defer close(a.done)
+
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.stop:
+			return
+		case now := <-ticker.C:
+			a.flushIdle(now)
+		}
+	}
+}
+
+func (a *Aggregator) flushIdle(now time.Time) {
+	var ready []models.Log
+	a.mu.Lock()
+	for key, p := range a.streams {
+		if now.Sub(p.lastSeen) >= p.rule.FlushTimeout {
+			ready = append(ready, p.merge())
+			delete(a.streams, key)
+		}
+	}
+	a.mu.Unlock()
+
+	for _, event := range ready {
+		a.emit(event)
+	}
+}
+
+func (a *Aggregator) streamKey(log *models.Log) string {
+	var b strings.Builder
+	b.WriteString(log.Source)
+	for _, k := range a.streamKeys {
+		b.WriteByte(0)
+		b.WriteString(log.Metadata[k])
+	}
+	return b.String()
+}
+
+func (p *pending) merge() models.Log {
+	event := p.log
+	if len(p.lines) > 1 {
+		event.Message = strings.Join(p.lines, "\n")
+		metadata := make(map[string]string, len(p.log.Metadata)+1)
+		for k, v := range p.log.Metadata {
+			metadata[k] = v
+		}
+		metadata[MetadataLines] = strconv.Itoa(len(p.lines))
+		event.Metadata = metadata
+	}
+	return event
+}
@@ -0,0 +1,184 @@
+package multiline
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+type collector struct {
+	mu     sync.Mutex
+	events []models.Log
+}
+
+func (c *collector) emit(log models.Log) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, log)
+}
+
+func (c *collector) all() []models.Log {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]models.Log(nil), c.events...)
+}
+
+func newAggregator(t *testing.T, cfg Config) (*Aggregator, *collector) {
+	t.Helper()
+	c := &collector{}
+	a, err := New(cfg, c.emit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(a.Close)
+	return a, c
+}
+
+func feed(a *Aggregator, source string, lines string) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, line := range strings.Split(lines, "\n") {
+		a.Add(models.Log{
+			Source:    source,
+			Message:   line,
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+			Metadata:  map[string]string{"host": "h1"},
+		})
+	}
+}
+
+func TestPresets(t *testing.T) {
+	tests := []struct {
+		preset string
+		input  string
+		want   []int
+	}{
+		{
+			preset: "java",
+			input: `Unhandled exception
+java.lang.IllegalStateException: boom
+	at com.example.Foo.bar(Foo.java:42)
+	at com.example.Main.main(Main.java:7)
+Caused by: java.io.IOException: nope
+	... 2 more
+next event`,
+			want: []int{1, 5, 1},
+		},
+		{
+			preset: "python",
+			input: `Traceback (most recent call last):
+  File "app.py", line 3, in <module>
+    main()
+ValueError: bad value
+next event`,
+			want: []int{4, 1},
+		},
+		{
+			preset: "go",
+			input: `panic: runtime error: index out of range
+
+goroutine 1 [running]:
+main.main()
+	/app/main.go:12 +0x1d
+exit status 2
+next event`,
+			want: []int{6, 1},
+		},
+		{
+			preset: "node",
+			input: `Error: connect ECONNREFUSED 127.0.0.1:5432
+    at TCPConnectWrap.afterConnect [as oncomplete] (net.js:1141:16)
+next event`,
+			want: []int{2, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.preset, func(t *testing.T) {
+			a, c := newAggregator(t, Config{
+				FlushTimeout: time.Hour,
+				Rules:        []*Rule{{Sources: []string{"app"}, Preset: tt.preset}},
+			})
+			feed(a, "app", tt.input)
+			a.Close()
+
+			events := c.all()
+			if len(events) != len(tt.want) {
+				t.Fatalf("got %d events, want %d: %q", len(events), len(tt.want), events)
+			}
+			for i, n := range tt.want {
+				if got := strings.Count(events[i].Message, "\n") + 1; got != n {
+					t.Errorf("event %d has %d lines, want %d: %q", i, got, n, events[i].Message)
+				}
+			}
+		})
+	}
+}
+
+func TestMergedEventKeepsFirstTimestamp(t *testing.T) {
+	a, c := newAggregator(t, Config{
+		FlushTimeout: time.Hour,
+		Rules:        []*Rule{{Sources: []string{"app"}, Start: `^\d{4}-`}},
+	})
+	feed(a, "app", "2024-01-01 first\n  detail\n  more detail")
+	a.Close()
+
+	events := c.all()
+	if len(events) != 1 {
+		t.Fatalf("got %d events", len(events))
+	}
+	want := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if !events[0].Timestamp.Equal(want) {
+		t.Errorf("timestamp = %v, want %v", events[0].Timestamp, want)
+	}
+	if events[0].Metadata[MetadataLines] != "3" {
+		t.Errorf("lines metadata = %q", events[0].Metadata[MetadataLines])
+	}
+}
+
+func TestFlushTimeoutAndStreams(t *testing.T) {
+	a, c := newAggregator(t, Config{
+		FlushTimeout: 30 * time.Millisecond,
+		Rules:        []*Rule{{Sources: []string{"app"}, Preset: "java"}},
+	})
+
+	a.Add(models.Log{Source: "app", Message: "err on h1", Metadata: map[string]string{"host": "h1"}})
+	a.Add(models.Log{Source: "app", Message: "err on h2", Metadata: map[string]string{"host": "h2"}})
+	a.Add(models.Log{Source: "app", Message: "\tat A.b(A.java:1)", Metadata: map[string]string{"host": "h1"}})
+
+	if a.Add(models.Log{Source: "other", Message: "x"}) {
+		t.Error("sources without a rule must not be buffered")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for a.Pending() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	events := c.all()
+	if len(events) != 2 {
+		t.Fatalf("got %d events after timeout, want 2", len(events))
+	}
+	for _, e := range events {
+		if e.Metadata["host"] == "h1" && !strings.Contains(e.Message, "A.java") {
+			t.Errorf("h1 event missing its frame: %q", e.Message)
+		}
+	}
+}
+
+func TestMaxLines(t *testing.T) {
+	a, c := newAggregator(t, Config{
+		FlushTimeout: time.Hour,
+		MaxLines:     3,
+		Rules:        []*Rule{{Sources: []string{"app"}, Preset: "java"}},
+	})
+	feed(a, "app", "e\n\tat a\n\tat b\n\tat c")
+	a.Close()
+
+	events := c.all()
+	if len(events) != 2 || events[0].Metadata[MetadataLines] != "3" {
+		t.Errorf("unexpected events %q", events)
+	}
+}
@@ -0,0 +1,34 @@
+package multiline
+
+// Presets for common stack trace shapes. Each describes which lines continue
+// the previous event; any other line starts a new one.
+var presets = map[string]Rule{
+	// java.lang.IllegalStateException: boom
+	//     at com.example.Foo.bar(Foo.java:42)
+	//     ... 12 more
+	// Caused by: java.io.IOException: nope
+	"java": {
+		Continuation: `^(?:\s+at\s|\s+\.\.\.\s+\d+\s+more|\s*Caused by:|\s*Suppressed:|\s+~\[)`,
+	},
+	// Traceback (most recent call last):
+	//   File "app.py", line 3, in <module>
+	//     main()
+	// ValueError: bad value
+	"python": {
+		Continuation: `^(?:[ \t]+\S|During handling of the above exception|The above exception was the direct cause|[A-Za-z_][\w.]*(?:Error|Exception|Exit|Interrupt|Warning)(?::|$))`,
+	},
+	// panic: runtime error: index out of range
+	//
+	// goroutine 1 [running]:
+	// main.main()
+	//         /app/main.go:12 +0x1d
+	// exit status 2
+	"go": {
+		Continuation: `^(?:\s|$|goroutine \d+ \[|[\w./*()\-]+\(.*\)$|created by |exit status \d+|\[signal )`,
+	},
+	// Error: connect ECONNREFUSED 127.0.0.1:5432
+	//     at TCPConnectWrap.afterConnect [as oncomplete] (net.js:1141:16)
+	"node": {
+		Continuation: `^(?:\s+at\s|\s+\{|\s+\}|\s+\w+: )`,
+	},
+}
@@ -0,0 +1,506 @@
+package notify
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/metrics"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+var ErrUnknownChannel = errors.New("unknown notification channel")
+
+// dispatchTick is how often groups are checked for notifications that are
+// due, and so the resolution of the grouping intervals.
+const dispatchTick = time.Second
+
+// Silences lists silences; every tenant's if tenantID is empty.
+type Silences interface {
+	List(ctx context.Context, tenantID string) ([]models.Silence, error)
+}
+
+// Dispatcher groups alert state changes and sends them to the channels their
+// routes select, retrying failed sends. A group is notified when it is
+// created, after the grouping wait, when its alerts change, at most once per
+// grouping interval, and again every repeat interval while it is firing.
+type Dispatcher struct {
+	silences Silences
+	setup    atomic.Pointer[setup]
+
+	mu     sync.Mutex
+	groups map[string]*group
+	// sendLocks serialize the sends of a group to a channel, so a resolve
+	// being retried cannot overtake the trigger before it. An entry lives
+	// while a send holds or waits for it.
+	sendMu    sync.Mutex
+	sendLocks map[string]*sendLock
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	sends  sync.WaitGroup
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+type sendLock struct {
+	sync.Mutex
+	refs int
+}
+
+type setup struct {
+	cfg       Config
+	notifiers map[string]Notifier
+}
+
+type group struct {
+	key     string
+	tenant  string
+	labels  map[string]string
+	alerts  map[string]models.Alert
+	created time.Time
+	// lastSent is zero until the first notification.
+	lastSent time.Time
+	changed  bool
+}
+
+// NewDispatcher returns a dispatcher for cfg, which may be nil for no
+// channels. silences may be nil.
+func NewDispatcher(cfg *Config, silences Silences) (*Dispatcher, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{
+		silences:  silences,
+		groups:    make(map[string]*group),
+		sendLocks: make(map[string]*sendLock),
+		ctx:       ctx,
+		cancel:    cancel,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	if err := d.Reload(cfg); err != nil {
+		cancel()
+		return nil, err
+	}
+	return d, nil
+}
+
+// Reload replaces the channels, routes and settings. Existing groups keep
+// their alerts.
+func (d *Dispatcher) Reload(cfg *Config) error {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	next := *cfg
+	notifiers, err := build(&next)
+	if err != nil {
+		return err
+	}
+	d.setup.Store(&setup{cfg: next, notifiers: notifiers})
+	return nil
+}
+
+func (d *Dispatcher) Start() {
+	go d.loop()
+}
+
+// Close stops dispatching and waits for running sends until ctx is done,
+// then abandons them.
+func (d *Dispatcher) Close(ctx context.Context) {
+	close(d.stop)
+	<-d.done
+
+	sent := make(chan struct{})
+	go func() {
+		d.sends.Wait()
+		close(sent)
+	}()
+	select {
+	case <-sent:
+	case <-ctx.Done():
+		log.Printf("Warning: abandoning notifications still being sent")
+	}
+	d.cancel()
+}
+
+// Channels returns the configured channels.
+func (d *Dispatcher) Channels() []ChannelConfig {
+	return d.setup.Load().cfg.Channels
+}
+
+// Observe records an alert's new state. Only firing and resolved alerts are
+// notified; an alert resolving before its group was first notified is
+// dropped without a notification.
+func (d *Dispatcher) Observe(alert models.Alert) {
+	if alert.State != models.AlertFiring && alert.State != models.AlertResolved {
+		return
+	}
+	key, groupLabels := groupKey(alert, d.setup.Load().cfg.Grouping.By)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	g, ok := d.groups[key]
+	if !ok {
+		if alert.State == models.AlertResolved {
+			return
+		}
+		g = &group{
+			key:     key,
+			tenant:  alert.Tenant,
+			labels:  groupLabels,
+			alerts:  make(map[string]models.Alert),
+			created: time.Now(),
+		}
+		d.groups[key] = g
+	}
+
+	prev, seen := g.alerts[alert.RuleID]
+	switch {
+	case alert.State == models.AlertResolved && !seen:
+		return
+	case alert.State == models.AlertResolved && g.lastSent.IsZero():
+		delete(g.alerts, alert.RuleID)
+		if len(g.alerts) == 0 {
+			delete(d.groups, key)
+		}
+		return
+	}
+	g.alerts[alert.RuleID] = alert
+	if !seen || prev.State != alert.State {
+		g.changed = true
+	}
+}
+
+// Test sends a synthetic firing notification to one channel, once and
+// without grouping, so its settings can be checked.
+func (d *Dispatcher) Test(ctx context.Context, channel, tenantID string) error {
+	notifier, ok := d.setup.Load().notifiers[channel]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownChannel, channel)
+	}
+
+	now := time.Now().UTC()
+	alert := models.Alert{
+		RuleID:         "test",
+		RuleName:       "Test notification",
+		Tenant:         tenantID,
+		Labels:         map[string]string{"channel": channel},
+		State:          models.AlertFiring,
+		ActiveSince:    &now,
+		FiredAt:        &now,
+		LastEvaluation: now,
+	}
+	key, labels := groupKey(alert, []string{"alertname"})
+	err := notifier.Notify(ctx, Notification{
+		GroupKey:    key,
+		Status:      StatusFiring,
+		Tenant:      tenantID,
+		GroupLabels: labels,
+		Alerts:      []models.Alert{alert},
+		Test:        true,
+	})
+	metrics.Notification(channel, outcome(err))
+	return err
+}
+
+func (d *Dispatcher) loop() {
+	defer close(d.done)
+
+	ticker := time.NewTicker(dispatchTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case now := <-ticker.C:
+			d.flush(now)
+		}
+	}
+}
+
+// flush sends the notifications that are due at now.
+func (d *Dispatcher) flush(now time.Time) {
+	s := d.setup.Load()
+
+	var due []Notification
+	d.mu.Lock()
+	for key, g := range d.groups {
+		if !g.due(now, s.cfg.Grouping) {
+			continue
+		}
+		due = append(due, g.notification())
+		g.lastSent, g.changed = now, false
+		// Resolved alerts are announced once.
+		for id, alert := range g.alerts {
+			if alert.State == models.AlertResolved {
+				delete(g.alerts, id)
+			}
+		}
+		if len(g.alerts) == 0 {
+			delete(d.groups, key)
+		}
+	}
+	d.mu.Unlock()
+
+	if len(due) == 0 {
+		return
+	}
+	silences := d.activeSilences(now)
+	for _, n := range due {
+		d.dispatch(s, unsilenced(n, silences))
+	}
+}
+
+func (g *group) due(now time.Time, grouping Grouping) bool {
+	switch {
+	case g.lastSent.IsZero():
+		return now.Sub(g.created) >= grouping.Wait
+	case g.changed:
+		return now.Sub(g.lastSent) >= grouping.Interval
+	default:
+		return g.firing() && now.Sub(g.lastSent) >= grouping.RepeatInterval
+	}
+}
+
+func (g *group) firing() bool {
+	for _, alert := range g.alerts {
+		if alert.State == models.AlertFiring {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *group) notification() Notification {
+	alerts := sortedAlerts(g.alerts)
+	return Notification{
+		GroupKey:    g.key,
+		Status:      status(alerts),
+		Tenant:      g.tenant,
+		GroupLabels: g.labels,
+		Alerts:      alerts,
+	}
+}
+
+func status(alerts []models.Alert) string {
+	for _, alert := range alerts {
+		if alert.State == models.AlertFiring {
+			return StatusFiring
+		}
+	}
+	return StatusResolved
+}
+
+// activeSilences returns the silences in effect at now. If they cannot be
+// loaded nothing is silenced; a spurious page beats a missed one.
+func (d *Dispatcher) activeSilences(now time.Time) []models.Silence {
+	if d.silences == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(d.ctx, 10*time.Second)
+	defer cancel()
+
+	silences, err := d.silences.List(ctx, "")
+	if err != nil {
+		log.Printf("Failed to load silences, notifying anyway: %v", err)
+		return nil
+	}
+	var active []models.Silence
+	for _, s := range silences {
+		if !now.Before(s.StartsAt) && now.Before(s.EndsAt) {
+			active = append(active, s)
+		}
+	}
+	return active
+}
+
+// Silenced reports whether any of silences mutes alert.
+func Silenced(alert models.Alert, silences []models.Silence) bool {
+	labels := Labels(alert)
+	for _, s := range silences {
+		if s.Tenant == alert.Tenant && len(s.Matchers) > 0 && matches(s.Matchers, labels) {
+			return true
+		}
+	}
+	return false
+}
+
+func unsilenced(n Notification, silences []models.Silence) Notification {
+	if len(silences) == 0 {
+		return n
+	}
+	alerts := make([]models.Alert, 0, len(n.Alerts))
+	for _, alert := range n.Alerts {
+		if !Silenced(alert, silences) {
+			alerts = append(alerts, alert)
+		}
+	}
+	n.Alerts = alerts
+	n.Status = status(alerts)
+	return n
+}
+
+// dispatch sends each channel the alerts routed to it.
+func (d *Dispatcher) dispatch(s *setup, n Notification) {
+	byChannel := make(map[string][]models.Alert)
+	for _, alert := range n.Alerts {
+		for _, channel := range s.channelsFor(alert) {
+			byChannel[channel] = append(byChannel[channel], alert)
+		}
+	}
+
+	for channel, alerts := range byChannel {
+		channelNotification := n
+		channelNotification.Alerts = alerts
+		channelNotification.Status = status(alerts)
+
+		channel, notifier := channel, s.notifiers[channel]
+		d.sends.Add(1)
+		go func() {
+			defer d.sends.Done()
+			defer d.lockSend(n.GroupKey + "/" + channel)()
+
+			if err := d.send(channel, notifier, channelNotification, s.cfg.Retry); err != nil {
+				log.Printf("Failed to notify %s about alert group %s of tenant %s: %v", channel, n.GroupKey, n.Tenant, err)
+			}
+		}()
+	}
+}
+
+// lockSend locks the send lock of key and returns its unlock function,
+// which drops the lock once no other send needs it.
+func (d *Dispatcher) lockSend(key string) func() {
+	d.sendMu.Lock()
+	lock := d.sendLocks[key]
+	if lock == nil {
+		lock = &sendLock{}
+		d.sendLocks[key] = lock
+	}
+	lock.refs++
+	d.sendMu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		d.sendMu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(d.sendLocks, key)
+		}
+		d.sendMu.Unlock()
+	}
+}
+
+func (s *setup) channelsFor(alert models.Alert) []string {
+	if len(s.cfg.Routes) == 0 {
+		channels := make([]string, 0, len(s.notifiers))
+		for _, ch := range s.cfg.Channels {
+			channels = append(channels, ch.Name)
+		}
+		return channels
+	}
+
+	labels := Labels(alert)
+	seen := make(map[string]bool)
+	var channels []string
+	for _, route := range s.cfg.Routes {
+		if !matches(route.Match, labels) {
+			continue
+		}
+		for _, ch := range route.Channels {
+			if !seen[ch] {
+				seen[ch] = true
+				channels = append(channels, ch)
+			}
+		}
+	}
+	return channels
+}
+
+// send tries up to retry.Attempts times, doubling the wait after each
+// failure up to retry.MaxBackoff. Permanent failures are not retried.
+func (d *Dispatcher) send(channel string, notifier Notifier, n Notification, retry Retry) error {
+	backoff := retry.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := notifier.Notify(d.ctx, n)
+		if err == nil || isPermanent(err) || attempt >= retry.Attempts || d.ctx.Err() != nil {
+			metrics.Notification(channel, outcome(err))
+			if err != nil {
+				return fmt.Errorf("attempt %d: %w", attempt, err)
+			}
+			return nil
+		}
+
+		log.Printf("Notifying %s failed, retrying in %s: %v", channel, backoff, err)
+		select {
+		case <-d.ctx.Done():
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > retry.MaxBackoff {
+			backoff = retry.MaxBackoff
+		}
+	}
+}
+
+func outcome(err error) string {
+	if err != nil {
+		return metrics.OutcomeFailed
+	}
+	return metrics.OutcomeSent
+}
+
+// groupKey identifies the group of alert's tenant and grouping labels. It is
+// hashed because PagerDuty limits dedup keys in length.
+func groupKey(alert models.Alert, by []string) (string, map[string]string) {
+	labels := Labels(alert)
+	groupLabels := make(map[string]string, len(by))
+	for _, name := range by {
+		if v, ok := labels[name]; ok {
+			groupLabels[name] = v
+		}
+	}
+
+	names := make([]string, 0, len(groupLabels))
+	for name := range groupLabels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(alert.Tenant)
+	for _, name := range names {
+		fmt.Fprintf(&b, "\x00%s=%s", name, groupLabels[name])
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:12]), groupLabels
+}
+
+var ErrInvalidSilence = errors.New("invalid silence")
+
+// ValidateSilence checks a silence before it is saved.
+func ValidateSilence(s *models.Silence, now time.Time) error {
+	if len(s.Matchers) == 0 {
+		return fmt.Errorf("%w: at least one matcher is required", ErrInvalidSilence)
+	}
+	for name := range s.Matchers {
+		if name == "" {
+			return fmt.Errorf("%w: matcher names must not be empty", ErrInvalidSilence)
+		}
+	}
+	if !s.EndsAt.After(s.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidSilence)
+	}
+	if !s.EndsAt.After(now) {
+		return fmt.Errorf("%w: ends_at is in the past", ErrInvalidSilence)
+	}
+	return nil
+}
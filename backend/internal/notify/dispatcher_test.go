@@ -0,0 +1,174 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+type recorder struct {
+	mu    sync.Mutex
+	sent  []Notification
+	fails int
+}
+
+func (r *recorder) Notify(ctx context.Context, n Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fails > 0 {
+		r.fails--
+		return errors.New("unavailable")
+	}
+	r.sent = append(r.sent, n)
+	return nil
+}
+
+type fakeSilences []models.Silence
+
+func (f fakeSilences) List(ctx context.Context, tenantID string) ([]models.Silence, error) {
+	return f, nil
+}
+
+func newTestDispatcher(t *testing.T, cfg Config, silences Silences) (*Dispatcher, map[string]*recorder) {
+	d, err := NewDispatcher(nil, silences)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorders := make(map[string]*recorder)
+	notifiers := make(map[string]Notifier)
+	for _, ch := range cfg.Channels {
+		recorders[ch.Name] = &recorder{}
+		notifiers[ch.Name] = recorders[ch.Name]
+	}
+	cfg.applyDefaults()
+	cfg.Retry.InitialBackoff, cfg.Retry.MaxBackoff = time.Millisecond, time.Millisecond
+	d.setup.Store(&setup{cfg: cfg, notifiers: notifiers})
+	return d, recorders
+}
+
+func alert(id, name, tenantID string, state models.AlertState, labels map[string]string) models.Alert {
+	return models.Alert{RuleID: id, RuleName: name, Tenant: tenantID, State: state, Labels: labels}
+}
+
+func TestDispatcherGroupsAndDeduplicates(t *testing.T) {
+	d, rec := newTestDispatcher(t, Config{
+		Channels: []ChannelConfig{{Name: "ops"}},
+		Grouping: Grouping{By: []string{"service"}, Wait: time.Minute, Interval: 5 * time.Minute, RepeatInterval: time.Hour},
+	}, nil)
+
+	d.Observe(alert("r1", "errors", "payments", models.AlertFiring, map[string]string{"service": "api"}))
+	d.Observe(alert("r2", "latency", "payments", models.AlertFiring, map[string]string{"service": "api"}))
+	d.Observe(alert("r3", "errors", "search", models.AlertFiring, map[string]string{"service": "api"}))
+	start := time.Now()
+
+	d.flush(start.Add(30 * time.Second))
+	d.sends.Wait()
+	if len(rec["ops"].sent) != 0 {
+		t.Fatalf("expected nothing during the group wait, got %v", rec["ops"].sent)
+	}
+
+	d.flush(start.Add(time.Minute))
+	d.sends.Wait()
+	if sent := rec["ops"].sent; len(sent) != 2 {
+		t.Fatalf("expected one notification per tenant, got %+v", sent)
+	}
+	for _, n := range rec["ops"].sent {
+		if n.Tenant == "payments" && len(n.Alerts) != 2 {
+			t.Fatalf("expected both payments alerts in one notification, got %+v", n)
+		}
+	}
+
+	// The same state again is not news.
+	d.Observe(alert("r1", "errors", "payments", models.AlertFiring, map[string]string{"service": "api"}))
+	d.flush(start.Add(10 * time.Minute))
+	d.sends.Wait()
+	if len(rec["ops"].sent) != 2 {
+		t.Fatalf("expected no repeat before the repeat interval, got %d", len(rec["ops"].sent))
+	}
+
+	d.Observe(alert("r1", "errors", "payments", models.AlertResolved, map[string]string{"service": "api"}))
+	d.flush(start.Add(11 * time.Minute))
+	d.sends.Wait()
+	last := rec["ops"].sent[2]
+	if last.Status != StatusFiring || last.Alerts[0].State != models.AlertResolved {
+		t.Fatalf("expected the resolved alert next to the still firing one, got %+v", last)
+	}
+
+	// Both tenants repeat, in no particular order.
+	sent := len(rec["ops"].sent)
+	d.flush(start.Add(2 * time.Hour))
+	d.sends.Wait()
+	var repeat *Notification
+	for i, n := range rec["ops"].sent[sent:] {
+		if n.Tenant == "payments" {
+			repeat = &rec["ops"].sent[sent+i]
+		}
+	}
+	if repeat == nil || len(repeat.Alerts) != 1 || repeat.Alerts[0].RuleID != "r2" {
+		t.Fatalf("expected a repeat of the firing alert only, got %+v", rec["ops"].sent[sent:])
+	}
+	if n := len(d.sendLocks); n != 0 {
+		t.Fatalf("expected send locks to be dropped after the sends, got %d", n)
+	}
+}
+
+func TestDispatcherRoutesAndSilences(t *testing.T) {
+	silences := fakeSilences{{
+		Tenant:   "payments",
+		Matchers: map[string]string{"alertname": "noisy"},
+		StartsAt: time.Now().Add(-time.Hour),
+		EndsAt:   time.Now().Add(2 * time.Hour),
+	}}
+	d, rec := newTestDispatcher(t, Config{
+		Channels: []ChannelConfig{{Name: "slack"}, {Name: "pager"}},
+		Routes: []Route{
+			{Channels: []string{"slack"}},
+			{Channels: []string{"pager"}, Match: map[string]string{"severity": "critical"}},
+		},
+		Grouping: Grouping{By: []string{"tenant"}},
+	}, silences)
+
+	d.Observe(alert("r1", "errors", "payments", models.AlertFiring, map[string]string{"severity": "critical"}))
+	d.Observe(alert("r2", "slow", "payments", models.AlertFiring, nil))
+	d.Observe(alert("r3", "noisy", "payments", models.AlertFiring, map[string]string{"severity": "critical"}))
+	d.flush(time.Now().Add(time.Hour))
+	d.sends.Wait()
+
+	if sent := rec["slack"].sent; len(sent) != 1 || len(sent[0].Alerts) != 2 {
+		t.Fatalf("expected slack to get the two unsilenced alerts, got %+v", sent)
+	}
+	if sent := rec["pager"].sent; len(sent) != 1 || len(sent[0].Alerts) != 1 || sent[0].Alerts[0].RuleID != "r1" {
+		t.Fatalf("expected the pager to get the critical alert only, got %+v", sent)
+	}
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	d, rec := newTestDispatcher(t, Config{
+		Channels: []ChannelConfig{{Name: "ops"}},
+		Retry:    Retry{Attempts: 3},
+	}, nil)
+	rec["ops"].fails = 2
+
+	d.Observe(alert("r1", "errors", "payments", models.AlertFiring, nil))
+	d.flush(time.Now().Add(time.Hour))
+	d.sends.Wait()
+	if len(rec["ops"].sent) != 1 {
+		t.Fatalf("expected the third attempt to succeed, got %d sends", len(rec["ops"].sent))
+	}
+}
+
+func TestDispatcherDropsAlertsResolvedBeforeNotifying(t *testing.T) {
+	d, rec := newTestDispatcher(t, Config{Channels: []ChannelConfig{{Name: "ops"}}}, nil)
+
+	d.Observe(alert("r1", "errors", "payments", models.AlertFiring, nil))
+	d.Observe(alert("r1", "errors", "payments", models.AlertResolved, nil))
+	d.flush(time.Now().Add(time.Hour))
+	d.sends.Wait()
+	if len(rec["ops"].sent) != 0 || len(d.groups) != 0 {
+		t.Fatalf("expected a flap inside the group wait to stay quiet, got %+v", rec["ops"].sent)
+	}
+}
@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Email sends a plain text message over SMTP, upgrading to TLS when the
+// server offers STARTTLS. Credentials are only sent over TLS, or to a
+// server on localhost.
+type Email struct {
+	addr     string
+	host     string
+	from     string
+	to       []string
+	username string
+	password string
+}
+
+func NewEmail(addr, from string, to []string, username, password string) (*Email, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("smtp: %q is not host:port", addr)
+	}
+	if _, err := mail.ParseAddress(from); err != nil {
+		return nil, fmt.Errorf("from: %w", err)
+	}
+	if len(to) == 0 {
+		return nil, errors.New("to: no recipients")
+	}
+	for _, rcpt := range to {
+		if _, err := mail.ParseAddress(rcpt); err != nil {
+			return nil, fmt.Errorf("to: %w", err)
+		}
+	}
+	return &Email{addr: addr, host: host, from: from, to: to, username: username, password: password}, nil
+}
+
+func (e *Email) Notify(ctx context.Context, n Notification) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", e.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(httpClient.Timeout)
+	}
+	conn.SetDeadline(deadline)
+
+	c, err := smtp.NewClient(conn, e.host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: e.host}); err != nil {
+			return err
+		}
+	}
+	if e.username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.username, e.password, e.host)); err != nil {
+			return permanent(fmt.Errorf("smtp auth: %w", err))
+		}
+	}
+
+	if err := c.Mail(mailAddress(e.from)); err != nil {
+		return err
+	}
+	for _, rcpt := range e.to {
+		if err := c.Rcpt(mailAddress(rcpt)); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(e.message(n)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (e *Email) message(n Notification) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", e.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", n.Summary()))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+
+	for _, alert := range n.Alerts {
+		fmt.Fprintf(&b, "%s is %s: %s\r\n", alert.RuleName, alert.State, alertText(alert))
+		for k, v := range alert.Labels {
+			fmt.Fprintf(&b, "  %s: %s\r\n", k, v)
+		}
+		if alert.LastError != "" {
+			fmt.Fprintf(&b, "  last error: %s\r\n", alert.LastError)
+		}
+		b.WriteString("\r\n")
+	}
+	fmt.Fprintf(&b, "Tenant: %s\r\nGroup: %s\r\n", n.Tenant, n.GroupKey)
+	return b.Bytes()
+}
+
+// mailAddress strips the display name from an address.
+func mailAddress(addr string) string {
+	if a, err := mail.ParseAddress(addr); err == nil {
+		return a.Address
+	}
+	return addr
+}
@@ -0,0 +1,279 @@
+// Package notify delivers alert state changes to external channels:
+// generic webhooks, Slack, email and PagerDuty.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+// Notification statuses.
+const (
+	StatusFiring   = "firing"
+	StatusResolved = "resolved"
+)
+
+// Notification is one message about a group of alerts of the same tenant.
+// Its status is firing while any of the alerts is.
+type Notification struct {
+	GroupKey    string            `json:"group_key"`
+	Status      string            `json:"status"`
+	Tenant      string            `json:"tenant"`
+	GroupLabels map[string]string `json:"group_labels"`
+	Alerts      []models.Alert    `json:"alerts"`
+	// Test is set on notifications sent through the test endpoint.
+	Test bool `json:"test,omitempty"`
+}
+
+// Summary is a one-line description, used as the Slack text, email subject
+// and PagerDuty summary.
+func (n Notification) Summary() string {
+	names := make([]string, 0, len(n.Alerts))
+	firing := 0
+	for _, alert := range n.Alerts {
+		names = append(names, alert.RuleName)
+		if alert.State == models.AlertFiring {
+			firing++
+		}
+	}
+
+	prefix := fmt.Sprintf("[%s:%d]", strings.ToUpper(n.Status), len(n.Alerts))
+	if n.Status == StatusFiring {
+		prefix = fmt.Sprintf("[FIRING:%d]", firing)
+	}
+	if n.Test {
+		prefix = "[TEST] " + prefix
+	}
+	return fmt.Sprintf("%s %s (tenant %s)", prefix, strings.Join(names, ", "), n.Tenant)
+}
+
+// Notifier sends a notification to one channel.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// Channel types.
+const (
+	TypeWebhook   = "webhook"
+	TypeSlack     = "slack"
+	TypeEmail     = "email"
+	TypePagerDuty = "pagerduty"
+)
+
+// Config is the notification file: the channels, which alerts go to which
+// channel, and how alerts are grouped and retried.
+type Config struct {
+	Channels []ChannelConfig `yaml:"channels"`
+	// Routes send the alerts matching them to their channels. Without
+	// routes every alert goes to every channel.
+	Routes   []Route  `yaml:"routes"`
+	Grouping Grouping `yaml:"grouping"`
+	Retry    Retry    `yaml:"retry"`
+}
+
+// ChannelConfig describes a channel. Which fields apply depends on Type.
+type ChannelConfig struct {
+	Name string `yaml:"name" json:"name"`
+	Type string `yaml:"type" json:"type"`
+
+	// URL is the webhook or Slack incoming webhook URL, or overrides the
+	// PagerDuty Events API endpoint.
+	URL     string            `yaml:"url" json:"-"`
+	Headers map[string]string `yaml:"headers" json:"-"`
+	// Secret signs webhook bodies, see Webhook.
+	Secret string `yaml:"secret" json:"-"`
+	// Body is a text/template rendered with the Notification; the default
+	// is the notification as JSON.
+	Body string `yaml:"body" json:"-"`
+
+	// SMTP is the host:port of the mail server.
+	SMTP     string   `yaml:"smtp" json:"-"`
+	From     string   `yaml:"from" json:"-"`
+	To       []string `yaml:"to" json:"-"`
+	Username string   `yaml:"username" json:"-"`
+	Password string   `yaml:"password" json:"-"`
+
+	RoutingKey string `yaml:"routing_key" json:"-"`
+	// Severity is the PagerDuty severity, error by default.
+	Severity string `yaml:"severity" json:"-"`
+}
+
+// Route selects alerts by tenant and labels. The rule name matches as
+// "alertname" and the tenant as "tenant".
+type Route struct {
+	Channels []string          `yaml:"channels"`
+	Match    map[string]string `yaml:"match"`
+}
+
+// Grouping batches alerts into one notification per group. The tenant is
+// always part of the group.
+type Grouping struct {
+	// By lists the labels alerts are grouped by; alerts without them share
+	// a group. The default groups by rule.
+	By []string `yaml:"by"`
+	// Wait is how long a new group waits for more alerts before the first
+	// notification.
+	Wait time.Duration `yaml:"wait"`
+	// Interval is the minimum time between notifications about changes to
+	// a group.
+	Interval time.Duration `yaml:"interval"`
+	// RepeatInterval resends a group that is still firing without changes.
+	RepeatInterval time.Duration `yaml:"repeat_interval"`
+}
+
+// Retry backs off exponentially between failed sends.
+type Retry struct {
+	Attempts       int           `yaml:"attempts"`
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+}
+
+// LoadFile reads a notification file. References to environment variables,
+// written $VAR or ${VAR}, are expanded first so secrets can stay out of the
+// file.
+func LoadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading notification config: %w", err)
+	}
+
+	var cfg Config
+	dec := yaml.NewDecoder(strings.NewReader(os.ExpandEnv(string(data))))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("error parsing notification config: %w", err)
+	}
+	if _, err := build(&cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// applyDefaults fills in the unset grouping and retry settings.
+func (c *Config) applyDefaults() {
+	if len(c.Grouping.By) == 0 {
+		c.Grouping.By = []string{"alertname"}
+	}
+	if c.Grouping.Wait == 0 {
+		c.Grouping.Wait = 30 * time.Second
+	}
+	if c.Grouping.Interval == 0 {
+		c.Grouping.Interval = 5 * time.Minute
+	}
+	if c.Grouping.RepeatInterval == 0 {
+		c.Grouping.RepeatInterval = 4 * time.Hour
+	}
+	if c.Retry.Attempts == 0 {
+		c.Retry.Attempts = 5
+	}
+	if c.Retry.InitialBackoff == 0 {
+		c.Retry.InitialBackoff = time.Second
+	}
+	if c.Retry.MaxBackoff == 0 {
+		c.Retry.MaxBackoff = time.Minute
+	}
+}
+
+// build validates cfg, fills in its defaults and creates its notifiers.
+func build(cfg *Config) (map[string]Notifier, error) {
+	cfg.applyDefaults()
+	if cfg.Grouping.Wait < 0 || cfg.Grouping.Interval < 0 || cfg.Grouping.RepeatInterval < 0 {
+		return nil, errors.New("grouping: intervals must not be negative")
+	}
+	if cfg.Retry.Attempts < 1 {
+		return nil, errors.New("retry.attempts: must be at least 1")
+	}
+	if cfg.Retry.InitialBackoff < 0 || cfg.Retry.MaxBackoff < cfg.Retry.InitialBackoff {
+		return nil, errors.New("retry: max_backoff must be at least initial_backoff")
+	}
+
+	notifiers := make(map[string]Notifier, len(cfg.Channels))
+	for i, ch := range cfg.Channels {
+		if ch.Name == "" {
+			return nil, fmt.Errorf("channel %d: name is required", i)
+		}
+		if _, ok := notifiers[ch.Name]; ok {
+			return nil, fmt.Errorf("channel %s: duplicate name", ch.Name)
+		}
+		n, err := newNotifier(ch)
+		if err != nil {
+			return nil, fmt.Errorf("channel %s: %w", ch.Name, err)
+		}
+		notifiers[ch.Name] = n
+	}
+
+	for i, route := range cfg.Routes {
+		if len(route.Channels) == 0 {
+			return nil, fmt.Errorf("route %d: no channels", i)
+		}
+		for _, name := range route.Channels {
+			if _, ok := notifiers[name]; !ok {
+				return nil, fmt.Errorf("route %d: unknown channel %q", i, name)
+			}
+		}
+	}
+	return notifiers, nil
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+func newNotifier(ch ChannelConfig) (Notifier, error) {
+	switch ch.Type {
+	case TypeWebhook:
+		return NewWebhook(ch.URL, ch.Secret, ch.Body, ch.Headers)
+	case TypeSlack:
+		return NewSlack(ch.URL)
+	case TypeEmail:
+		return NewEmail(ch.SMTP, ch.From, ch.To, ch.Username, ch.Password)
+	case TypePagerDuty:
+		return NewPagerDuty(ch.URL, ch.RoutingKey, ch.Severity)
+	default:
+		return nil, fmt.Errorf("unknown type %q", ch.Type)
+	}
+}
+
+// Labels are the labels alerts are routed, grouped and silenced by: the
+// rule's labels plus alertname and tenant.
+func Labels(alert models.Alert) map[string]string {
+	labels := make(map[string]string, len(alert.Labels)+2)
+	for k, v := range alert.Labels {
+		labels[k] = v
+	}
+	labels["alertname"] = alert.RuleName
+	labels["tenant"] = alert.Tenant
+	return labels
+}
+
+func matches(matchers, labels map[string]string) bool {
+	for k, v := range matchers {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedAlerts orders alerts by rule name so notifications are stable.
+func sortedAlerts(alerts map[string]models.Alert) []models.Alert {
+	list := make([]models.Alert, 0, len(alerts))
+	for _, alert := range alerts {
+		list = append(list, alert)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].RuleName != list[j].RuleName {
+			return list[i].RuleName < list[j].RuleName
+		}
+		return list[i].RuleID < list[j].RuleID
+	})
+	return list
+}
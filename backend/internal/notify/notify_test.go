@@ -0,0 +1,222 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func testNotification() Notification {
+	fired := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	return Notification{
+		GroupKey:    "abc123",
+		Status:      StatusFiring,
+		Tenant:      "payments",
+		GroupLabels: map[string]string{"alertname": "errors"},
+		Alerts: []models.Alert{{
+			RuleID:   "r1",
+			RuleName: "errors",
+			Tenant:   "payments",
+			Labels:   map[string]string{"severity": "critical"},
+			State:    models.AlertFiring,
+			Value:    42,
+			FiredAt:  &fired,
+		}},
+	}
+}
+
+type capture struct {
+	mu      sync.Mutex
+	headers http.Header
+	body    []byte
+	status  int
+	calls   int
+}
+
+func (c *capture) server(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.calls++
+		c.headers = r.Header.Clone()
+		c.body, _ = io.ReadAll(r.Body)
+		if c.status != 0 {
+			w.WriteHeader(c.status)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWebhookSignsTemplatedBody(t *testing.T) {
+	var c capture
+	srv := c.server(t)
+
+	w, err := NewWebhook(srv.URL, "s3cret", `{"text": {{ json .Summary }}, "value": {{ (index .Alerts 0).Value }}}`, map[string]string{"X-Team": "payments"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Notify(context.Background(), testNotification()); err != nil {
+		t.Fatal(err)
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(c.body, &body); err != nil {
+		t.Fatalf("expected JSON, got %s: %v", c.body, err)
+	}
+	if body["value"] != 42.0 || !strings.HasPrefix(body["text"].(string), "[FIRING:1] errors") {
+		t.Fatalf("unexpected body %v", body)
+	}
+	if c.headers.Get("X-Team") != "payments" {
+		t.Fatalf("expected the configured header, got %v", c.headers)
+	}
+	want := "sha256=" + Sign([]byte("s3cret"), c.headers.Get(TimestampHeader), c.body)
+	if got := c.headers.Get(SignatureHeader); got != want {
+		t.Fatalf("expected signature %s, got %s", want, got)
+	}
+}
+
+func TestPostClassifiesFailures(t *testing.T) {
+	c := capture{status: http.StatusServiceUnavailable}
+	srv := c.server(t)
+	w, _ := NewWebhook(srv.URL, "", "", nil)
+
+	if err := w.Notify(context.Background(), testNotification()); err == nil || isPermanent(err) {
+		t.Fatalf("expected a retryable error, got %v", err)
+	}
+	c.status = http.StatusBadRequest
+	if err := w.Notify(context.Background(), testNotification()); !isPermanent(err) {
+		t.Fatalf("expected a permanent error, got %v", err)
+	}
+}
+
+func TestSlackAndPagerDutyPayloads(t *testing.T) {
+	var slackReq, pdReq capture
+	slackSrv, pdSrv := slackReq.server(t), pdReq.server(t)
+
+	slack, _ := NewSlack(slackSrv.URL)
+	if err := slack.Notify(context.Background(), testNotification()); err != nil {
+		t.Fatal(err)
+	}
+	var msg slackPayload
+	json.Unmarshal(slackReq.body, &msg)
+	if len(msg.Attachments) != 1 || msg.Attachments[0].Color != "danger" || msg.Attachments[0].Fields[0].Value != "critical" {
+		t.Fatalf("unexpected slack message %s", slackReq.body)
+	}
+
+	pd, err := NewPagerDuty(pdSrv.URL, "routing", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved := testNotification()
+	resolved.Status = StatusResolved
+	for _, n := range []Notification{testNotification(), resolved} {
+		if err := pd.Notify(context.Background(), n); err != nil {
+			t.Fatal(err)
+		}
+		var event pagerDutyEvent
+		json.Unmarshal(pdReq.body, &event)
+		if event.RoutingKey != "routing" || event.DedupKey != "abc123" {
+			t.Fatalf("unexpected event %s", pdReq.body)
+		}
+		if n.Status == StatusFiring && (event.EventAction != "trigger" || event.Payload.Severity != "error") {
+			t.Fatalf("expected a trigger, got %s", pdReq.body)
+		}
+		if n.Status == StatusResolved && (event.EventAction != "resolve" || event.Payload != nil) {
+			t.Fatalf("expected a bare resolve, got %s", pdReq.body)
+		}
+	}
+}
+
+// smtpServer accepts one message without TLS or authentication and returns
+// its data.
+func smtpServer(t *testing.T) (string, <-chan string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { io.WriteString(conn, s+"\r\n") }
+
+		reply("220 localhost ESMTP")
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					messages <- data.String()
+					reply("250 queued")
+					continue
+				}
+				data.WriteString(line)
+				continue
+			}
+			switch cmd := strings.ToUpper(strings.Fields(line)[0]); cmd {
+			case "EHLO", "HELO":
+				reply("250 localhost")
+			case "DATA":
+				inData = true
+				reply("354 go ahead")
+			case "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+	return ln.Addr().String(), messages
+}
+
+func TestEmailSendsOverSMTP(t *testing.T) {
+	addr, messages := smtpServer(t)
+
+	e, err := NewEmail(addr, "Logana <logana@example.com>", []string{"oncall@example.com"}, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.Notify(ctx, testNotification()); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := <-messages
+	if !strings.Contains(msg, "Subject: [FIRING:1] errors") || !strings.Contains(msg, "errors is firing: value 42") {
+		t.Fatalf("unexpected message:\n%s", msg)
+	}
+}
+
+func TestBuildRejectsUnknownRouteChannels(t *testing.T) {
+	cfg := &Config{
+		Channels: []ChannelConfig{{Name: "ops", Type: TypeSlack, URL: "https://hooks.example.com/x"}},
+		Routes:   []Route{{Channels: []string{"ops", "missing"}}},
+	}
+	if _, err := build(cfg); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Fatalf("expected the unknown channel to be rejected, got %v", err)
+	}
+}
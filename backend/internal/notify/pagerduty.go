@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+var pagerDutySeverities = map[string]bool{"critical": true, "error": true, "warning": true, "info": true}
+
+// PagerDuty sends Events API v2 trigger and resolve events. The group key
+// is the dedup key, so a group is one PagerDuty incident.
+type PagerDuty struct {
+	url        string
+	routingKey string
+	severity   string
+}
+
+func NewPagerDuty(rawURL, routingKey, severity string) (*PagerDuty, error) {
+	if rawURL == "" {
+		rawURL = pagerDutyEventsURL
+	}
+	if err := checkURL(rawURL); err != nil {
+		return nil, err
+	}
+	if routingKey == "" {
+		return nil, errors.New("routing_key is required")
+	}
+	if severity == "" {
+		severity = "error"
+	}
+	if !pagerDutySeverities[severity] {
+		return nil, fmt.Errorf("unknown severity %q", severity)
+	}
+	return &PagerDuty{url: rawURL, routingKey: routingKey, severity: severity}, nil
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string      `json:"summary"`
+	Source        string      `json:"source"`
+	Severity      string      `json:"severity"`
+	Group         string      `json:"group,omitempty"`
+	CustomDetails interface{} `json:"custom_details,omitempty"`
+}
+
+func (p *PagerDuty) Notify(ctx context.Context, n Notification) error {
+	event := pagerDutyEvent{
+		RoutingKey:  p.routingKey,
+		EventAction: "trigger",
+		DedupKey:    n.GroupKey,
+	}
+	if n.Status == StatusResolved {
+		event.EventAction = "resolve"
+	} else {
+		event.Payload = &pagerDutyPayload{
+			// PagerDuty truncates longer summaries.
+			Summary:       truncate(n.Summary(), 1024),
+			Source:        "logana",
+			Severity:      p.severity,
+			Group:         n.Tenant,
+			CustomDetails: map[string]interface{}{"alerts": n.Alerts, "group_labels": n.GroupLabels},
+		}
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return permanent(fmt.Errorf("error encoding pagerduty event: %w", err))
+	}
+	return post(ctx, p.url, body, map[string]string{"Content-Type": "application/json"})
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
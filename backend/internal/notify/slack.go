@@ -0,0 +1,84 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+// Slack posts to a Slack incoming webhook, or anything accepting its payload
+// such as Mattermost.
+type Slack struct {
+	url string
+}
+
+func NewSlack(rawURL string) (*Slack, error) {
+	if err := checkURL(rawURL); err != nil {
+		return nil, err
+	}
+	return &Slack{url: rawURL}, nil
+}
+
+type slackPayload struct {
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+type slackAttachment struct {
+	Color  string       `json:"color"`
+	Title  string       `json:"title"`
+	Text   string       `json:"text"`
+	Fields []slackField `json:"fields,omitempty"`
+}
+
+type slackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+func (s *Slack) Notify(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(slackMessage(n))
+	if err != nil {
+		return permanent(fmt.Errorf("error encoding slack message: %w", err))
+	}
+	return post(ctx, s.url, body, map[string]string{"Content-Type": "application/json"})
+}
+
+func slackMessage(n Notification) slackPayload {
+	payload := slackPayload{Text: n.Summary()}
+	for _, alert := range n.Alerts {
+		color := "good"
+		if alert.State == models.AlertFiring {
+			color = "danger"
+		}
+
+		var fields []slackField
+		for k, v := range alert.Labels {
+			fields = append(fields, slackField{Title: k, Value: v, Short: true})
+		}
+		payload.Attachments = append(payload.Attachments, slackAttachment{
+			Color:  color,
+			Title:  fmt.Sprintf("%s is %s", alert.RuleName, alert.State),
+			Text:   alertText(alert),
+			Fields: fields,
+		})
+	}
+	return payload
+}
+
+// alertText describes an alert's latest evaluation in one line.
+func alertText(alert models.Alert) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "value %g", alert.Value)
+	if alert.FiredAt != nil {
+		fmt.Fprintf(&b, ", firing since %s", alert.FiredAt.UTC().Format("2006-01-02 15:04:05 UTC"))
+	}
+	if alert.ResolvedAt != nil {
+		fmt.Fprintf(&b, ", resolved at %s", alert.ResolvedAt.UTC().Format("2006-01-02 15:04:05 UTC"))
+	}
+	return b.String()
+}
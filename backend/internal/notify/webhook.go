@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"text/template"
+	"time"
+)
+
+// Webhook signature headers. The signature is the hex HMAC-SHA256 of the
+// timestamp, a dot and the body, so receivers can reject replays.
+const (
+	SignatureHeader = "X-Logana-Signature"
+	TimestampHeader = "X-Logana-Timestamp"
+)
+
+// Webhook posts the notification to a URL, as JSON or rendered through a
+// template.
+type Webhook struct {
+	url     string
+	secret  []byte
+	body    *template.Template
+	headers map[string]string
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+func NewWebhook(rawURL, secret, body string, headers map[string]string) (*Webhook, error) {
+	if err := checkURL(rawURL); err != nil {
+		return nil, err
+	}
+
+	w := &Webhook{url: rawURL, headers: headers}
+	if secret != "" {
+		w.secret = []byte(secret)
+	}
+	if body != "" {
+		tmpl, err := template.New("body").Funcs(templateFuncs).Option("missingkey=error").Parse(body)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing body template: %w", err)
+		}
+		w.body = tmpl
+	}
+	return w, nil
+}
+
+func (w *Webhook) Notify(ctx context.Context, n Notification) error {
+	var body bytes.Buffer
+	if w.body != nil {
+		if err := w.body.Execute(&body, n); err != nil {
+			return permanent(fmt.Errorf("error rendering body: %w", err))
+		}
+	} else if err := json.NewEncoder(&body).Encode(n); err != nil {
+		return permanent(fmt.Errorf("error encoding notification: %w", err))
+	}
+
+	headers := map[string]string{"Content-Type": "application/json"}
+	for k, v := range w.headers {
+		headers[k] = v
+	}
+	if w.secret != nil {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		headers[TimestampHeader] = ts
+		headers[SignatureHeader] = "sha256=" + Sign(w.secret, ts, body.Bytes())
+	}
+	return post(ctx, w.url, body.Bytes(), headers)
+}
+
+// Sign returns the webhook signature of body sent at timestamp.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func checkURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an http or https URL", rawURL)
+	}
+	return nil
+}
+
+// post sends body and treats any 2xx as delivered. Rate limiting and server
+// errors are retried; other statuses are not.
+func post(ctx context.Context, rawURL string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return permanent(err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("unexpected status %d: %s", res.StatusCode, bytes.TrimSpace(msg))
+	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500 {
+		return err
+	}
+	return permanent(err)
+}
+
+// permanentError marks a failure that retrying cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+func permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
@@ -0,0 +1,285 @@
+// Package patterns clusters log messages into templates with the Drain
+// algorithm. A message is routed through a fixed-depth tree by its token
+// count and leading tokens, then compared with the templates in the leaf it
+// reaches; tokens that differ between a template and a similar enough
+// message become wildcards.
+package patterns
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+// Wildcard replaces the variable tokens of a template.
+const Wildcard = "<*>"
+
+const (
+	defaultDepth       = 2
+	defaultSimilarity  = 0.5
+	defaultMaxChildren = 100
+	defaultMaxPatterns = 5000
+
+	idLength = 16
+)
+
+type Config struct {
+	// Depth is how many leading tokens route a message through the tree.
+	Depth int
+	// Similarity is the share of a template's constant tokens a message
+	// must repeat to join it, between 0 and 1.
+	Similarity float64
+	// MaxChildren caps the branches of a tree node; further tokens share
+	// a wildcard branch.
+	MaxChildren int
+	// MaxPatterns caps the patterns of one miner. Messages that would need
+	// a new pattern past it are left untagged.
+	MaxPatterns int
+}
+
+func (c Config) withDefaults() Config {
+	if c.Depth == 0 {
+		c.Depth = defaultDepth
+	}
+	if c.Similarity == 0 {
+		c.Similarity = defaultSimilarity
+	}
+	if c.MaxChildren == 0 {
+		c.MaxChildren = defaultMaxChildren
+	}
+	if c.MaxPatterns == 0 {
+		c.MaxPatterns = defaultMaxPatterns
+	}
+	return c
+}
+
+// Validate reports settings the miner cannot work with. Zero values select
+// the defaults.
+func (c Config) Validate() error {
+	var errs []error
+	if c.Depth < 0 {
+		errs = append(errs, errors.New("depth must not be negative"))
+	}
+	if c.Similarity < 0 || c.Similarity > 1 {
+		errs = append(errs, errors.New("similarity must be between 0 and 1"))
+	}
+	if c.MaxChildren < 0 || c.MaxChildren == 1 {
+		errs = append(errs, errors.New("max_children must be at least 2"))
+	}
+	if c.MaxPatterns < 0 {
+		errs = append(errs, errors.New("max_patterns must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// Miner mines the patterns of one tenant. It is safe for concurrent use.
+type Miner struct {
+	cfg    Config
+	tenant string
+
+	mu    sync.Mutex
+	root  map[int]*node
+	byID  map[string]*cluster
+	count int
+}
+
+type node struct {
+	children map[string]*node
+	clusters []*cluster
+}
+
+type cluster struct {
+	pattern models.Pattern
+	tokens  []string
+}
+
+func NewMiner(cfg Config, tenant string) *Miner {
+	return &Miner{
+		cfg:    cfg.withDefaults(),
+		tenant: tenant,
+		root:   make(map[int]*node),
+		byID:   make(map[string]*cluster),
+	}
+}
+
+// Restore adds patterns mined earlier, keeping their IDs.
+func (m *Miner) Restore(patterns []models.Pattern) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, p := range patterns {
+		if _, ok := m.byID[p.ID]; ok {
+			continue
+		}
+		c := &cluster{pattern: p, tokens: strings.Fields(p.Template)}
+		leaf := m.leaf(c.tokens, true)
+		leaf.clusters = append(leaf.clusters, c)
+		m.byID[p.ID] = c
+		m.count++
+	}
+}
+
+// Add assigns message to the most similar pattern, generalizing its
+// template if needed, or to a new pattern. changed reports whether the
+// pattern is new or its template changed. Add returns nil if the message
+// would need a new pattern but the miner is full.
+func (m *Miner) Add(message string, now time.Time) (pattern *models.Pattern, changed bool) {
+	tokens := strings.Fields(message)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if c := m.match(tokens); c != nil {
+		for i, token := range tokens {
+			if c.tokens[i] != token && c.tokens[i] != Wildcard {
+				c.tokens[i] = Wildcard
+				changed = true
+			}
+		}
+		if changed {
+			c.pattern.Template = strings.Join(c.tokens, " ")
+			c.pattern.UpdatedAt = now
+		}
+		p := c.pattern
+		return &p, changed
+	}
+
+	if m.count >= m.cfg.MaxPatterns {
+		return nil, false
+	}
+	template := strings.Join(tokens, " ")
+	c := &cluster{
+		pattern: models.Pattern{
+			ID:        patternID(m.tenant, template),
+			Tenant:    m.tenant,
+			Template:  template,
+			FirstSeen: now,
+			UpdatedAt: now,
+		},
+		tokens: tokens,
+	}
+	leaf := m.leaf(tokens, true)
+	leaf.clusters = append(leaf.clusters, c)
+	m.byID[c.pattern.ID] = c
+	m.count++
+
+	p := c.pattern
+	return &p, true
+}
+
+// Patterns returns the patterns known to the miner by ID.
+func (m *Miner) Patterns() map[string]models.Pattern {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	patterns := make(map[string]models.Pattern, len(m.byID))
+	for id, c := range m.byID {
+		patterns[id] = c.pattern
+	}
+	return patterns
+}
+
+// match returns the most similar cluster in the leaf tokens route to, if it
+// is similar enough. Ties go to the more general template.
+func (m *Miner) match(tokens []string) *cluster {
+	leaf := m.leaf(tokens, false)
+	if leaf == nil {
+		return nil
+	}
+
+	var best *cluster
+	bestSim, bestParams := -1.0, -1
+	for _, c := range leaf.clusters {
+		sim, params := similarity(c.tokens, tokens)
+		if sim > bestSim || (sim == bestSim && params > bestParams) {
+			best, bestSim, bestParams = c, sim, params
+		}
+	}
+	if best == nil || bestSim < m.cfg.Similarity {
+		return nil
+	}
+	return best
+}
+
+// leaf walks the tree by token count and then the first Depth tokens.
+// Tokens containing digits are likely variable and always take the
+// wildcard branch. With create, missing nodes are added; without, a token
+// without a branch of its own falls back to the wildcard branch.
+func (m *Miner) leaf(tokens []string, create bool) *node {
+	n, ok := m.root[len(tokens)]
+	if !ok {
+		if !create {
+			return nil
+		}
+		n = &node{children: make(map[string]*node)}
+		m.root[len(tokens)] = n
+	}
+
+	for i := 0; i < m.cfg.Depth && i < len(tokens); i++ {
+		key := tokens[i]
+		if hasDigit(key) {
+			key = Wildcard
+		}
+
+		if child, ok := n.children[key]; ok {
+			n = child
+			continue
+		}
+		if !create {
+			child, ok := n.children[Wildcard]
+			if !ok {
+				return nil
+			}
+			n = child
+			continue
+		}
+
+		// Keep one slot for the wildcard branch.
+		if key != Wildcard && len(n.children) >= m.cfg.MaxChildren-1 {
+			key = Wildcard
+			if child, ok := n.children[key]; ok {
+				n = child
+				continue
+			}
+		}
+		child := &node{children: make(map[string]*node)}
+		n.children[key] = child
+		n = child
+	}
+	return n
+}
+
+// similarity returns the share of tokens equal in template and tokens, not
+// counting wildcards, and the number of wildcards. Both have the same
+// length.
+func similarity(template, tokens []string) (float64, int) {
+	if len(template) == 0 {
+		return 1, 0
+	}
+	same, params := 0, 0
+	for i, token := range template {
+		if token == Wildcard {
+			params++
+		} else if token == tokens[i] {
+			same++
+		}
+	}
+	return float64(same) / float64(len(template)), params
+}
+
+func hasDigit(token string) bool {
+	return strings.IndexFunc(token, unicode.IsDigit) >= 0
+}
+
+// patternID derives the ID from the template a pattern started with, so it
+// stays stable while the template generalizes and is unique across tenants.
+func patternID(tenant, template string) string {
+	sum := sha256.Sum256([]byte(tenant + "\x00" + template))
+	return hex.EncodeToString(sum[:])[:idLength]
+}
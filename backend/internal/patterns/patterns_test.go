@@ -0,0 +1,108 @@
+package patterns
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func TestAddGeneralizesSimilarMessages(t *testing.T) {
+	m := NewMiner(Config{}, "default")
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	first, changed := m.Add("Request processed in 120ms", now)
+	if first == nil || !changed || first.Template != "Request processed in 120ms" {
+		t.Fatalf("unexpected first pattern %+v, changed %v", first, changed)
+	}
+
+	second, changed := m.Add("Request processed in 87ms", now.Add(time.Minute))
+	if second.ID != first.ID || !changed || second.Template != "Request processed in <*>" {
+		t.Fatalf("expected the template to generalize, got %+v", second)
+	}
+	if !second.FirstSeen.Equal(now) {
+		t.Fatalf("expected first seen to stay %v, got %v", now, second.FirstSeen)
+	}
+
+	third, changed := m.Add("Request processed in 5ms", now)
+	if third.ID != first.ID || changed {
+		t.Fatalf("expected an unchanged match, got %+v changed %v", third, changed)
+	}
+
+	for _, message := range []string{
+		"Authentication failed for user alice",
+		"Request processed",
+		"Cache miss for key session:42",
+	} {
+		if p, _ := m.Add(message, now); p.ID == first.ID {
+			t.Errorf("expected %q to get its own pattern", message)
+		}
+	}
+	if got := len(m.Patterns()); got != 4 {
+		t.Fatalf("expected 4 patterns, got %d", got)
+	}
+}
+
+func TestDigitsTakeTheWildcardBranch(t *testing.T) {
+	m := NewMiner(Config{}, "default")
+	now := time.Now()
+
+	a, _ := m.Add("worker-1 started job 17", now)
+	b, _ := m.Add("worker-2 started job 18", now)
+	if a.ID != b.ID || b.Template != "<*> started job <*>" {
+		t.Fatalf("expected one pattern, got %+v and %+v", a, b)
+	}
+}
+
+func TestRestoreKeepsIDs(t *testing.T) {
+	now := time.Now()
+	m := NewMiner(Config{}, "acme")
+	p, _ := m.Add("Payment 1 declined", now)
+	m.Add("Payment 2 declined", now)
+
+	restored := NewMiner(Config{}, "acme")
+	var saved []models.Pattern
+	for _, pattern := range m.Patterns() {
+		saved = append(saved, pattern)
+	}
+	restored.Restore(saved)
+
+	got, changed := restored.Add("Payment 3 declined", now)
+	if got.ID != p.ID || changed {
+		t.Fatalf("expected the restored pattern %s, got %+v changed %v", p.ID, got, changed)
+	}
+	if other := NewMiner(Config{}, "other"); mustAdd(t, other, "Payment 1 declined").ID == p.ID {
+		t.Fatal("expected pattern IDs to differ across tenants")
+	}
+}
+
+func TestMaxPatterns(t *testing.T) {
+	m := NewMiner(Config{MaxPatterns: 1}, "default")
+	mustAdd(t, m, "service started")
+	if p, _ := m.Add("disk almost full", time.Now()); p != nil {
+		t.Fatalf("expected a full miner to leave the message untagged, got %+v", p)
+	}
+	if p, _ := m.Add("service started", time.Now()); p == nil {
+		t.Fatal("expected known patterns to keep matching")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := (Config{}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, cfg := range []Config{{Depth: -1}, {Similarity: 1.5}, {MaxChildren: 1}} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", cfg)
+		}
+	}
+}
+
+func mustAdd(t *testing.T, m *Miner, message string) *models.Pattern {
+	t.Helper()
+	p, _ := m.Add(message, time.Now())
+	if p == nil {
+		t.Fatalf("expected %q to be tagged", message)
+	}
+	return p
+}
@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+// Condition gates a processor or route on a single field. Exactly one of
+// Equals, In, Matches or Exists should be set.
+type Condition struct {
+	Field   string   `yaml:"field"`
+	Equals  *string  `yaml:"equals"`
+	In      []string `yaml:"in"`
+	Matches string   `yaml:"matches"`
+	Exists  *bool    `yaml:"exists"`
+
+	re *regexp.Regexp
+}
+
+// Compile checks the condition and prepares its pattern. It must be called
+// before Match.
+func (c *Condition) Compile() error {
+	if c.Field == "" {
+		return errors.New("condition requires a field")
+	}
+	if c.Matches != "" {
+		re, err := regexp.Compile(c.Matches)
+		if err != nil {
+			return fmt.Errorf("invalid condition pattern: %w", err)
+		}
+		c.re = re
+	}
+	return nil
+}
+
+func (c *Condition) Match(log *models.Log) bool {
+	value, ok := getField(log, c.Field)
+
+	switch {
+	case c.Exists != nil:
+		return ok == *c.Exists
+	case c.Equals != nil:
+		return ok && value == *c.Equals
+	case len(c.In) > 0:
+		if !ok {
+			return false
+		}
+		for _, v := range c.In {
+			if value == v {
+				return true
+			}
+		}
+		return false
+	case c.re != nil:
+		return ok && c.re.MatchString(value)
+	default:
+		return ok
+	}
+}
@@ -0,0 +1,146 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the on-disk pipeline definition:
+//
+//	pipelines:
+//	  nginx:
+//	    - grok:
+//	        pattern: '%{COMMONAPACHELOG}'
+//	    - timestamp:
+//	        field: metadata.timestamp
+//	        formats: ["02/Jan/2006:15:04:05 -0700"]
+//	sources:
+//	  web-server: nginx
+//	default: ""
+//
+// Each processor entry is a single-key map of type to options. Every
+// processor also accepts "if" (a Condition) and "ignore_failure".
+type Config struct {
+	Pipelines map[string][]map[string]yaml.Node `yaml:"pipelines"`
+	Sources   map[string]string                 `yaml:"sources"`
+	Default   string                            `yaml:"default"`
+}
+
+type commonOptions struct {
+	If            *Condition `yaml:"if"`
+	IgnoreFailure bool       `yaml:"ignore_failure"`
+}
+
+// linker is implemented by processors that reference other pipelines and
+// must be resolved once the whole set is built.
+type linker interface {
+	link(set *Set) error
+}
+
+// LoadFile reads and builds the pipeline set defined in path.
+func LoadFile(path string) (*Set, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading pipeline config: %w", err)
+	}
+	return Load(data)
+}
+
+// Load builds a pipeline set from YAML.
+func Load(data []byte) (*Set, error) {
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("error parsing pipeline config: %w", err)
+	}
+	return Build(cfg)
+}
+
+func Build(cfg Config) (*Set, error) {
+	set := &Set{
+		pipelines:       make(map[string]*Pipeline),
+		sources:         cfg.Sources,
+		defaultPipeline: cfg.Default,
+	}
+
+	var linkers []linker
+	for name, entries := range cfg.Pipelines {
+		p := &Pipeline{Name: name}
+		for i, entry := range entries {
+			if len(entry) != 1 {
+				return nil, fmt.Errorf("pipeline %s: processor %d must have exactly one type", name, i)
+			}
+			for typ, options := range entry {
+				options := options
+				s, err := buildStep(typ, &options)
+				if err != nil {
+					return nil, fmt.Errorf("pipeline %s: processor %d (%s): %w", name, i, typ, err)
+				}
+				if l, ok := s.processor.(linker); ok {
+					linkers = append(linkers, l)
+				}
+				p.steps = append(p.steps, s)
+			}
+		}
+		set.pipelines[name] = p
+	}
+
+	for source, name := range set.sources {
+		if _, ok := set.pipelines[name]; !ok {
+			return nil, fmt.Errorf("source %s uses unknown pipeline %q", source, name)
+		}
+	}
+	if set.defaultPipeline != "" {
+		if _, ok := set.pipelines[set.defaultPipeline]; !ok {
+			return nil, fmt.Errorf("unknown default pipeline %q", set.defaultPipeline)
+		}
+	}
+	for _, l := range linkers {
+		if err := l.link(set); err != nil {
+			return nil, err
+		}
+	}
+
+	return set, nil
+}
+
+func buildStep(typ string, options *yaml.Node) (step, error) {
+	factory, ok := lookupFactory(typ)
+	if !ok {
+		return step{}, fmt.Errorf("unknown processor type %q", typ)
+	}
+
+	var common commonOptions
+	if options.Kind != 0 {
+		if err := options.Decode(&common); err != nil {
+			return step{}, err
+		}
+	}
+	if common.If != nil {
+		if err := common.If.Compile(); err != nil {
+			return step{}, err
+		}
+	}
+
+	processor, err := factory(options)
+	if err != nil {
+		return step{}, err
+	}
+
+	return step{
+		name:          typ,
+		processor:     processor,
+		condition:     common.If,
+		ignoreFailure: common.IgnoreFailure,
+	}, nil
+}
+
+// decodeOptions decodes options into v, tolerating an empty node for
+// processors whose options are all optional.
+func decodeOptions(options *yaml.Node, v interface{}) error {
+	if options == nil || options.Kind == 0 {
+		return nil
+	}
+	return options.Decode(v)
+}
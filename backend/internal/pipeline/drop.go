@@ -0,0 +1,22 @@
+package pipeline
+
+import (
+	"gopkg.in/yaml.v3"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func init() {
+	Register("drop", newDropProcessor)
+}
+
+// dropProcessor discards the whole log. It is meant to be used with "if".
+type dropProcessor struct{}
+
+func newDropProcessor(options *yaml.Node) (Processor, error) {
+	return dropProcessor{}, nil
+}
+
+func (dropProcessor) Process(log *models.Log) error {
+	return ErrDrop
+}
@@ -0,0 +1,27 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func TestDropProcessor(t *testing.T) {
+	set, err := Load([]byte(`
+pipelines:
+  p:
+    - drop:
+        if: {field: level, in: [DEBUG, TRACE]}
+default: p
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if set.Process(&models.Log{Level: "DEBUG"}) {
+		t.Error("debug log should be dropped")
+	}
+	if !set.Process(&models.Log{Level: "ERROR"}) {
+		t.Error("error log should be kept")
+	}
+}
@@ -0,0 +1,80 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+const metadataPrefix = "metadata."
+
+// Field names used in pipeline configs refer to the core log fields
+// (message, level, source, timestamp). Anything else, with or without a
+// "metadata." prefix, is a metadata key.
+func metadataKey(field string) string {
+	return strings.TrimPrefix(field, metadataPrefix)
+}
+
+// Field returns the value of a field named as in pipeline configs and
+// whether the log has it.
+func Field(log *models.Log, field string) (string, bool) {
+	return getField(log, field)
+}
+
+func getField(log *models.Log, field string) (string, bool) {
+	switch field {
+	case "message":
+		return log.Message, true
+	case "level":
+		return log.Level, true
+	case "source":
+		return log.Source, true
+	case "timestamp":
+		if log.Timestamp.IsZero() {
+			return "", false
+		}
+		return log.Timestamp.Format(time.RFC3339Nano), true
+	}
+	value, ok := log.Metadata[metadataKey(field)]
+	return value, ok
+}
+
+func setField(log *models.Log, field, value string) error {
+	switch field {
+	case "message":
+		log.Message = value
+	case "level":
+		log.Level = value
+	case "source":
+		log.Source = value
+	case "timestamp":
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return fmt.Errorf("invalid timestamp %q: %w", value, err)
+		}
+		log.Timestamp = t
+	default:
+		if log.Metadata == nil {
+			log.Metadata = make(map[string]string)
+		}
+		log.Metadata[metadataKey(field)] = value
+	}
+	return nil
+}
+
+func deleteField(log *models.Log, field string) {
+	switch field {
+	case "message":
+		log.Message = ""
+	case "level":
+		log.Level = ""
+	case "source":
+		log.Source = ""
+	case "timestamp":
+		log.Timestamp = time.Time{}
+	default:
+		delete(log.Metadata, metadataKey(field))
+	}
+}
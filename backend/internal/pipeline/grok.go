@@ -0,0 +1,175 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func init() {
+	Register("grok", newGrokProcessor)
+}
+
+// grokPatterns is a subset of the Logstash grok library, rewritten for RE2
+// (no lookarounds or possessive quantifiers).
+var grokPatterns = map[string]string{
+	"WORD":              `\b\w+\b`,
+	"NOTSPACE":          `\S+`,
+	"SPACE":             `\s*`,
+	"DATA":              `.*?`,
+	"GREEDYDATA":        `.*`,
+	"INT":               `[+-]?[0-9]+`,
+	"POSINT":            `\b[1-9][0-9]*\b`,
+	"NONNEGINT":         `\b[0-9]+\b`,
+	"NUMBER":            `[+-]?(?:[0-9]+(?:\.[0-9]+)?|\.[0-9]+)`,
+	"BASE16NUM":         `(?:0[xX])?[0-9A-Fa-f]+`,
+	"QUOTEDSTRING":      `"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'`,
+	"QS":                `%{QUOTEDSTRING}`,
+	"UUID":              `[A-Fa-f0-9]{8}-(?:[A-Fa-f0-9]{4}-){3}[A-Fa-f0-9]{12}`,
+	"USERNAME":          `[a-zA-Z0-9._-]+`,
+	"USER":              `%{USERNAME}`,
+	"EMAILADDRESS":      `[a-zA-Z0-9!#$%&'*+/=?^_{|}~.-]+@%{HOSTNAME}`,
+	"IPV4":              `(?:(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\.){3}(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)`,
+	"IPV6":              `(?:[0-9A-Fa-f]{0,4}:){2,7}(?:[0-9A-Fa-f]{0,4}|%{IPV4})`,
+	"IP":                `(?:%{IPV6}|%{IPV4})`,
+	"HOSTNAME":          `\b[0-9A-Za-z][0-9A-Za-z-]{0,62}(?:\.[0-9A-Za-z][0-9A-Za-z-]{0,62})*\.?`,
+	"IPORHOST":          `(?:%{IP}|%{HOSTNAME})`,
+	"HOSTPORT":          `%{IPORHOST}:%{POSINT}`,
+	"PATH":              `(?:/[^\s?#]*)+`,
+	"URIPATH":           `(?:/[A-Za-z0-9$.+!*'(){},~:;=@#%&_\-]*)+`,
+	"URIPARAM":          `\?[A-Za-z0-9$.+!*'|(){},~@#%&/=:;_?\-\[\]<>]*`,
+	"URIPATHPARAM":      `%{URIPATH}(?:%{URIPARAM})?`,
+	"URI":               `[A-Za-z][A-Za-z0-9+.-]*://\S+`,
+	"MONTH":             `\b(?:[Jj]an(?:uary)?|[Ff]eb(?:ruary)?|[Mm]ar(?:ch)?|[Aa]pr(?:il)?|[Mm]ay|[Jj]une?|[Jj]uly?|[Aa]ug(?:ust)?|[Ss]ep(?:tember)?|[Oo]ct(?:ober)?|[Nn]ov(?:ember)?|[Dd]ec(?:ember)?)\b`,
+	"MONTHNUM":          `(?:0?[1-9]|1[0-2])`,
+	"MONTHDAY":          `(?:(?:0[1-9])|(?:[12][0-9])|(?:3[01])|[1-9])`,
+	"DAY":               `(?:Mon(?:day)?|Tue(?:sday)?|Wed(?:nesday)?|Thu(?:rsday)?|Fri(?:day)?|Sat(?:urday)?|Sun(?:day)?)`,
+	"YEAR":              `(?:\d\d){1,2}`,
+	"HOUR":              `(?:2[0123]|[01]?[0-9])`,
+	"MINUTE":            `(?:[0-5][0-9])`,
+	"SECOND":            `(?:(?:[0-5]?[0-9]|60)(?:[:.,][0-9]+)?)`,
+	"TIME":              `%{HOUR}:%{MINUTE}(?::%{SECOND})?`,
+	"ISO8601_TIMEZONE":  `(?:Z|[+-]%{HOUR}(?::?%{MINUTE}))`,
+	"TIMESTAMP_ISO8601": `%{YEAR}-%{MONTHNUM}-%{MONTHDAY}[T ]%{HOUR}:?%{MINUTE}(?::?%{SECOND})?%{ISO8601_TIMEZONE}?`,
+	"HTTPDATE":          `%{MONTHDAY}/%{MONTH}/%{YEAR}:%{TIME} %{INT}`,
+	"SYSLOGTIMESTAMP":   `%{MONTH} +%{MONTHDAY} %{TIME}`,
+	"LOGLEVEL":          `(?i:trace|debug|info(?:rmation)?|notice|warn(?:ing)?|err(?:or)?|crit(?:ical)?|fatal|severe|alert|emerg(?:ency)?)`,
+	"HTTPDUSER":         `(?:%{EMAILADDRESS}|%{USER})`,
+	"COMMONAPACHELOG":   `%{IPORHOST:client_ip} %{HTTPDUSER:ident} %{HTTPDUSER:auth} \[%{HTTPDATE:timestamp_raw}\] "(?:%{WORD:http_method} %{NOTSPACE:url}(?: HTTP/%{NUMBER:http_version})?|%{DATA:raw_request})" %{NUMBER:status} (?:%{NUMBER:bytes}|-)`,
+	"COMBINEDAPACHELOG": `%{COMMONAPACHELOG} %{QS:referrer} %{QS:user_agent}`,
+}
+
+var grokReference = regexp.MustCompile(`%\{(\w+)(?::([\w.@\-]+))?\}`)
+
+// grokProcessor matches a field against a grok expression such as
+// "%{IP:client} %{WORD:method}". Each named reference is stored in the field
+// it names.
+type grokProcessor struct {
+	Field    string            `yaml:"field"`
+	Pattern  string            `yaml:"pattern"`
+	Patterns map[string]string `yaml:"patterns"`
+
+	re     *regexp.Regexp
+	fields []string
+}
+
+func newGrokProcessor(options *yaml.Node) (Processor, error) {
+	p := &grokProcessor{Field: "message"}
+	if err := decodeOptions(options, p); err != nil {
+		return nil, err
+	}
+	if p.Pattern == "" {
+		return nil, errors.New("pattern is required")
+	}
+
+	expr, fields, err := expandGrok(p.Pattern, p.Patterns)
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid grok pattern: %w", err)
+	}
+	p.re = re
+	p.fields = fields
+	return p, nil
+}
+
+// expandGrok rewrites a grok expression into a regexp. Captures are emitted
+// as positional groups named g0..gN because field names may contain dots; the
+// returned slice maps group index to field name.
+func expandGrok(pattern string, custom map[string]string) (string, []string, error) {
+	var fields []string
+	var expand func(p string, depth int) (string, error)
+
+	expand = func(p string, depth int) (string, error) {
+		if depth > 16 {
+			return "", errors.New("grok pattern nests too deeply")
+		}
+
+		var expandErr error
+		out := grokReference.ReplaceAllStringFunc(p, func(ref string) string {
+			m := grokReference.FindStringSubmatch(ref)
+			name, field := m[1], m[2]
+
+			def, ok := custom[name]
+			if !ok {
+				def, ok = grokPatterns[name]
+			}
+			if !ok {
+				expandErr = fmt.Errorf("unknown grok pattern %q", name)
+				return ""
+			}
+
+			inner, err := expand(def, depth+1)
+			if err != nil {
+				expandErr = err
+				return ""
+			}
+			if field == "" {
+				return "(?:" + inner + ")"
+			}
+			group := fmt.Sprintf("g%d", len(fields))
+			fields = append(fields, field)
+			return "(?P<" + group + ">" + inner + ")"
+		})
+		return out, expandErr
+	}
+
+	expr, err := expand(pattern, 0)
+	if err != nil {
+		return "", nil, err
+	}
+	return expr, fields, nil
+}
+
+func (p *grokProcessor) Process(log *models.Log) error {
+	raw, ok := getField(log, p.Field)
+	if !ok {
+		return fmt.Errorf("field %s not found", p.Field)
+	}
+
+	match := p.re.FindStringSubmatch(raw)
+	if match == nil {
+		return fmt.Errorf("%s does not match grok pattern", p.Field)
+	}
+
+	for i, name := range p.re.SubexpNames() {
+		if !strings.HasPrefix(name, "g") || match[i] == "" {
+			continue
+		}
+		var idx int
+		if _, err := fmt.Sscanf(name, "g%d", &idx); err != nil || idx >= len(p.fields) {
+			continue
+		}
+		if err := setField(log, p.fields[idx], match[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func TestGrokProcessorCommonLog(t *testing.T) {
+	p := newProcessor(t, "grok", `{pattern: '%{COMMONAPACHELOG}'}`)
+
+	log := &models.Log{Message: `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326`}
+	if err := p.Process(log); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"client_ip":     "127.0.0.1",
+		"auth":          "frank",
+		"timestamp_raw": "10/Oct/2000:13:55:36 -0700",
+		"http_method":   "GET",
+		"url":           "/apache_pb.gif",
+		"status":        "200",
+		"bytes":         "2326",
+	}
+	for k, v := range want {
+		if log.Metadata[k] != v {
+			t.Errorf("metadata[%s] = %q, want %q", k, log.Metadata[k], v)
+		}
+	}
+}
+
+func TestGrokProcessorCustomPatternsAndCoreFields(t *testing.T) {
+	p := newProcessor(t, "grok", `
+pattern: '%{LOGLEVEL:level} %{ORDER:metadata.order} %{GREEDYDATA:message}'
+patterns:
+  ORDER: 'ORD-%{INT}'
+`)
+
+	log := &models.Log{Message: "error ORD-991 payment declined"}
+	if err := p.Process(log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Level != "error" || log.Message != "payment declined" || log.Metadata["order"] != "ORD-991" {
+		t.Errorf("got %+v", log)
+	}
+}
+
+func TestGrokUnknownPattern(t *testing.T) {
+	if _, _, err := expandGrok("%{NOPE:x}", nil); err == nil {
+		t.Error("expected error for unknown pattern")
+	}
+}
@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func init() {
+	Register("json", newJSONProcessor)
+}
+
+// jsonProcessor parses a JSON object held in a field. Nested objects are
+// flattened into dotted keys and non-string values are stored as JSON text.
+// Without a target prefix, keys named after core fields (message, level,
+// source, timestamp) replace those fields.
+type jsonProcessor struct {
+	Field  string `yaml:"field"`
+	Target string `yaml:"target"`
+}
+
+func newJSONProcessor(options *yaml.Node) (Processor, error) {
+	p := &jsonProcessor{Field: "message"}
+	if err := decodeOptions(options, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *jsonProcessor) Process(log *models.Log) error {
+	raw, ok := getField(log, p.Field)
+	if !ok {
+		return fmt.Errorf("field %s not found", p.Field)
+	}
+	raw = strings.TrimSpace(raw)
+	if !strings.HasPrefix(raw, "{") {
+		return errors.New("field does not hold a JSON object")
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &obj); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	prefix := ""
+	if p.Target != "" {
+		prefix = p.Target + "."
+	}
+	return p.flatten(log, prefix, obj)
+}
+
+func (p *jsonProcessor) flatten(log *models.Log, prefix string, obj map[string]interface{}) error {
+	for key, value := range obj {
+		field := prefix + key
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if err := p.flatten(log, field+".", v); err != nil {
+				return err
+			}
+			continue
+		case string:
+			if err := setField(log, field, v); err != nil {
+				return err
+			}
+		case nil:
+			continue
+		default:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			if err := setField(log, field, string(b)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
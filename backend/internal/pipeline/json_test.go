@@ -0,0 +1,48 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func TestJSONProcessor(t *testing.T) {
+	p := newProcessor(t, "json", "")
+
+	log := &models.Log{Message: `{"message":"boom","level":"error","user":{"id":42,"name":"ann"},"tags":["a"]}`}
+	if err := p.Process(log); err != nil {
+		t.Fatal(err)
+	}
+
+	if log.Message != "boom" || log.Level != "error" {
+		t.Errorf("core fields not replaced: %+v", log)
+	}
+	want := map[string]string{"user.id": "42", "user.name": "ann", "tags": `["a"]`}
+	for k, v := range want {
+		if log.Metadata[k] != v {
+			t.Errorf("metadata[%s] = %q, want %q", k, log.Metadata[k], v)
+		}
+	}
+}
+
+func TestJSONProcessorTarget(t *testing.T) {
+	p := newProcessor(t, "json", "{field: metadata.payload, target: body}")
+
+	log := &models.Log{Message: "m", Metadata: map[string]string{"payload": `{"message":"inner"}`}}
+	if err := p.Process(log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Message != "m" || log.Metadata["body.message"] != "inner" {
+		t.Errorf("got %+v", log)
+	}
+}
+
+func TestJSONProcessorRejectsNonObject(t *testing.T) {
+	p := newProcessor(t, "json", "")
+
+	for _, msg := range []string{"plain text", `["array"]`, `{"broken":`} {
+		if err := p.Process(&models.Log{Message: msg}); err == nil {
+			t.Errorf("expected error for %q", msg)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func init() {
+	Register("kv", newKVProcessor)
+}
+
+// kvProcessor extracts key=value pairs. Values may be double quoted to
+// contain the field separator.
+type kvProcessor struct {
+	Field       string   `yaml:"field"`
+	FieldSplit  string   `yaml:"field_split"`
+	ValueSplit  string   `yaml:"value_split"`
+	Prefix      string   `yaml:"prefix"`
+	IncludeKeys []string `yaml:"include_keys"`
+
+	include map[string]bool
+}
+
+func newKVProcessor(options *yaml.Node) (Processor, error) {
+	p := &kvProcessor{Field: "message", FieldSplit: " ", ValueSplit: "="}
+	if err := decodeOptions(options, p); err != nil {
+		return nil, err
+	}
+	if p.FieldSplit == "" || p.ValueSplit == "" {
+		return nil, fmt.Errorf("field_split and value_split must not be empty")
+	}
+	if len(p.IncludeKeys) > 0 {
+		p.include = make(map[string]bool, len(p.IncludeKeys))
+		for _, k := range p.IncludeKeys {
+			p.include[k] = true
+		}
+	}
+	return p, nil
+}
+
+func (p *kvProcessor) Process(log *models.Log) error {
+	raw, ok := getField(log, p.Field)
+	if !ok {
+		return fmt.Errorf("field %s not found", p.Field)
+	}
+
+	found := false
+	for _, token := range splitQuoted(raw, p.FieldSplit) {
+		key, value, ok := strings.Cut(token, p.ValueSplit)
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			continue
+		}
+		if p.include != nil && !p.include[key] {
+			continue
+		}
+		if err := setField(log, p.Prefix+key, unquote(strings.TrimSpace(value))); err != nil {
+			return err
+		}
+		found = true
+	}
+
+	if !found {
+		return fmt.Errorf("no key/value pairs in %s", p.Field)
+	}
+	return nil
+}
+
+// splitQuoted splits s on sep, ignoring separators inside double quotes.
+func splitQuoted(s, sep string) []string {
+	var tokens []string
+	inQuote := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && inQuote:
+			i++
+		case s[i] == '"':
+			inQuote = !inQuote
+		case !inQuote && strings.HasPrefix(s[i:], sep):
+			if i > start {
+				tokens = append(tokens, s[start:i])
+			}
+			start = i + len(sep)
+			i += len(sep) - 1
+		}
+	}
+	if start < len(s) {
+		tokens = append(tokens, s[start:])
+	}
+	return tokens
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return strings.ReplaceAll(s[1:len(s)-1], `\"`, `"`)
+	}
+	return s
+}
@@ -0,0 +1,59 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func TestKVProcessor(t *testing.T) {
+	tests := []struct {
+		name    string
+		options string
+		message string
+		want    map[string]string
+	}{
+		{
+			name:    "defaults",
+			message: `user=bob status=200 path="/a b"`,
+			want:    map[string]string{"user": "bob", "status": "200", "path": "/a b"},
+		},
+		{
+			name:    "custom separators and prefix",
+			options: `{field_split: ";", value_split: ":", prefix: "kv."}`,
+			message: "a:1;b:2",
+			want:    map[string]string{"kv.a": "1", "kv.b": "2"},
+		},
+		{
+			name:    "include keys",
+			options: `{include_keys: [b]}`,
+			message: "a=1 b=2",
+			want:    map[string]string{"b": "2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newProcessor(t, "kv", tt.options)
+			log := &models.Log{Message: tt.message}
+			if err := p.Process(log); err != nil {
+				t.Fatal(err)
+			}
+			if len(log.Metadata) != len(tt.want) {
+				t.Errorf("metadata = %v, want %v", log.Metadata, tt.want)
+			}
+			for k, v := range tt.want {
+				if log.Metadata[k] != v {
+					t.Errorf("metadata[%s] = %q, want %q", k, log.Metadata[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestKVProcessorNoPairs(t *testing.T) {
+	p := newProcessor(t, "kv", "")
+	if err := p.Process(&models.Log{Message: "nothing here"}); err == nil {
+		t.Error("expected error when no pairs are found")
+	}
+}
@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func init() {
+	Register("level", newLevelProcessor)
+}
+
+// levelAliases maps common spellings, and syslog severities 0-7, onto the
+// levels the rest of logana uses: DEBUG, INFO, WARN, ERROR and FATAL.
+var levelAliases = map[string]string{
+	"TRACE":       "DEBUG",
+	"DEBUG":       "DEBUG",
+	"DBG":         "DEBUG",
+	"INFO":        "INFO",
+	"INFORMATION": "INFO",
+	"NOTICE":      "INFO",
+	"WARN":        "WARN",
+	"WARNING":     "WARN",
+	"ERR":         "ERROR",
+	"ERROR":       "ERROR",
+	"SEVERE":      "ERROR",
+	"CRIT":        "FATAL",
+	"CRITICAL":    "FATAL",
+	"FATAL":       "FATAL",
+	"ALERT":       "FATAL",
+	"EMERG":       "FATAL",
+	"EMERGENCY":   "FATAL",
+	"PANIC":       "FATAL",
+	"0":           "FATAL",
+	"1":           "FATAL",
+	"2":           "FATAL",
+	"3":           "ERROR",
+	"4":           "WARN",
+	"5":           "INFO",
+	"6":           "INFO",
+	"7":           "DEBUG",
+}
+
+type levelProcessor struct {
+	Field   string            `yaml:"field"`
+	Mapping map[string]string `yaml:"mapping"`
+	Default string            `yaml:"default"`
+}
+
+func newLevelProcessor(options *yaml.Node) (Processor, error) {
+	p := &levelProcessor{Field: "level"}
+	if err := decodeOptions(options, p); err != nil {
+		return nil, err
+	}
+
+	mapping := make(map[string]string, len(levelAliases)+len(p.Mapping))
+	for k, v := range levelAliases {
+		mapping[k] = v
+	}
+	for k, v := range p.Mapping {
+		mapping[strings.ToUpper(k)] = strings.ToUpper(v)
+	}
+	p.Mapping = mapping
+	return p, nil
+}
+
+func (p *levelProcessor) Process(log *models.Log) error {
+	raw, _ := getField(log, p.Field)
+	key := strings.ToUpper(strings.TrimSpace(raw))
+
+	level, ok := p.Mapping[key]
+	if !ok {
+		if p.Default == "" {
+			return fmt.Errorf("unknown level %q", raw)
+		}
+		level = strings.ToUpper(p.Default)
+	}
+	log.Level = level
+	return nil
+}
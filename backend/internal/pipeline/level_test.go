@@ -0,0 +1,46 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func TestLevelProcessor(t *testing.T) {
+	p := newProcessor(t, "level", `{mapping: {verbose: debug}}`)
+
+	tests := map[string]string{
+		"warning": "WARN",
+		"Err":     "ERROR",
+		"3":       "ERROR",
+		"crit":    "FATAL",
+		" info ":  "INFO",
+		"verbose": "DEBUG",
+	}
+	for in, want := range tests {
+		log := &models.Log{Level: in}
+		if err := p.Process(log); err != nil {
+			t.Errorf("%q: %v", in, err)
+			continue
+		}
+		if log.Level != want {
+			t.Errorf("%q normalized to %q, want %q", in, log.Level, want)
+		}
+	}
+
+	if err := p.Process(&models.Log{Level: "loud"}); err == nil {
+		t.Error("expected error for unknown level without default")
+	}
+}
+
+func TestLevelProcessorFromMetadata(t *testing.T) {
+	p := newProcessor(t, "level", `{field: severity, default: info}`)
+
+	log := &models.Log{Metadata: map[string]string{"severity": "unknown"}}
+	if err := p.Process(log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Level != "INFO" {
+		t.Errorf("level = %q", log.Level)
+	}
+}
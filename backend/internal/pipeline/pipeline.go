@@ -0,0 +1,150 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+// MetadataError is the metadata key that records why a pipeline stopped
+// early. The log is still indexed so a broken processor never loses data.
+const MetadataError = "pipeline_error"
+
+// maxRouteDepth bounds nested route processors so a misconfigured loop
+// cannot recurse forever.
+const maxRouteDepth = 8
+
+// ErrDrop is returned by a processor to discard the log entirely.
+var ErrDrop = errors.New("log dropped by pipeline")
+
+// Processor transforms a log in place.
+type Processor interface {
+	Process(log *models.Log) error
+}
+
+// Factory builds a processor from its YAML options.
+type Factory func(options *yaml.Node) (Processor, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]Factory{}
+)
+
+// Register makes a processor type available to pipeline configs. It panics
+// on duplicate names, like database/sql drivers.
+func Register(name string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if _, dup := factories[name]; dup {
+		panic("pipeline: Register called twice for processor " + name)
+	}
+	factories[name] = factory
+}
+
+// Processors lists the registered processor types.
+func Processors() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func lookupFactory(name string) (Factory, bool) {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	factory, ok := factories[name]
+	return factory, ok
+}
+
+type step struct {
+	name          string
+	processor     Processor
+	condition     *Condition
+	ignoreFailure bool
+}
+
+// Pipeline is an ordered list of processors.
+type Pipeline struct {
+	Name  string
+	steps []step
+}
+
+func (p *Pipeline) run(log *models.Log, depth int) error {
+	if depth > maxRouteDepth {
+		return fmt.Errorf("pipeline %s: route depth exceeds %d", p.Name, maxRouteDepth)
+	}
+
+	for i, s := range p.steps {
+		if s.condition != nil && !s.condition.Match(log) {
+			continue
+		}
+
+		var err error
+		if r, ok := s.processor.(*routeProcessor); ok {
+			err = r.route(log, depth)
+		} else {
+			err = s.processor.Process(log)
+		}
+		if err == nil || (s.ignoreFailure && !errors.Is(err, ErrDrop)) {
+			continue
+		}
+		if errors.Is(err, ErrDrop) {
+			return ErrDrop
+		}
+		return fmt.Errorf("pipeline %s: processor %d (%s): %w", p.Name, i, s.name, err)
+	}
+	return nil
+}
+
+// Set holds every configured pipeline and the source-to-pipeline mapping.
+type Set struct {
+	pipelines       map[string]*Pipeline
+	sources         map[string]string
+	defaultPipeline string
+}
+
+// ForSource returns the pipeline that applies to source, or nil.
+func (s *Set) ForSource(source string) *Pipeline {
+	if s == nil {
+		return nil
+	}
+	name, ok := s.sources[source]
+	if !ok {
+		name = s.defaultPipeline
+	}
+	return s.pipelines[name]
+}
+
+// Process runs the pipeline selected by the log's source. It returns false
+// when the log was dropped. Processor failures are recorded in the
+// MetadataError key rather than returned.
+func (s *Set) Process(log *models.Log) bool {
+	p := s.ForSource(log.Source)
+	if p == nil {
+		return true
+	}
+
+	err := p.run(log, 0)
+	if errors.Is(err, ErrDrop) {
+		return false
+	}
+	if err != nil {
+		if log.Metadata == nil {
+			log.Metadata = make(map[string]string)
+		}
+		log.Metadata[MetadataError] = err.Error()
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func newProcessor(t *testing.T, typ, options string) Processor {
+	t.Helper()
+
+	factory, ok := lookupFactory(typ)
+	if !ok {
+		t.Fatalf("processor %q is not registered", typ)
+	}
+
+	var node yaml.Node
+	if options != "" {
+		var doc yaml.Node
+		if err := yaml.Unmarshal([]byte(options), &doc); err != nil {
+			t.Fatalf("invalid options: %v", err)
+		}
+		node = *doc.Content[0]
+	}
+
+	p, err := factory(&node)
+	if err != nil {
+		t.Fatalf("building %s processor: %v", typ, err)
+	}
+	return p
+}
+
+func TestSetProcessSelectsPipelineBySource(t *testing.T) {
+	set, err := Load([]byte(`
+pipelines:
+  web:
+    - kv: {}
+  fallback:
+    - level: {default: INFO}
+sources:
+  web-server: web
+default: fallback
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	web := &models.Log{Source: "web-server", Message: "status=200"}
+	if !set.Process(web) || web.Metadata["status"] != "200" {
+		t.Errorf("web pipeline not applied: %+v", web)
+	}
+
+	other := &models.Log{Source: "cache", Level: "weird"}
+	if !set.Process(other) || other.Level != "INFO" {
+		t.Errorf("default pipeline not applied: %+v", other)
+	}
+}
+
+func TestSetProcessRecordsFailure(t *testing.T) {
+	set, err := Load([]byte(`
+pipelines:
+  p:
+    - json: {}
+    - level: {default: INFO}
+default: p
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := &models.Log{Message: "not json", Level: "x"}
+	if !set.Process(log) {
+		t.Fatal("failed log must not be dropped")
+	}
+	if log.Metadata[MetadataError] == "" {
+		t.Error("expected pipeline_error metadata")
+	}
+	if log.Level != "x" {
+		t.Error("processors after a failure must not run")
+	}
+}
+
+func TestIgnoreFailureAndCondition(t *testing.T) {
+	set, err := Load([]byte(`
+pipelines:
+  p:
+    - json: {ignore_failure: true}
+    - level:
+        if: {field: source, equals: legacy}
+        mapping: {x: ERROR}
+default: p
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := &models.Log{Message: "plain", Level: "x", Source: "legacy"}
+	set.Process(log)
+	if log.Level != "ERROR" || log.Metadata[MetadataError] != "" {
+		t.Errorf("got %+v", log)
+	}
+
+	other := &models.Log{Message: "plain", Level: "x", Source: "other"}
+	set.Process(other)
+	if other.Level != "x" {
+		t.Errorf("condition should have skipped level processor, got %q", other.Level)
+	}
+}
+
+func TestLoadRejectsUnknownPipeline(t *testing.T) {
+	_, err := Load([]byte(`
+pipelines:
+  p:
+    - drop: {}
+sources:
+  web: missing
+`))
+	if err == nil {
+		t.Fatal("expected error for unknown pipeline")
+	}
+}
+
+func TestFieldsTimestamp(t *testing.T) {
+	log := &models.Log{}
+	if err := setField(log, "timestamp", "2024-05-01T10:00:00Z"); err != nil {
+		t.Fatal(err)
+	}
+	if !log.Timestamp.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("got %v", log.Timestamp)
+	}
+	if err := setField(log, "timestamp", "yesterday"); err == nil {
+		t.Error("expected error for invalid timestamp")
+	}
+}
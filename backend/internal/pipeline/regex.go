@@ -0,0 +1,63 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func init() {
+	Register("regex", newRegexProcessor)
+}
+
+// regexProcessor copies each named group of Pattern into the field of the
+// same name.
+type regexProcessor struct {
+	Field   string `yaml:"field"`
+	Pattern string `yaml:"pattern"`
+
+	re *regexp.Regexp
+}
+
+func newRegexProcessor(options *yaml.Node) (Processor, error) {
+	p := &regexProcessor{Field: "message"}
+	if err := decodeOptions(options, p); err != nil {
+		return nil, err
+	}
+	if p.Pattern == "" {
+		return nil, errors.New("pattern is required")
+	}
+
+	re, err := regexp.Compile(p.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	p.re = re
+	return p, nil
+}
+
+func (p *regexProcessor) Process(log *models.Log) error {
+	raw, ok := getField(log, p.Field)
+	if !ok {
+		return fmt.Errorf("field %s not found", p.Field)
+	}
+
+	match := p.re.FindStringSubmatch(raw)
+	if match == nil {
+		return fmt.Errorf("%s does not match pattern", p.Field)
+	}
+
+	for i, name := range p.re.SubexpNames() {
+		if name == "" || match[i] == "" {
+			continue
+		}
+		if err := setField(log, name, match[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func TestRegexProcessor(t *testing.T) {
+	p := newProcessor(t, "regex", `{pattern: '^(?P<level>[A-Z]+) \[(?P<thread>[^\]]+)\] (?P<message>.*)$'}`)
+
+	log := &models.Log{Message: "WARN [worker-3] queue is full"}
+	if err := p.Process(log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Level != "WARN" || log.Message != "queue is full" || log.Metadata["thread"] != "worker-3" {
+		t.Errorf("got %+v", log)
+	}
+
+	if err := p.Process(&models.Log{Message: "no match"}); err == nil {
+		t.Error("expected error on mismatch")
+	}
+}
+
+func TestRegexProcessorRequiresPattern(t *testing.T) {
+	if _, err := newRegexProcessor(nil); err == nil {
+		t.Error("expected error without pattern")
+	}
+}
@@ -0,0 +1,36 @@
+package pipeline
+
+import (
+	"errors"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func init() {
+	Register("remove", newRemoveProcessor)
+}
+
+// removeProcessor drops fields from the log. Missing fields are ignored.
+type removeProcessor struct {
+	Fields []string `yaml:"fields"`
+}
+
+func newRemoveProcessor(options *yaml.Node) (Processor, error) {
+	p := &removeProcessor{}
+	if err := decodeOptions(options, p); err != nil {
+		return nil, err
+	}
+	if len(p.Fields) == 0 {
+		return nil, errors.New("fields is required")
+	}
+	return p, nil
+}
+
+func (p *removeProcessor) Process(log *models.Log) error {
+	for _, field := range p.Fields {
+		deleteField(log, field)
+	}
+	return nil
+}
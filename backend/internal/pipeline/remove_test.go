@@ -0,0 +1,19 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func TestRemoveProcessor(t *testing.T) {
+	p := newProcessor(t, "remove", `{fields: [metadata.secret, source, missing]}`)
+
+	log := &models.Log{Source: "s", Metadata: map[string]string{"secret": "x", "keep": "y"}}
+	if err := p.Process(log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Source != "" || len(log.Metadata) != 1 || log.Metadata["keep"] != "y" {
+		t.Errorf("got %+v", log)
+	}
+}
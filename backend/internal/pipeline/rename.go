@@ -0,0 +1,47 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func init() {
+	Register("rename", newRenameProcessor)
+}
+
+type renameProcessor struct {
+	From          string `yaml:"from"`
+	To            string `yaml:"to"`
+	IgnoreMissing bool   `yaml:"ignore_missing"`
+}
+
+func newRenameProcessor(options *yaml.Node) (Processor, error) {
+	p := &renameProcessor{}
+	if err := decodeOptions(options, p); err != nil {
+		return nil, err
+	}
+	if p.From == "" || p.To == "" {
+		return nil, errors.New("from and to are required")
+	}
+	return p, nil
+}
+
+func (p *renameProcessor) Process(log *models.Log) error {
+	value, ok := getField(log, p.From)
+	if !ok {
+		if p.IgnoreMissing {
+			return nil
+		}
+		return fmt.Errorf("field %s not found", p.From)
+	}
+
+	if err := setField(log, p.To, value); err != nil {
+		return err
+	}
+	deleteField(log, p.From)
+	return nil
+}
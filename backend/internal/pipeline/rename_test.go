@@ -0,0 +1,34 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func TestRenameProcessor(t *testing.T) {
+	p := newProcessor(t, "rename", `{from: msg, to: message}`)
+
+	log := &models.Log{Metadata: map[string]string{"msg": "hello"}}
+	if err := p.Process(log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Message != "hello" {
+		t.Errorf("message = %q", log.Message)
+	}
+	if _, ok := log.Metadata["msg"]; ok {
+		t.Error("source field should be removed")
+	}
+}
+
+func TestRenameProcessorMissing(t *testing.T) {
+	strict := newProcessor(t, "rename", `{from: a, to: b}`)
+	if err := strict.Process(&models.Log{}); err == nil {
+		t.Error("expected error for missing field")
+	}
+
+	lenient := newProcessor(t, "rename", `{from: a, to: b, ignore_missing: true}`)
+	if err := lenient.Process(&models.Log{}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
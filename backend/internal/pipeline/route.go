@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func init() {
+	Register("route", newRouteProcessor)
+}
+
+type route struct {
+	If       *Condition `yaml:"if"`
+	Pipeline string     `yaml:"pipeline"`
+
+	target *Pipeline
+}
+
+// routeProcessor runs the pipeline of the first route whose condition
+// matches, or Default when none do, then resumes the calling pipeline.
+type routeProcessor struct {
+	Routes  []*route `yaml:"routes"`
+	Default string   `yaml:"default"`
+
+	fallback *Pipeline
+}
+
+func newRouteProcessor(options *yaml.Node) (Processor, error) {
+	p := &routeProcessor{}
+	if err := decodeOptions(options, p); err != nil {
+		return nil, err
+	}
+	if len(p.Routes) == 0 && p.Default == "" {
+		return nil, errors.New("routes or default is required")
+	}
+	for i, r := range p.Routes {
+		if r.If == nil || r.Pipeline == "" {
+			return nil, fmt.Errorf("route %d needs if and pipeline", i)
+		}
+		if err := r.If.Compile(); err != nil {
+			return nil, fmt.Errorf("route %d: %w", i, err)
+		}
+	}
+	return p, nil
+}
+
+func (p *routeProcessor) link(set *Set) error {
+	for _, r := range p.Routes {
+		target, ok := set.pipelines[r.Pipeline]
+		if !ok {
+			return fmt.Errorf("route to unknown pipeline %q", r.Pipeline)
+		}
+		r.target = target
+	}
+	if p.Default != "" {
+		target, ok := set.pipelines[p.Default]
+		if !ok {
+			return fmt.Errorf("route to unknown pipeline %q", p.Default)
+		}
+		p.fallback = target
+	}
+	return nil
+}
+
+func (p *routeProcessor) route(log *models.Log, depth int) error {
+	for _, r := range p.Routes {
+		if r.If.Match(log) {
+			return r.target.run(log, depth+1)
+		}
+	}
+	if p.fallback != nil {
+		return p.fallback.run(log, depth+1)
+	}
+	return nil
+}
+
+func (p *routeProcessor) Process(log *models.Log) error {
+	return p.route(log, 0)
+}
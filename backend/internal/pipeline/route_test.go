@@ -0,0 +1,69 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func TestRouteProcessor(t *testing.T) {
+	set, err := Load([]byte(`
+pipelines:
+  main:
+    - route:
+        routes:
+          - if: {field: message, matches: '^\{'}
+            pipeline: json
+        default: text
+    - level: {default: INFO}
+  json:
+    - json: {}
+  text:
+    - kv: {ignore_failure: true}
+default: main
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jsonLog := &models.Log{Message: `{"message":"hi","level":"warning"}`}
+	set.Process(jsonLog)
+	if jsonLog.Message != "hi" || jsonLog.Level != "WARN" {
+		t.Errorf("json route: %+v", jsonLog)
+	}
+
+	textLog := &models.Log{Message: "a=1"}
+	set.Process(textLog)
+	if textLog.Metadata["a"] != "1" || textLog.Level != "INFO" {
+		t.Errorf("default route: %+v", textLog)
+	}
+}
+
+func TestRouteProcessorLoop(t *testing.T) {
+	set, err := Load([]byte(`
+pipelines:
+  a:
+    - route: {default: a}
+default: a
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := &models.Log{}
+	set.Process(log)
+	if log.Metadata[MetadataError] == "" {
+		t.Error("expected route depth error")
+	}
+}
+
+func TestRouteProcessorUnknownTarget(t *testing.T) {
+	_, err := Load([]byte(`
+pipelines:
+  a:
+    - route: {default: nowhere}
+`))
+	if err == nil {
+		t.Error("expected error for unknown route target")
+	}
+}
@@ -0,0 +1,108 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func init() {
+	Register("timestamp", newTimestampProcessor)
+}
+
+// Named formats accepted besides Go reference layouts.
+const (
+	formatISO8601 = "ISO8601"
+	formatUnix    = "UNIX"
+	formatUnixMS  = "UNIX_MS"
+)
+
+var iso8601Layouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05,999",
+}
+
+// timestampProcessor parses Field with the first matching format and stores
+// the result in Target. Layouts without a zone are read in Timezone.
+type timestampProcessor struct {
+	Field    string   `yaml:"field"`
+	Target   string   `yaml:"target"`
+	Formats  []string `yaml:"formats"`
+	Timezone string   `yaml:"timezone"`
+
+	loc *time.Location
+}
+
+func newTimestampProcessor(options *yaml.Node) (Processor, error) {
+	p := &timestampProcessor{Target: "timestamp", Formats: []string{formatISO8601}}
+	if err := decodeOptions(options, p); err != nil {
+		return nil, err
+	}
+	if p.Field == "" {
+		return nil, errors.New("field is required")
+	}
+
+	p.loc = time.UTC
+	if p.Timezone != "" {
+		loc, err := time.LoadLocation(p.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone: %w", err)
+		}
+		p.loc = loc
+	}
+	return p, nil
+}
+
+func (p *timestampProcessor) Process(log *models.Log) error {
+	raw, ok := getField(log, p.Field)
+	if !ok {
+		return fmt.Errorf("field %s not found", p.Field)
+	}
+	raw = strings.TrimSpace(raw)
+
+	for _, format := range p.Formats {
+		t, err := p.parse(format, raw)
+		if err != nil {
+			continue
+		}
+		if p.Target == "timestamp" {
+			log.Timestamp = t
+			return nil
+		}
+		return setField(log, p.Target, t.Format(time.RFC3339Nano))
+	}
+	return fmt.Errorf("%s value %q matches none of the formats", p.Field, raw)
+}
+
+func (p *timestampProcessor) parse(format, raw string) (time.Time, error) {
+	switch format {
+	case formatUnix, formatUnixMS:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if format == formatUnixMS {
+			return time.UnixMilli(int64(f)).UTC(), nil
+		}
+		sec := int64(f)
+		return time.Unix(sec, int64((f-float64(sec))*1e9)).UTC(), nil
+	case formatISO8601:
+		for _, layout := range iso8601Layouts {
+			if t, err := time.ParseInLocation(layout, raw, p.loc); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, errors.New("not ISO8601")
+	default:
+		return time.ParseInLocation(format, raw, p.loc)
+	}
+}
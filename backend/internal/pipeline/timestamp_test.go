@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func TestTimestampProcessor(t *testing.T) {
+	tests := []struct {
+		name    string
+		options string
+		value   string
+		want    time.Time
+	}{
+		{
+			name:    "iso8601",
+			options: `{field: ts}`,
+			value:   "2024-03-01T12:30:00.5Z",
+			want:    time.Date(2024, 3, 1, 12, 30, 0, 5e8, time.UTC),
+		},
+		{
+			name:    "layout with timezone",
+			options: `{field: ts, formats: ["2006-01-02 15:04:05"], timezone: "America/New_York"}`,
+			value:   "2024-03-01 07:00:00",
+			want:    time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "unix seconds",
+			options: `{field: ts, formats: [UNIX]}`,
+			value:   "1700000000",
+			want:    time.Unix(1700000000, 0),
+		},
+		{
+			name:    "unix millis after failed format",
+			options: `{field: ts, formats: ["02/Jan/2006", UNIX_MS]}`,
+			value:   "1700000000123",
+			want:    time.UnixMilli(1700000000123),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newProcessor(t, "timestamp", tt.options)
+			log := &models.Log{Metadata: map[string]string{"ts": tt.value}}
+			if err := p.Process(log); err != nil {
+				t.Fatal(err)
+			}
+			if !log.Timestamp.Equal(tt.want) {
+				t.Errorf("timestamp = %v, want %v", log.Timestamp, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimestampProcessorNoMatch(t *testing.T) {
+	p := newProcessor(t, "timestamp", `{field: ts, formats: [UNIX]}`)
+	if err := p.Process(&models.Log{Metadata: map[string]string{"ts": "soon"}}); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,231 @@
+// Package querycache caches search and aggregation results in memory, so
+// dashboards refreshing the same queries do not each reach Elasticsearch.
+// Concurrent requests for the same result share one fetch.
+package querycache
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Eviction policies. Both evict the least recently used results; they
+// differ in what bounds the cache.
+const (
+	// PolicyLRU bounds the number of cached results.
+	PolicyLRU = "lru"
+	// PolicySize bounds the memory taken by the cached results.
+	PolicySize = "size"
+)
+
+const (
+	// settleTime is how far back logs still arrive; ranges ending later
+	// than that are treated as live.
+	settleTime = time.Minute
+	// fetchTimeout bounds a shared fetch, which outlives the request that
+	// started it if other requests wait for it.
+	fetchTimeout = time.Minute
+)
+
+// Config mirrors the search.cache section of the configuration file.
+type Config struct {
+	Policy     string
+	MaxEntries int
+	MaxBytes   int64
+	// TTL applies to ranges that ended; LiveTTL to ranges relative to now
+	// or reaching the present, whose results change as logs arrive.
+	TTL     time.Duration
+	LiveTTL time.Duration
+}
+
+func (c Config) Validate() error {
+	var errs []error
+	switch c.Policy {
+	case PolicyLRU:
+		if c.MaxEntries < 1 {
+			errs = append(errs, errors.New("max_entries: must be at least 1"))
+		}
+	case PolicySize:
+		if c.MaxBytes < 1 {
+			errs = append(errs, errors.New("max_memory_mb: must be at least 1"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("policy: must be %s or %s, not %q", PolicyLRU, PolicySize, c.Policy))
+	}
+	if c.TTL < 0 || c.LiveTTL < 0 {
+		errs = append(errs, errors.New("ttl and live_ttl: must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// Cache holds encoded results, so every caller decodes its own copy and
+// may change it freely.
+type Cache struct {
+	cfg Config
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// order holds the entries, the most recently used first.
+	order *list.List
+	used  int64
+	calls map[string]*call
+}
+
+type entry struct {
+	key     string
+	value   []byte
+	cost    int64
+	expires time.Time
+}
+
+type call struct {
+	done  chan struct{}
+	value []byte
+	err   error
+}
+
+func New(cfg Config) *Cache {
+	return &Cache{
+		cfg:     cfg,
+		now:     time.Now,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		calls:   make(map[string]*call),
+	}
+}
+
+// TTL returns how long the results of a query may be served, given
+// whether its range is relative to now and where it ends; a zero end is
+// open.
+func (c *Cache) TTL(relative bool, to time.Time) time.Duration {
+	if relative || to.IsZero() || to.After(c.now().Add(-settleTime)) {
+		return c.cfg.LiveTTL
+	}
+	return c.cfg.TTL
+}
+
+// Do decodes the result cached under key into dst. Without one, it calls
+// fetch, or waits for a caller already fetching key, and caches the result
+// for ttl. Errors are not cached. The outcome is recorded for the request
+// in ctx, labelled with operation.
+func (c *Cache) Do(ctx context.Context, operation, key string, ttl time.Duration, dst interface{}, fetch func(ctx context.Context) (interface{}, error)) error {
+	req := requestFrom(ctx)
+	if !req.noCache {
+		if value, remaining, ok := c.lookup(key); ok {
+			req.record(Status{Operation: operation, Outcome: OutcomeHit, TTL: remaining})
+			return json.Unmarshal(value, dst)
+		}
+	}
+
+	value, shared, err := c.fetch(ctx, key, ttl, fetch)
+	status := Status{Operation: operation, Outcome: OutcomeMiss, Refreshed: req.noCache}
+	switch {
+	case err != nil:
+		status.Outcome = OutcomeError
+	case shared:
+		status.Outcome = OutcomeCoalesced
+	default:
+		status.TTL = ttl
+	}
+	req.record(status)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(value, dst)
+}
+
+func (c *Cache) lookup(key string) ([]byte, time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, 0, false
+	}
+	e := elem.Value.(*entry)
+	remaining := e.expires.Sub(c.now())
+	if remaining <= 0 {
+		c.remove(elem)
+		return nil, 0, false
+	}
+	c.order.MoveToFront(elem)
+	return e.value, remaining, true
+}
+
+// fetch calls fn once for all concurrent callers of key. It reports
+// whether the result came from another caller's fetch.
+func (c *Cache) fetch(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) (interface{}, error)) ([]byte, bool, error) {
+	c.mu.Lock()
+	if cl, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-cl.done:
+			return cl.value, true, cl.err
+		case <-ctx.Done():
+			return nil, true, ctx.Err()
+		}
+	}
+	cl := &call{done: make(chan struct{})}
+	c.calls[key] = cl
+	c.mu.Unlock()
+
+	// Waiting callers must not fail because this one gave up.
+	fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fetchTimeout)
+	result, err := fn(fetchCtx)
+	cancel()
+	if err == nil {
+		cl.value, err = json.Marshal(result)
+	}
+	cl.err = err
+
+	c.mu.Lock()
+	delete(c.calls, key)
+	if err == nil && ttl > 0 {
+		c.store(key, cl.value, ttl)
+	}
+	c.mu.Unlock()
+	close(cl.done)
+
+	return cl.value, false, err
+}
+
+// store adds a result, evicting the least recently used ones to make room.
+// Results larger than the whole cache are not kept.
+func (c *Cache) store(key string, value []byte, ttl time.Duration) {
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+
+	cost, capacity := int64(1), int64(c.cfg.MaxEntries)
+	if c.cfg.Policy == PolicySize {
+		cost, capacity = int64(len(key)+len(value)), c.cfg.MaxBytes
+	}
+	if cost > capacity {
+		return
+	}
+	for c.used+cost > capacity {
+		c.remove(c.order.Back())
+	}
+
+	e := &entry{key: key, value: value, cost: cost, expires: c.now().Add(ttl)}
+	c.entries[key] = c.order.PushFront(e)
+	c.used += cost
+}
+
+func (c *Cache) remove(elem *list.Element) {
+	e := c.order.Remove(elem).(*entry)
+	delete(c.entries, e.key)
+	c.used -= e.cost
+}
+
+// Len returns the number of cached results.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
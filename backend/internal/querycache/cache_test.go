@@ -0,0 +1,201 @@
+package querycache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testConfig = Config{
+	Policy:     PolicyLRU,
+	MaxEntries: 2,
+	TTL:        5 * time.Minute,
+	LiveTTL:    10 * time.Second,
+}
+
+type clock struct{ now time.Time }
+
+func newCache(cfg Config) (*Cache, *clock) {
+	c := New(cfg)
+	clk := &clock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	c.now = func() time.Time { return clk.now }
+	return c, clk
+}
+
+// get runs a query for key whose result is value and returns the result
+// and the recorded status.
+func get(t *testing.T, c *Cache, ctx context.Context, key string, value int) (int, Status) {
+	t.Helper()
+	var status Status
+	ctx = WithRequest(ctx, requestFrom(ctx).noCache, func(s Status) { status = s })
+	var got int
+	err := c.Do(ctx, "search", key, time.Minute, &got, func(context.Context) (interface{}, error) {
+		return value, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got, status
+}
+
+func TestHitMissAndExpiry(t *testing.T) {
+	c, clk := newCache(testConfig)
+	ctx := context.Background()
+
+	if got, status := get(t, c, ctx, "a", 1); got != 1 || status.Outcome != OutcomeMiss {
+		t.Fatalf("expected a miss, got %d %+v", got, status)
+	}
+	clk.now = clk.now.Add(30 * time.Second)
+	if got, status := get(t, c, ctx, "a", 2); got != 1 || status.Outcome != OutcomeHit || status.TTL != 30*time.Second {
+		t.Fatalf("expected a hit with 30s left, got %d %+v", got, status)
+	}
+	clk.now = clk.now.Add(30 * time.Second)
+	if got, status := get(t, c, ctx, "a", 3); got != 3 || status.Outcome != OutcomeMiss {
+		t.Fatalf("expected the entry to expire, got %d %+v", got, status)
+	}
+
+	// no-cache skips the cached result and stores the fresh one.
+	noCache := WithRequest(ctx, true, nil)
+	if got, status := get(t, c, noCache, "a", 4); got != 4 || !status.Refreshed {
+		t.Fatalf("expected a refresh, got %d %+v", got, status)
+	}
+	if got, _ := get(t, c, ctx, "a", 5); got != 4 {
+		t.Fatalf("expected the refreshed result, got %d", got)
+	}
+}
+
+func TestErrorsAreNotCached(t *testing.T) {
+	c, _ := newCache(testConfig)
+	var v int
+	err := c.Do(context.Background(), "count", "a", time.Minute, &v, func(context.Context) (interface{}, error) {
+		return nil, errors.New("boom")
+	})
+	if err == nil || c.Len() != 0 {
+		t.Fatalf("expected an uncached error, got %v with %d entries", err, c.Len())
+	}
+}
+
+func TestLRUEviction(t *testing.T) {
+	c, _ := newCache(testConfig)
+	ctx := context.Background()
+	get(t, c, ctx, "a", 1)
+	get(t, c, ctx, "b", 2)
+	get(t, c, ctx, "a", 0) // a is now the most recently used
+	get(t, c, ctx, "c", 3)
+
+	if _, status := get(t, c, ctx, "a", 0); status.Outcome != OutcomeHit {
+		t.Fatal("expected a to stay cached")
+	}
+	if _, status := get(t, c, ctx, "b", 0); status.Outcome != OutcomeMiss {
+		t.Fatal("expected b to be evicted")
+	}
+}
+
+func TestSizePolicy(t *testing.T) {
+	c, _ := newCache(Config{Policy: PolicySize, MaxBytes: 10, TTL: time.Minute})
+	ctx := context.Background()
+	get(t, c, ctx, "a", 1000) // 1 + 4 bytes
+	get(t, c, ctx, "b", 2000)
+	if c.Len() != 2 {
+		t.Fatalf("expected both results to fit, got %d", c.Len())
+	}
+	get(t, c, ctx, "c", 3)
+	if c.Len() != 2 {
+		t.Fatalf("expected the oldest result to be evicted, got %d", c.Len())
+	}
+	get(t, c, ctx, "too-large-a-key", 1)
+	if _, ok := c.entries["too-large-a-key"]; ok {
+		t.Fatal("expected a result larger than the cache to be skipped")
+	}
+}
+
+func TestConcurrentRequestsAreCoalesced(t *testing.T) {
+	c, _ := newCache(testConfig)
+	var fetches atomic.Int32
+	release := make(chan struct{})
+
+	const callers = 5
+	var wg sync.WaitGroup
+	outcomes := make(chan string, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := WithRequest(context.Background(), false, func(s Status) { outcomes <- s.Outcome })
+			var v int
+			err := c.Do(ctx, "search", "a", time.Minute, &v, func(context.Context) (interface{}, error) {
+				fetches.Add(1)
+				<-release
+				return 42, nil
+			})
+			if err != nil || v != 42 {
+				t.Errorf("unexpected result %d, %v", v, err)
+			}
+		}()
+	}
+	// Let every caller reach the cache before the fetch completes.
+	for deadline := time.Now().Add(time.Second); fetches.Load() == 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(outcomes)
+
+	if n := fetches.Load(); n != 1 {
+		t.Fatalf("expected one fetch, got %d", n)
+	}
+	counts := make(map[string]int)
+	for outcome := range outcomes {
+		counts[outcome]++
+	}
+	if counts[OutcomeMiss] != 1 || counts[OutcomeCoalesced]+counts[OutcomeHit] != callers-1 {
+		t.Fatalf("unexpected outcomes %v", counts)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	c, clk := newCache(testConfig)
+	if got := c.TTL(true, clk.now.Add(-time.Hour)); got != testConfig.LiveTTL {
+		t.Errorf("expected relative ranges to be live, got %s", got)
+	}
+	if got := c.TTL(false, time.Time{}); got != testConfig.LiveTTL {
+		t.Errorf("expected open ranges to be live, got %s", got)
+	}
+	if got := c.TTL(false, clk.now.Add(-30*time.Second)); got != testConfig.LiveTTL {
+		t.Errorf("expected ranges ending just now to be live, got %s", got)
+	}
+	if got := c.TTL(false, clk.now.Add(-time.Hour)); got != testConfig.TTL {
+		t.Errorf("expected past ranges to use the long TTL, got %s", got)
+	}
+}
+
+func TestStatusString(t *testing.T) {
+	for _, tc := range []struct {
+		status Status
+		want   string
+	}{
+		{Status{Operation: "search", Outcome: OutcomeHit, TTL: 6500 * time.Millisecond}, "logana; hit; ttl=7; detail=search"},
+		{Status{Operation: "count", Outcome: OutcomeMiss, TTL: 10 * time.Second}, "logana; fwd=miss; stored; ttl=10; detail=count"},
+		{Status{Operation: "search", Outcome: OutcomeCoalesced}, "logana; fwd=miss; collapsed; detail=search"},
+		{Status{Operation: "search", Outcome: OutcomeMiss, Refreshed: true, TTL: time.Minute}, "logana; fwd=request; stored; ttl=60; detail=search"},
+	} {
+		if got := tc.status.String(); got != tc.want {
+			t.Errorf("expected %q, got %q", tc.want, got)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := testConfig.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, cfg := range []Config{{Policy: "fifo", MaxEntries: 1}, {Policy: PolicyLRU}, {Policy: PolicySize}, {Policy: PolicyLRU, MaxEntries: 1, TTL: -time.Second}} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", cfg)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package querycache
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/metrics"
+)
+
+// Cache outcomes.
+const (
+	OutcomeHit       = "hit"
+	OutcomeMiss      = "miss"
+	OutcomeCoalesced = "coalesced"
+	OutcomeError     = "error"
+)
+
+// Status describes how the cache answered one query.
+type Status struct {
+	Operation string
+	Outcome   string
+	// Refreshed is set when the request asked to skip cached results.
+	Refreshed bool
+	// TTL is how long a hit or a newly stored result stays cached.
+	TTL time.Duration
+}
+
+// String formats s as a Cache-Status header value (RFC 9211), such as
+// "logana; hit; ttl=7; detail=search".
+func (s Status) String() string {
+	parts := []string{"logana"}
+	switch s.Outcome {
+	case OutcomeHit:
+		parts = append(parts, "hit")
+	default:
+		fwd := "miss"
+		if s.Refreshed {
+			fwd = "request"
+		}
+		parts = append(parts, "fwd="+fwd)
+		if s.Outcome == OutcomeCoalesced {
+			parts = append(parts, "collapsed")
+		}
+		if s.Outcome == OutcomeMiss && s.TTL > 0 {
+			parts = append(parts, "stored")
+		}
+	}
+	if s.TTL > 0 {
+		parts = append(parts, fmt.Sprintf("ttl=%d", int64(math.Ceil(s.TTL.Seconds()))))
+	}
+	parts = append(parts, "detail="+s.Operation)
+	return strings.Join(parts, "; ")
+}
+
+type requestKey struct{}
+
+type request struct {
+	noCache bool
+	observe func(Status)
+}
+
+// WithRequest returns a context whose cached queries skip cached results
+// if noCache is set and report their status to observe, which may be nil.
+func WithRequest(ctx context.Context, noCache bool, observe func(Status)) context.Context {
+	return context.WithValue(ctx, requestKey{}, &request{noCache: noCache, observe: observe})
+}
+
+func requestFrom(ctx context.Context) *request {
+	if req, ok := ctx.Value(requestKey{}).(*request); ok {
+		return req
+	}
+	return &request{}
+}
+
+func (r *request) record(s Status) {
+	metrics.QueryCache(s.Operation, s.Outcome)
+	if r.observe != nil {
+		r.observe(s)
+	}
+}
+
+// Middleware adds a Cache-Status header for every cached query a request
+// runs. Requests sent with "Cache-Control: no-cache" skip cached results
+// and store fresh ones.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		noCache := strings.Contains(strings.ToLower(c.GetHeader("Cache-Control")), "no-cache")
+		ctx := WithRequest(c.Request.Context(), noCache, func(s Status) {
+			c.Writer.Header().Add("Cache-Status", s.String())
+		})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
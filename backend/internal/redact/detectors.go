@@ -0,0 +1,111 @@
+package redact
+
+import (
+	"net"
+	"regexp"
+	"strings"
+)
+
+// detector finds one kind of sensitive value. validate, when set, filters
+// regexp candidates that are not real matches (e.g. Luhn for card numbers).
+// joins, when set, reports characters that may not touch a candidate on
+// either side, for patterns that \b cannot bound.
+type detector struct {
+	name     string
+	re       *regexp.Regexp
+	validate func(string) bool
+	joins    func(byte) bool
+	mode     Mode
+}
+
+// matches reports whether the candidate s[start:end] is a real match.
+func (d *detector) matches(s string, start, end int) bool {
+	if d.joins != nil && ((start > 0 && d.joins(s[start-1])) || (end < len(s) && d.joins(s[end]))) {
+		return false
+	}
+	return d.validate == nil || d.validate(s[start:end])
+}
+
+var builtinDetectors = map[string]func() *detector{
+	"jwt": func() *detector {
+		return &detector{
+			re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{10,}`),
+		}
+	},
+	"api_key": func() *detector {
+		return &detector{
+			re: regexp.MustCompile(`\b(?:` +
+				`(?:AKIA|ASIA)[0-9A-Z]{16}` + // AWS access key id
+				`|gh[pousr]_[A-Za-z0-9]{36,}` + // GitHub tokens
+				`|xox[abposr]-[A-Za-z0-9-]{10,}` + // Slack tokens
+				`|AIza[0-9A-Za-z_-]{35}` + // Google API keys
+				`|(?:sk|rk)_(?:live|test)_[0-9A-Za-z]{16,}` + // Stripe secret keys
+				`|glpat-[0-9A-Za-z_-]{20}` + // GitLab personal access tokens
+				`)\b`),
+		}
+	},
+	"aws_secret_key": func() *detector {
+		return &detector{
+			re: regexp.MustCompile(`(?i)aws_?secret_?(?:access_?)?key["']?\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}`),
+		}
+	},
+	"email": func() *detector {
+		return &detector{
+			re: regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`),
+		}
+	},
+	"credit_card": func() *detector {
+		return &detector{
+			re:       regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+			validate: luhnValid,
+		}
+	},
+	"ipv4": func() *detector {
+		return &detector{
+			re: regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\.){3}(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\b`),
+		}
+	},
+	"ipv6": func() *detector {
+		return &detector{
+			re: regexp.MustCompile(`(?i)(?:[0-9a-f]{0,4}:){2,7}[0-9a-f]{0,4}`),
+			validate: func(s string) bool {
+				return strings.Count(s, ":") >= 2 && len(s) > 2 && net.ParseIP(s) != nil
+			},
+			// Keeps "d::" in std::string or "::" in Foo::bar from matching.
+			joins: func(c byte) bool {
+				return c == ':' || c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+			},
+		}
+	},
+}
+
+// builtinOrder runs the most specific detectors first so that, for example,
+// a JWT is not partially consumed by the API key detector.
+var builtinOrder = []string{"jwt", "api_key", "aws_secret_key", "email", "credit_card", "ipv6", "ipv4"}
+
+func luhnValid(s string) bool {
+	var digits []int
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, int(r-'0'))
+		}
+	}
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := digits[i]
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,273 @@
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+// Mode is what happens to a detected value.
+type Mode string
+
+const (
+	// ModeMask replaces the value with "[REDACTED:<detector>]".
+	ModeMask Mode = "mask"
+	// ModeHash replaces the value with a keyed HMAC-SHA256 digest, so the
+	// same value always yields the same token and can still be joined on.
+	ModeHash Mode = "hash"
+	// ModeDrop discards the whole log.
+	ModeDrop Mode = "drop"
+)
+
+// hashLength is the number of hex characters of the HMAC kept in output.
+const hashLength = 16
+
+type DetectorConfig struct {
+	Mode Mode `yaml:"mode"`
+}
+
+type CustomDetector struct {
+	Name    string `yaml:"name"`
+	Pattern string `yaml:"pattern"`
+	Mode    Mode   `yaml:"mode"`
+}
+
+// Config selects the built-in detectors to enable and adds custom ones.
+// Detectors without a mode use Mode.
+type Config struct {
+	Mode             Mode                      `yaml:"mode"`
+	Detectors        map[string]DetectorConfig `yaml:"detectors"`
+	Custom           []CustomDetector          `yaml:"custom"`
+	SkipMetadataKeys []string                  `yaml:"skip_metadata_keys"`
+}
+
+type Redactor struct {
+	detectors []*detector
+	skipKeys  map[string]bool
+	hmacKey   []byte
+	counter   *Counter
+}
+
+// Counter tallies redactions per tenant, source and detector. It lives
+// apart from the Redactor so the totals survive a reload that replaces the
+// rules.
+type Counter struct {
+	mu     sync.Mutex
+	counts map[string]map[string]map[string]int64
+}
+
+// maxSources bounds the sources counted per tenant; redactions from sources
+// seen after that are counted under "other".
+const maxSources = 200
+
+func NewCounter() *Counter {
+	return &Counter{counts: make(map[string]map[string]map[string]int64)}
+}
+
+// LoadFile builds a redactor from the YAML config at path. hmacKey is only
+// required when a detector uses ModeHash.
+func LoadFile(path string, hmacKey []byte) (*Redactor, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading redaction config: %w", err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("error parsing redaction config: %w", err)
+	}
+	return New(cfg, hmacKey)
+}
+
+func New(cfg Config, hmacKey []byte) (*Redactor, error) {
+	if cfg.Mode == "" {
+		cfg.Mode = ModeMask
+	}
+
+	r := &Redactor{
+		skipKeys: make(map[string]bool, len(cfg.SkipMetadataKeys)),
+		hmacKey:  hmacKey,
+		counter:  NewCounter(),
+	}
+	for _, key := range cfg.SkipMetadataKeys {
+		r.skipKeys[key] = true
+	}
+
+	for name := range cfg.Detectors {
+		if _, ok := builtinDetectors[name]; !ok {
+			return nil, fmt.Errorf("unknown detector %q", name)
+		}
+	}
+	for _, name := range builtinOrder {
+		dc, ok := cfg.Detectors[name]
+		if !ok {
+			continue
+		}
+		d := builtinDetectors[name]()
+		d.name = name
+		d.mode = dc.Mode
+		r.detectors = append(r.detectors, d)
+	}
+
+	for _, c := range cfg.Custom {
+		if c.Name == "" || c.Pattern == "" {
+			return nil, errors.New("custom detectors need a name and a pattern")
+		}
+		re, err := regexp.Compile(c.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("custom detector %s: %w", c.Name, err)
+		}
+		r.detectors = append(r.detectors, &detector{name: c.Name, re: re, mode: c.Mode})
+	}
+
+	for _, d := range r.detectors {
+		if d.mode == "" {
+			d.mode = cfg.Mode
+		}
+		switch d.mode {
+		case ModeMask, ModeDrop:
+		case ModeHash:
+			if len(hmacKey) == 0 {
+				return nil, fmt.Errorf("detector %s uses hash mode but no HMAC key is configured", d.name)
+			}
+		default:
+			return nil, fmt.Errorf("detector %s: unknown mode %q", d.name, d.mode)
+		}
+	}
+
+	return r, nil
+}
+
+// Redact scrubs the message and metadata values of log, ingested for
+// tenantID, in place. It returns false when a detector in drop mode matched
+// and the log must be discarded.
+func (r *Redactor) Redact(tenantID string, log *models.Log) bool {
+	hits := make(map[string]int64)
+
+	var dropped bool
+	log.Message, dropped = r.redactString(log.Message, hits)
+	for key, value := range log.Metadata {
+		if dropped {
+			break
+		}
+		if r.skipKeys[key] {
+			continue
+		}
+		var redacted string
+		redacted, dropped = r.redactString(value, hits)
+		log.Metadata[key] = redacted
+	}
+
+	r.counter.record(tenantID, log.Source, hits)
+	return !dropped
+}
+
+func (r *Redactor) redactString(s string, hits map[string]int64) (string, bool) {
+	for _, d := range r.detectors {
+		locs := d.re.FindAllStringIndex(s, -1)
+		if len(locs) == 0 {
+			continue
+		}
+
+		var b strings.Builder
+		last := 0
+		for _, loc := range locs {
+			if !d.matches(s, loc[0], loc[1]) {
+				continue
+			}
+			match := s[loc[0]:loc[1]]
+			hits[d.name]++
+			if d.mode == ModeDrop {
+				return s, true
+			}
+			b.WriteString(s[last:loc[0]])
+			if d.mode == ModeHash {
+				fmt.Fprintf(&b, "[%s:%s]", d.name, r.hash(match))
+			} else {
+				fmt.Fprintf(&b, "[REDACTED:%s]", d.name)
+			}
+			last = loc[1]
+		}
+		b.WriteString(s[last:])
+		s = b.String()
+	}
+	return s, false
+}
+
+func (r *Redactor) hash(value string) string {
+	mac := hmac.New(sha256.New, r.hmacKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))[:hashLength]
+}
+
+// SetCounter makes r count into c instead of its own counter. Call it
+// before r is used.
+func (r *Redactor) SetCounter(c *Counter) {
+	r.counter = c
+}
+
+// Stats returns the number of redactions per tenant, source and detector
+// counted by r's counter.
+func (r *Redactor) Stats() map[string]map[string]map[string]int64 {
+	return r.counter.Stats()
+}
+
+func (c *Counter) record(tenantID, source string, hits map[string]int64) {
+	if len(hits) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	byTenant := c.counts[tenantID]
+	if byTenant == nil {
+		byTenant = make(map[string]map[string]int64)
+		c.counts[tenantID] = byTenant
+	}
+	bySource := byTenant[source]
+	if bySource == nil {
+		if len(byTenant) >= maxSources {
+			source = "other"
+			bySource = byTenant[source]
+		}
+		if bySource == nil {
+			bySource = make(map[string]int64)
+			byTenant[source] = bySource
+		}
+	}
+	for name, n := range hits {
+		bySource[name] += n
+	}
+}
+
+// Stats returns the number of redactions per tenant, source and detector
+// since the counter was created.
+func (c *Counter) Stats() map[string]map[string]map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := make(map[string]map[string]map[string]int64, len(c.counts))
+	for tenantID, byTenant := range c.counts {
+		sources := make(map[string]map[string]int64, len(byTenant))
+		for source, bySource := range byTenant {
+			copied := make(map[string]int64, len(bySource))
+			for name, n := range bySource {
+				copied[name] = n
+			}
+			sources[source] = copied
+		}
+		out[tenantID] = sources
+	}
+	return out
+}
@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/config"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+type AlertRuleRepository interface {
+	EnsureIndex(ctx context.Context) error
+	Save(ctx context.Context, rule *models.AlertRule) error
+	GetByID(ctx context.Context, id string) (*models.AlertRule, error)
+	// List returns the rules of tenantID, or of every tenant if it is empty.
+	List(ctx context.Context, tenantID string) ([]models.AlertRule, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// alertRuleMapping leaves labels unindexed so their keys cannot grow the
+// mapping.
+const alertRuleMapping = `{
+  "mappings": {
+    "properties": {
+      "id":          {"type": "keyword"},
+      "tenant":      {"type": "keyword"},
+      "name":        {"type": "keyword"},
+      "description": {"type": "text"},
+      "query":       {"type": "text"},
+      "level":       {"type": "keyword"},
+      "source":      {"type": "keyword"},
+      "condition":   {"type": "object", "enabled": false},
+      "window":      {"type": "keyword"},
+      "for":         {"type": "keyword"},
+      "interval":    {"type": "keyword"},
+      "labels":      {"type": "object", "enabled": false},
+      "disabled":    {"type": "boolean"},
+      "created_at":  {"type": "date"},
+      "updated_at":  {"type": "date"}
+    }
+  }
+}`
+
+const maxAlertRules = 1000
+
+type alertRuleRepository struct {
+	es *config.ElasticsearchConfig
+}
+
+func NewAlertRuleRepository(es *config.ElasticsearchConfig) AlertRuleRepository {
+	return &alertRuleRepository{es: es}
+}
+
+// EnsureIndex creates the rule index with its mapping if it does not exist.
+func (r *alertRuleRepository) EnsureIndex(ctx context.Context) error {
+	res, err := r.es.Client.Indices.Exists(
+		[]string{r.es.AlertRuleIndexName},
+		r.es.Client.Indices.Exists.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("error checking alert rule index: %w", err)
+	}
+	res.Body.Close()
+	if res.StatusCode == 200 {
+		return nil
+	}
+
+	res, err = r.es.Client.Indices.Create(
+		r.es.AlertRuleIndexName,
+		r.es.Client.Indices.Create.WithContext(ctx),
+		r.es.Client.Indices.Create.WithBody(strings.NewReader(alertRuleMapping)),
+	)
+	if err != nil {
+		return fmt.Errorf("error creating alert rule index: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() && !strings.Contains(res.String(), "resource_already_exists_exception") {
+		return fmt.Errorf("error creating alert rule index: %s", res.String())
+	}
+
+	return nil
+}
+
+// Save creates or replaces the rule document with the rule's ID.
+func (r *alertRuleRepository) Save(ctx context.Context, rule *models.AlertRule) error {
+	body, err := json.Marshal(rule)
+	if err != nil {
+		return fmt.Errorf("error marshaling alert rule: %w", err)
+	}
+
+	res, err := r.es.Client.Index(
+		r.es.AlertRuleIndexName,
+		strings.NewReader(string(body)),
+		r.es.Client.Index.WithDocumentID(rule.ID),
+		r.es.Client.Index.WithContext(ctx),
+		r.es.Client.Index.WithRefresh("true"),
+	)
+	if err != nil {
+		return fmt.Errorf("error saving alert rule: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("error saving alert rule: %s", res.String())
+	}
+
+	return nil
+}
+
+func (r *alertRuleRepository) GetByID(ctx context.Context, id string) (*models.AlertRule, error) {
+	res, err := r.es.Client.Get(
+		r.es.AlertRuleIndexName,
+		id,
+		r.es.Client.Get.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting alert rule: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		if res.StatusCode == 404 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting alert rule: %s", res.String())
+	}
+
+	var result struct {
+		Source models.AlertRule `json:"_source"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	return &result.Source, nil
+}
+
+func (r *alertRuleRepository) List(ctx context.Context, tenantID string) ([]models.AlertRule, error) {
+	query := map[string]interface{}{
+		"size":  maxAlertRules,
+		"query": map[string]interface{}{"match_all": map[string]interface{}{}},
+		"sort": []map[string]interface{}{
+			{"name": map[string]string{"order": "asc"}},
+		},
+	}
+	if tenantID != "" {
+		query["query"] = map[string]interface{}{
+			"term": map[string]interface{}{"tenant": tenantID},
+		}
+	}
+
+	var buf strings.Builder
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		return nil, fmt.Errorf("error encoding query: %w", err)
+	}
+
+	res, err := r.es.Client.Search(
+		r.es.Client.Search.WithContext(ctx),
+		r.es.Client.Search.WithIndex(r.es.AlertRuleIndexName),
+		r.es.Client.Search.WithBody(strings.NewReader(buf.String())),
+		r.es.Client.Search.WithIgnoreUnavailable(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error listing alert rules: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("error listing alert rules: %s", res.String())
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.AlertRule `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	rules := make([]models.AlertRule, len(result.Hits.Hits))
+	for i, hit := range result.Hits.Hits {
+		rules[i] = hit.Source
+	}
+
+	return rules, nil
+}
+
+func (r *alertRuleRepository) Delete(ctx context.Context, id string) error {
+	res, err := r.es.Client.Delete(
+		r.es.AlertRuleIndexName,
+		id,
+		r.es.Client.Delete.WithContext(ctx),
+		r.es.Client.Delete.WithRefresh("true"),
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting alert rule: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() && res.StatusCode != 404 {
+		return fmt.Errorf("error deleting alert rule: %s", res.String())
+	}
+
+	return nil
+}
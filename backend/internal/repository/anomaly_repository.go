@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/config"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+type AnomalyRepository interface {
+	EnsureIndex(ctx context.Context) error
+	// Save creates or replaces the anomaly document with the anomaly's ID.
+	Save(ctx context.Context, anomaly *models.Anomaly) error
+	// List returns tenantID's anomalies matching filter, the latest first.
+	List(ctx context.Context, tenantID string, filter models.AnomalyFilter, limit int) ([]models.Anomaly, error)
+}
+
+const anomalyMapping = `{
+  "mappings": {
+    "properties": {
+      "id":          {"type": "keyword"},
+      "tenant":      {"type": "keyword"},
+      "source":      {"type": "keyword"},
+      "level":       {"type": "keyword"},
+      "direction":   {"type": "keyword"},
+      "score":       {"type": "double"},
+      "count":       {"type": "long"},
+      "expected":    {"type": "double"},
+      "stddev":      {"type": "double"},
+      "start":       {"type": "date"},
+      "end":         {"type": "date"},
+      "detected_at": {"type": "date"}
+    }
+  }
+}`
+
+type anomalyRepository struct {
+	es *config.ElasticsearchConfig
+}
+
+func NewAnomalyRepository(es *config.ElasticsearchConfig) AnomalyRepository {
+	return &anomalyRepository{es: es}
+}
+
+// EnsureIndex creates the anomaly index with its mapping if it does not exist.
+func (r *anomalyRepository) EnsureIndex(ctx context.Context) error {
+	res, err := r.es.Client.Indices.Exists(
+		[]string{r.es.AnomalyIndexName},
+		r.es.Client.Indices.Exists.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("error checking anomaly index: %w", err)
+	}
+	res.Body.Close()
+	if res.StatusCode == 200 {
+		return nil
+	}
+
+	res, err = r.es.Client.Indices.Create(
+		r.es.AnomalyIndexName,
+		r.es.Client.Indices.Create.WithContext(ctx),
+		r.es.Client.Indices.Create.WithBody(strings.NewReader(anomalyMapping)),
+	)
+	if err != nil {
+		return fmt.Errorf("error creating anomaly index: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() && !strings.Contains(res.String(), "resource_already_exists_exception") {
+		return fmt.Errorf("error creating anomaly index: %s", res.String())
+	}
+
+	return nil
+}
+
+func (r *anomalyRepository) Save(ctx context.Context, anomaly *models.Anomaly) error {
+	body, err := json.Marshal(anomaly)
+	if err != nil {
+		return fmt.Errorf("error marshaling anomaly: %w", err)
+	}
+
+	res, err := r.es.Client.Index(
+		r.es.AnomalyIndexName,
+		strings.NewReader(string(body)),
+		r.es.Client.Index.WithDocumentID(anomaly.ID),
+		r.es.Client.Index.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("error saving anomaly: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("error saving anomaly: %s", res.String())
+	}
+
+	return nil
+}
+
+func (r *anomalyRepository) List(ctx context.Context, tenantID string, filter models.AnomalyFilter, limit int) ([]models.Anomaly, error) {
+	filters := []map[string]interface{}{
+		{"term": map[string]interface{}{"tenant": tenantID}},
+	}
+	if filter.Source != "" {
+		filters = append(filters, map[string]interface{}{
+			"term": map[string]interface{}{"source": filter.Source},
+		})
+	}
+	if filter.Level != "" {
+		filters = append(filters, map[string]interface{}{
+			"term": map[string]interface{}{"level": filter.Level},
+		})
+	}
+	if !filter.From.IsZero() || !filter.To.IsZero() {
+		timeRange := map[string]interface{}{}
+		if !filter.From.IsZero() {
+			timeRange["gte"] = filter.From.Format(time.RFC3339Nano)
+		}
+		if !filter.To.IsZero() {
+			timeRange["lte"] = filter.To.Format(time.RFC3339Nano)
+		}
+		filters = append(filters, map[string]interface{}{
+			"range": map[string]interface{}{"start": timeRange},
+		})
+	}
+
+	query := map[string]interface{}{
+		"size":  limit,
+		"query": map[string]interface{}{"bool": map[string]interface{}{"filter": filters}},
+		"sort": []map[string]interface{}{
+			{"start": map[string]string{"order": "desc"}},
+			{"score": map[string]string{"order": "desc"}},
+		},
+	}
+
+	var buf strings.Builder
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		return nil, fmt.Errorf("error encoding query: %w", err)
+	}
+
+	res, err := r.es.Client.Search(
+		r.es.Client.Search.WithContext(ctx),
+		r.es.Client.Search.WithIndex(r.es.AnomalyIndexName),
+		r.es.Client.Search.WithBody(strings.NewReader(buf.String())),
+		r.es.Client.Search.WithIgnoreUnavailable(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error listing anomalies: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("error listing anomalies: %s", res.String())
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.Anomaly `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	anomalies := make([]models.Anomaly, len(result.Hits.Hits))
+	for i, hit := range result.Hits.Hits {
+		anomalies[i] = hit.Source
+	}
+
+	return anomalies, nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/config"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+type APIKeyRepository interface {
+	Save(ctx context.Context, key *models.APIKey) error
+	GetByID(ctx context.Context, id string) (*models.APIKey, error)
+	List(ctx context.Context) ([]models.APIKey, error)
+}
+
+type apiKeyRepository struct {
+	es *config.ElasticsearchConfig
+}
+
+func NewAPIKeyRepository(es *config.ElasticsearchConfig) APIKeyRepository {
+	return &apiKeyRepository{es: es}
+}
+
+// Save creates or replaces the key document with the key's ID.
+func (r *apiKeyRepository) Save(ctx context.Context, key *models.APIKey) error {
+	body, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("error marshaling api key: %w", err)
+	}
+
+	res, err := r.es.Client.Index(
+		r.es.APIKeyIndexName,
+		strings.NewReader(string(body)),
+		r.es.Client.Index.WithDocumentID(key.ID),
+		r.es.Client.Index.WithContext(ctx),
+		r.es.Client.Index.WithRefresh("true"),
+	)
+	if err != nil {
+		return fmt.Errorf("error saving api key: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("error saving api key: %s", res.String())
+	}
+
+	return nil
+}
+
+func (r *apiKeyRepository) GetByID(ctx context.Context, id string) (*models.APIKey, error) {
+	res, err := r.es.Client.Get(
+		r.es.APIKeyIndexName,
+		id,
+		r.es.Client.Get.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting api key: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		if res.StatusCode == 404 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting api key: %s", res.String())
+	}
+
+	var result struct {
+		Source models.APIKey `json:"_source"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	return &result.Source, nil
+}
+
+func (r *apiKeyRepository) List(ctx context.Context) ([]models.APIKey, error) {
+	query := map[string]interface{}{
+		"size": 1000,
+		"sort": []map[string]interface{}{
+			{"created_at": map[string]string{"order": "desc"}},
+		},
+	}
+
+	var buf strings.Builder
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		return nil, fmt.Errorf("error encoding query: %w", err)
+	}
+
+	res, err := r.es.Client.Search(
+		r.es.Client.Search.WithContext(ctx),
+		r.es.Client.Search.WithIndex(r.es.APIKeyIndexName),
+		r.es.Client.Search.WithBody(strings.NewReader(buf.String())),
+		r.es.Client.Search.WithIgnoreUnavailable(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error listing api keys: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("error listing api keys: %s", res.String())
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.APIKey `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	keys := make([]models.APIKey, len(result.Hits.Hits))
+	for i, hit := range result.Hits.Hits {
+		keys[i] = hit.Source
+	}
+
+	return keys, nil
+}
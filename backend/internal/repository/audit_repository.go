@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/config"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+// AuditRepository is append-only: events can be added and queried but never
+// changed or removed through the API.
+type AuditRepository interface {
+	EnsureIndex(ctx context.Context) error
+	Append(ctx context.Context, event *models.AuditEvent) error
+	Search(ctx context.Context, filter models.AuditFilter, page, limit int) ([]models.AuditEvent, error)
+}
+
+// auditMapping keeps snapshots stored but unindexed, so logs and API keys of
+// any shape can be recorded without mapping conflicts.
+const auditMapping = `{
+  "mappings": {
+    "properties": {
+      "timestamp":   {"type": "date"},
+      "action":      {"type": "keyword"},
+      "outcome":     {"type": "keyword"},
+      "error":       {"type": "text"},
+      "actor_id":    {"type": "keyword"},
+      "actor_name":  {"type": "keyword"},
+      "actor_kind":  {"type": "keyword"},
+      "tenant":      {"type": "keyword"},
+      "remote_addr": {"type": "keyword"},
+      "user_agent":  {"type": "keyword"},
+      "resource":    {"type": "keyword"},
+      "resource_id": {"type": "keyword"},
+      "before":      {"type": "object", "enabled": false},
+      "after":       {"type": "object", "enabled": false},
+      "details":     {"type": "object", "enabled": false}
+    }
+  }
+}`
+
+type auditRepository struct {
+	es *config.ElasticsearchConfig
+}
+
+func NewAuditRepository(es *config.ElasticsearchConfig) AuditRepository {
+	return &auditRepository{es: es}
+}
+
+// EnsureIndex creates the audit index with its mapping if it does not exist.
+func (r *auditRepository) EnsureIndex(ctx context.Context) error {
+	res, err := r.es.Client.Indices.Exists(
+		[]string{r.es.AuditIndexName},
+		r.es.Client.Indices.Exists.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("error checking audit index: %w", err)
+	}
+	res.Body.Close()
+	if res.StatusCode == 200 {
+		return nil
+	}
+
+	res, err = r.es.Client.Indices.Create(
+		r.es.AuditIndexName,
+		r.es.Client.Indices.Create.WithContext(ctx),
+		r.es.Client.Indices.Create.WithBody(strings.NewReader(auditMapping)),
+	)
+	if err != nil {
+		return fmt.Errorf("error creating audit index: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() && !strings.Contains(res.String(), "resource_already_exists_exception") {
+		return fmt.Errorf("error creating audit index: %s", res.String())
+	}
+
+	return nil
+}
+
+func (r *auditRepository) Append(ctx context.Context, event *models.AuditEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error marshaling audit event: %w", err)
+	}
+
+	res, err := r.es.Client.Index(
+		r.es.AuditIndexName,
+		strings.NewReader(string(body)),
+		r.es.Client.Index.WithContext(ctx),
+		r.es.Client.Index.WithOpType("create"),
+		r.es.Client.Index.WithRefresh("true"),
+	)
+	if err != nil {
+		return fmt.Errorf("error recording audit event: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("error recording audit event: %s", res.String())
+	}
+
+	return nil
+}
+
+func (r *auditRepository) Search(ctx context.Context, filter models.AuditFilter, page, limit int) ([]models.AuditEvent, error) {
+	query := map[string]interface{}{
+		"from":  (page - 1) * limit,
+		"size":  limit,
+		"query": buildAuditQuery(filter),
+		"sort": []map[string]interface{}{
+			{"timestamp": map[string]string{"order": "desc"}},
+		},
+	}
+
+	var buf strings.Builder
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		return nil, fmt.Errorf("error encoding query: %w", err)
+	}
+
+	res, err := r.es.Client.Search(
+		r.es.Client.Search.WithContext(ctx),
+		r.es.Client.Search.WithIndex(r.es.AuditIndexName),
+		r.es.Client.Search.WithBody(strings.NewReader(buf.String())),
+		r.es.Client.Search.WithIgnoreUnavailable(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error searching audit events: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("error searching audit events: %s", res.String())
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				ID     string            `json:"_id"`
+				Source models.AuditEvent `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	events := make([]models.AuditEvent, len(result.Hits.Hits))
+	for i, hit := range result.Hits.Hits {
+		events[i] = hit.Source
+		events[i].ID = hit.ID
+	}
+
+	return events, nil
+}
+
+func buildAuditQuery(filter models.AuditFilter) map[string]interface{} {
+	var filters []interface{}
+
+	terms := map[string]string{
+		"action":      filter.Action,
+		"actor_id":    filter.ActorID,
+		"resource":    filter.Resource,
+		"resource_id": filter.ResourceID,
+		"tenant":      filter.Tenant,
+		"outcome":     filter.Outcome,
+	}
+	for field, value := range terms {
+		if value != "" {
+			filters = append(filters, map[string]interface{}{
+				"term": map[string]interface{}{field: value},
+			})
+		}
+	}
+
+	if !filter.From.IsZero() || !filter.To.IsZero() {
+		timeRange := map[string]interface{}{}
+		if !filter.From.IsZero() {
+			timeRange["gte"] = filter.From.Format(time.RFC3339Nano)
+		}
+		if !filter.To.IsZero() {
+			timeRange["lte"] = filter.To.Format(time.RFC3339Nano)
+		}
+		filters = append(filters, map[string]interface{}{
+			"range": map[string]interface{}{"timestamp": timeRange},
+		})
+	}
+
+	if len(filters) == 0 {
+		return map[string]interface{}{"match_all": map[string]interface{}{}}
+	}
+	return map[string]interface{}{"bool": map[string]interface{}{"filter": filters}}
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/querycache"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tenant"
+)
+
+// cachedLogRepository serves searches and counts from a query cache. The
+// cache key is the compiled query, with relative times kept as written so
+// "the last 15 minutes" hits the same entry on every refresh.
+type cachedLogRepository struct {
+	LogRepository
+	cache *querycache.Cache
+
+	// generations counts the changes made through this repository per
+	// tenant; it is part of every key, so an update or deletion is never
+	// hidden by an earlier result. Logs indexed one at a time do not count:
+	// they land in the present, which live ranges refresh often.
+	generations sync.Map
+}
+
+func NewCachedLogRepository(next LogRepository, cache *querycache.Cache) LogRepository {
+	return &cachedLogRepository{LogRepository: next, cache: cache}
+}
+
+func (r *cachedLogRepository) Search(ctx context.Context, filter models.LogFilter, page, limit int) ([]models.Log, error) {
+	var logs []models.Log
+	err := r.do(ctx, "search", filter, &logs, func(ctx context.Context) (interface{}, error) {
+		return r.LogRepository.Search(ctx, filter, page, limit)
+	}, page, limit)
+	return logs, err
+}
+
+func (r *cachedLogRepository) SearchHits(ctx context.Context, filter models.LogFilter, page, limit int, opts models.SearchOptions) (*models.SearchResult, error) {
+	var result *models.SearchResult
+	err := r.do(ctx, "search", filter, &result, func(ctx context.Context) (interface{}, error) {
+		return r.LogRepository.SearchHits(ctx, filter, page, limit, opts)
+	}, page, limit, opts)
+	return result, err
+}
+
+func (r *cachedLogRepository) Count(ctx context.Context, filter models.LogFilter) (int64, error) {
+	var n int64
+	err := r.do(ctx, "count", filter, &n, func(ctx context.Context) (interface{}, error) {
+		return r.LogRepository.Count(ctx, filter)
+	})
+	return n, err
+}
+
+func (r *cachedLogRepository) CountPatterns(ctx context.Context, filter models.LogFilter, ids []string, size int) ([]models.PatternCount, error) {
+	var counts []models.PatternCount
+	err := r.do(ctx, "count_patterns", filter, &counts, func(ctx context.Context) (interface{}, error) {
+		return r.LogRepository.CountPatterns(ctx, filter, ids, size)
+	}, ids, size)
+	return counts, err
+}
+
+func (r *cachedLogRepository) Update(ctx context.Context, log *models.Log) error {
+	err := r.LogRepository.Update(ctx, log)
+	if err == nil {
+		r.invalidate(ctx)
+	}
+	return err
+}
+
+func (r *cachedLogRepository) Delete(ctx context.Context, id string) error {
+	err := r.LogRepository.Delete(ctx, id)
+	if err == nil {
+		r.invalidate(ctx)
+	}
+	return err
+}
+
+// BulkCreate invalidates the cache because imports often fill in the past.
+func (r *cachedLogRepository) BulkCreate(ctx context.Context, logs []models.Log) ([]error, error) {
+	itemErrs, err := r.LogRepository.BulkCreate(ctx, logs)
+	r.invalidate(ctx)
+	return itemErrs, err
+}
+
+func (r *cachedLogRepository) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	deleted, err := r.LogRepository.DeleteBefore(ctx, cutoff)
+	if deleted > 0 {
+		r.invalidate(ctx)
+	}
+	return deleted, err
+}
+
+func (r *cachedLogRepository) do(ctx context.Context, operation string, filter models.LogFilter, dst interface{}, fetch func(ctx context.Context) (interface{}, error), args ...interface{}) error {
+	key, err := r.key(ctx, operation, filter, args)
+	if err != nil {
+		return err
+	}
+	relative := filter.RelativeFrom != "" || filter.RelativeTo != ""
+	return r.cache.Do(ctx, operation, key, r.cache.TTL(relative, filter.To), dst, fetch)
+}
+
+// key identifies a query by its tenant, the tenant's generation and a hash
+// of the normalized compiled query.
+func (r *cachedLogRepository) key(ctx context.Context, operation string, filter models.LogFilter, args []interface{}) (string, error) {
+	tenantID := tenant.FromContext(ctx)
+
+	normalized := filter
+	normalized.Query = strings.Join(strings.Fields(filter.Query), " ")
+	normalized.Level = strings.ToLower(filter.Level)
+	normalized.RestrictedFields = append([]string(nil), filter.RestrictedFields...)
+	sort.Strings(normalized.RestrictedFields)
+	// The range is part of the key as written, not compiled.
+	normalized.From, normalized.To = time.Time{}, time.Time{}
+
+	body, err := json.Marshal(struct {
+		Query interface{}   `json:"query"`
+		From  string        `json:"from"`
+		To    string        `json:"to"`
+		Args  []interface{} `json:"args"`
+	}{
+		Query: buildFilterQuery(normalized),
+		From:  rangeKey(filter.From, filter.RelativeFrom),
+		To:    rangeKey(filter.To, filter.RelativeTo),
+		Args:  args,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error encoding cache key: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	return fmt.Sprintf("%s/%s/%d/%s", operation, tenantID, r.generation(tenantID).Load(), hex.EncodeToString(sum[:])), nil
+}
+
+func rangeKey(t time.Time, relative string) string {
+	if relative != "" {
+		return relative
+	}
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func (r *cachedLogRepository) generation(tenantID string) *atomic.Uint64 {
+	gen, _ := r.generations.LoadOrStore(tenantID, new(atomic.Uint64))
+	return gen.(*atomic.Uint64)
+}
+
+func (r *cachedLogRepository) invalidate(ctx context.Context) {
+	r.generation(tenant.FromContext(ctx)).Add(1)
+}
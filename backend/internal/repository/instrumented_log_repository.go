@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/metrics"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tracing"
+)
+
+// instrumentedLogRepository records a span, Elasticsearch latency and errors
+// for every operation of the wrapped repository.
+type instrumentedLogRepository struct {
+	next LogRepository
+}
+
+func NewInstrumentedLogRepository(next LogRepository) LogRepository {
+	return &instrumentedLogRepository{next: next}
+}
+
+type operation struct {
+	name  string
+	span  trace.Span
+	start time.Time
+}
+
+func startOperation(ctx context.Context, name string) (context.Context, *operation) {
+	ctx, span := tracing.Start(ctx, "LogRepository."+name,
+		semconv.DBSystemElasticsearch,
+		semconv.DBOperation(name),
+	)
+	return ctx, &operation{name: name, span: span, start: time.Now()}
+}
+
+func (op *operation) end(err error) {
+	metrics.ObserveElasticsearch(op.name, time.Since(op.start), err)
+	tracing.End(op.span, err)
+}
+
+func (r *instrumentedLogRepository) Create(ctx context.Context, log *models.Log) error {
+	ctx, op := startOperation(ctx, "create")
+	err := r.next.Create(ctx, log)
+	op.end(err)
+	return err
+}
+
+func (r *instrumentedLogRepository) GetAll(ctx context.Context, page, limit int) ([]models.Log, error) {
+	ctx, op := startOperation(ctx, "get_all")
+	logs, err := r.next.GetAll(ctx, page, limit)
+	op.end(err)
+	return logs, err
+}
+
+func (r *instrumentedLogRepository) GetByID(ctx context.Context, id string) (*models.Log, error) {
+	ctx, op := startOperation(ctx, "get")
+	log, err := r.next.GetByID(ctx, id)
+	op.end(err)
+	return log, err
+}
+
+func (r *instrumentedLogRepository) Update(ctx context.Context, log *models.Log) error {
+	ctx, op := startOperation(ctx, "update")
+	err := r.next.Update(ctx, log)
+	op.end(err)
+	return err
+}
+
+func (r *instrumentedLogRepository) Delete(ctx context.Context, id string) error {
+	ctx, op := startOperation(ctx, "delete")
+	err := r.next.Delete(ctx, id)
+	op.end(err)
+	return err
+}
+
+func (r *instrumentedLogRepository) CountPatterns(ctx context.Context, filter models.LogFilter, ids []string, size int) ([]models.PatternCount, error) {
+	ctx, op := startOperation(ctx, "count_patterns")
+	counts, err := r.next.CountPatterns(ctx, filter, ids, size)
+	op.end(err)
+	return counts, err
+}
+
+func (r *instrumentedLogRepository) VolumeBuckets(ctx context.Context, starts []time.Time, width time.Duration, maxSeries int) ([]models.VolumeBucket, error) {
+	ctx, op := startOperation(ctx, "volume_buckets")
+	buckets, err := r.next.VolumeBuckets(ctx, starts, width, maxSeries)
+	op.end(err)
+	return buckets, err
+}
+
+func (r *instrumentedLogRepository) Count(ctx context.Context, filter models.LogFilter) (int64, error) {
+	ctx, op := startOperation(ctx, "count")
+	n, err := r.next.Count(ctx, filter)
+	op.end(err)
+	return n, err
+}
+
+func (r *instrumentedLogRepository) Surrounding(ctx context.Context, id string, stream map[string]string, before, after int) ([]models.Log, []models.Log, error) {
+	ctx, op := startOperation(ctx, "surrounding")
+	preceding, following, err := r.next.Surrounding(ctx, id, stream, before, after)
+	op.end(err)
+	return preceding, following, err
+}
+
+func (r *instrumentedLogRepository) Search(ctx context.Context, filter models.LogFilter, page, limit int) ([]models.Log, error) {
+	ctx, op := startOperation(ctx, "search")
+	logs, err := r.next.Search(ctx, filter, page, limit)
+	op.end(err)
+	return logs, err
+}
+
+func (r *instrumentedLogRepository) SearchHits(ctx context.Context, filter models.LogFilter, page, limit int, opts models.SearchOptions) (*models.SearchResult, error) {
+	ctx, op := startOperation(ctx, "search")
+	result, err := r.next.SearchHits(ctx, filter, page, limit, opts)
+	op.end(err)
+	return result, err
+}
+
+// Export excludes the time spent in fn, which is usually writing to a slow
+// client, from the latency metric and does not count fn's errors as
+// Elasticsearch errors. The span covers the whole scan.
+func (r *instrumentedLogRepository) Export(ctx context.Context, filter models.LogFilter, batchSize int, fn func([]models.Log) error) error {
+	ctx, op := startOperation(ctx, "export")
+	var (
+		inFn  time.Duration
+		fnErr error
+	)
+	err := r.next.Export(ctx, filter, batchSize, func(logs []models.Log) error {
+		fnStart := time.Now()
+		fnErr = fn(logs)
+		inFn += time.Since(fnStart)
+		return fnErr
+	})
+
+	esErr := err
+	if fnErr != nil && err == fnErr {
+		esErr = nil
+	}
+	metrics.ObserveElasticsearch(op.name, time.Since(op.start)-inFn, esErr)
+	tracing.End(op.span, err)
+	return err
+}
+
+func (r *instrumentedLogRepository) BulkCreate(ctx context.Context, logs []models.Log) ([]error, error) {
+	ctx, op := startOperation(ctx, "bulk")
+	itemErrs, err := r.next.BulkCreate(ctx, logs)
+	op.end(err)
+	return itemErrs, err
+}
+
+func (r *instrumentedLogRepository) StorageSize(ctx context.Context) (int64, error) {
+	ctx, op := startOperation(ctx, "storage_size")
+	size, err := r.next.StorageSize(ctx)
+	op.end(err)
+	return size, err
+}
+
+func (r *instrumentedLogRepository) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	ctx, op := startOperation(ctx, "delete_by_query")
+	deleted, err := r.next.DeleteBefore(ctx, cutoff)
+	op.end(err)
+	return deleted, err
+}
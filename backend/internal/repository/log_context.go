@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tracing"
+)
+
+// Surrounding returns up to before logs immediately preceding the log with
+// id and up to after logs following it, both oldest first, among the logs
+// whose stream fields equal stream. An empty value matches logs without the
+// field. Logs with the same timestamp are ordered by their place in the
+// index within one point in time, so none is skipped or repeated.
+func (r *logRepository) Surrounding(ctx context.Context, id string, stream map[string]string, before, after int) ([]models.Log, []models.Log, error) {
+	pitID, err := r.openPointInTime(ctx)
+	if err != nil || pitID == "" {
+		return nil, nil, err
+	}
+	defer func() {
+		r.closePointInTime(pitID)
+	}()
+
+	// The anchor's sort values, including its shard position, are only
+	// known within the point in time.
+	anchor, err := r.searchPointInTime(ctx, &pitID, map[string]interface{}{
+		"size":  1,
+		"query": map[string]interface{}{"ids": map[string]interface{}{"values": []string{id}}},
+		"sort":  contextSort("asc"),
+	})
+	if err != nil || len(anchor) == 0 {
+		return nil, nil, err
+	}
+
+	query := streamQuery(stream)
+	page := func(order string, size int) ([]searchHit, error) {
+		if size == 0 {
+			return nil, nil
+		}
+		return r.searchPointInTime(ctx, &pitID, map[string]interface{}{
+			"size":             size,
+			"query":            query,
+			"sort":             contextSort(order),
+			"search_after":     anchor[0].Sort,
+			"track_total_hits": false,
+		})
+	}
+
+	preceding, err := page("desc", before)
+	if err != nil {
+		return nil, nil, err
+	}
+	following, err := page("asc", after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Preceding logs come newest first.
+	for i, j := 0, len(preceding)-1; i < j; i, j = i+1, j-1 {
+		preceding[i], preceding[j] = preceding[j], preceding[i]
+	}
+	return hitLogs(preceding), hitLogs(following), nil
+}
+
+// searchPointInTime runs body against the point in time, updating pitID if
+// Elasticsearch hands out a new one.
+func (r *logRepository) searchPointInTime(ctx context.Context, pitID *string, body map[string]interface{}) ([]searchHit, error) {
+	body["pit"] = map[string]interface{}{
+		"id":         *pitID,
+		"keep_alive": pointInTimeKeepAlive,
+	}
+
+	var buf strings.Builder
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return nil, fmt.Errorf("error encoding query: %w", err)
+	}
+	tracing.RecordQuery(ctx, buf.String())
+
+	res, err := r.es.Client.Search(
+		r.es.Client.Search.WithContext(ctx),
+		r.es.Client.Search.WithBody(strings.NewReader(buf.String())),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error searching logs: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("error searching logs: %s", res.String())
+	}
+
+	var result searchResponse
+	// Sort values are longs that must survive the round trip into
+	// search_after exactly.
+	dec := json.NewDecoder(res.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&result); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+	if result.PitID != "" {
+		*pitID = result.PitID
+	}
+	return result.Hits.Hits, nil
+}
+
+func contextSort(order string) []map[string]interface{} {
+	return []map[string]interface{}{
+		{"timestamp": map[string]string{"order": order}},
+		{"_shard_doc": map[string]string{"order": order}},
+	}
+}
+
+// streamQuery matches the logs of one stream. Fields are filtered in name
+// order so the same stream always yields the same query.
+func streamQuery(stream map[string]string) map[string]interface{} {
+	fields := make([]string, 0, len(stream))
+	for field := range stream {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var filters, mustNot []interface{}
+	for _, field := range fields {
+		if value := stream[field]; value != "" {
+			filters = append(filters, map[string]interface{}{
+				"term": map[string]interface{}{field + ".keyword": value},
+			})
+		} else {
+			mustNot = append(mustNot, map[string]interface{}{
+				"exists": map[string]interface{}{"field": field},
+			})
+		}
+	}
+
+	if len(filters) == 0 && len(mustNot) == 0 {
+		return map[string]interface{}{"match_all": map[string]interface{}{}}
+	}
+	boolQuery := map[string]interface{}{}
+	if len(filters) > 0 {
+		boolQuery["filter"] = filters
+	}
+	if len(mustNot) > 0 {
+		boolQuery["must_not"] = mustNot
+	}
+	return map[string]interface{}{"bool": boolQuery}
+}
+
+func hitLogs(hits []searchHit) []models.Log {
+	logs := make([]models.Log, len(hits))
+	for i, hit := range hits {
+		logs[i] = hit.Source
+		if logs[i].ID == "" {
+			logs[i].ID = hit.ID
+		}
+	}
+	return logs
+}
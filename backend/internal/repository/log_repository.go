@@ -4,10 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/sanjeevmurmu/logana/logana-backend/internal/config"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/guardrail"
 	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tenant"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tracing"
 )
 
 type LogRepository interface {
@@ -16,7 +22,30 @@ type LogRepository interface {
 	GetByID(ctx context.Context, id string) (*models.Log, error)
 	Update(ctx context.Context, log *models.Log) error
 	Delete(ctx context.Context, id string) error
-	Search(ctx context.Context, query string, page, limit int) ([]models.Log, error)
+	Search(ctx context.Context, filter models.LogFilter, page, limit int) ([]models.Log, error)
+	// SearchHits is Search with the scores, highlights and explanations
+	// opts asks for.
+	SearchHits(ctx context.Context, filter models.LogFilter, page, limit int, opts models.SearchOptions) (*models.SearchResult, error)
+	// Count returns how many logs match filter.
+	Count(ctx context.Context, filter models.LogFilter) (int64, error)
+	// CountPatterns returns how many logs matching filter were tagged with
+	// each pattern, for at most size patterns, the most frequent first. A
+	// non-empty ids limits the count to those patterns.
+	CountPatterns(ctx context.Context, filter models.LogFilter, ids []string, size int) ([]models.PatternCount, error)
+	// VolumeBuckets counts the logs per source and level in each window of
+	// width beginning at one of starts, for the maxSeries sources with the
+	// most logs. Every window of a returned series is included, empty or
+	// not.
+	VolumeBuckets(ctx context.Context, starts []time.Time, width time.Duration, maxSeries int) ([]models.VolumeBucket, error)
+	Export(ctx context.Context, filter models.LogFilter, batchSize int, fn func([]models.Log) error) error
+	// Surrounding returns the logs of a stream just before and after the log
+	// with id.
+	Surrounding(ctx context.Context, id string, stream map[string]string, before, after int) ([]models.Log, []models.Log, error)
+	BulkCreate(ctx context.Context, logs []models.Log) ([]error, error)
+	// StorageSize returns the bytes used by the caller's tenant index.
+	StorageSize(ctx context.Context) (int64, error)
+	// DeleteBefore removes the caller's tenant logs older than cutoff.
+	DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error)
 }
 
 type logRepository struct {
@@ -27,6 +56,12 @@ func NewLogRepository(es *config.ElasticsearchConfig) LogRepository {
 	return &logRepository{es: es}
 }
 
+// index returns the index of the tenant the request runs as. Every query
+// goes through it, so tenants can never read each other's logs.
+func (r *logRepository) index(ctx context.Context) string {
+	return tenant.IndexName(r.es.IndexName, tenant.FromContext(ctx))
+}
+
 func (r *logRepository) Create(ctx context.Context, log *models.Log) error {
 	body, err := json.Marshal(log)
 	if err != nil {
@@ -34,7 +69,7 @@ func (r *logRepository) Create(ctx context.Context, log *models.Log) error {
 	}
 
 	res, err := r.es.Client.Index(
-		r.es.IndexName,
+		r.index(ctx),
 		strings.NewReader(string(body)),
 		r.es.Client.Index.WithContext(ctx),
 		r.es.Client.Index.WithRefresh("true"),
@@ -51,6 +86,100 @@ func (r *logRepository) Create(ctx context.Context, log *models.Log) error {
 	return nil
 }
 
+// BulkItemError is the failure of one document of a bulk request.
+type BulkItemError struct {
+	Status int
+	Type   string
+	Reason string
+}
+
+func (e *BulkItemError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Type, e.Reason)
+}
+
+// Conflict reports that a document with the same id already exists.
+func (e *BulkItemError) Conflict() bool {
+	return e.Status == http.StatusConflict
+}
+
+// Retryable reports whether Elasticsearch turned the document away under
+// load rather than for its content.
+func (e *BulkItemError) Retryable() bool {
+	switch e.Status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return e.Type == "es_rejected_execution_exception"
+}
+
+// BulkCreate indexes logs in a single bulk request. Logs with an ID are
+// created under it, so sending them again fails with a conflict instead of
+// duplicating them. The returned slice has one entry per log, nil when that
+// document was accepted and a *BulkItemError otherwise; the error is only
+// set when the request as a whole failed.
+func (r *logRepository) BulkCreate(ctx context.Context, logs []models.Log) ([]error, error) {
+	if len(logs) == 0 {
+		return nil, nil
+	}
+
+	var buf strings.Builder
+	enc := json.NewEncoder(&buf)
+	for i := range logs {
+		action := map[string]interface{}{"index": map[string]interface{}{}}
+		if logs[i].ID != "" {
+			action = map[string]interface{}{"create": map[string]interface{}{"_id": logs[i].ID}}
+		}
+		if err := enc.Encode(action); err != nil {
+			return nil, fmt.Errorf("error encoding bulk action: %w", err)
+		}
+		if err := enc.Encode(&logs[i]); err != nil {
+			return nil, fmt.Errorf("error marshaling log: %w", err)
+		}
+	}
+
+	res, err := r.es.Client.Bulk(
+		strings.NewReader(buf.String()),
+		r.es.Client.Bulk.WithContext(ctx),
+		r.es.Client.Bulk.WithIndex(r.index(ctx)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error bulk indexing logs: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("error bulk indexing logs: %s", res.String())
+	}
+
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int `json:"status"`
+			Error  *struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	itemErrs := make([]error, len(logs))
+	for i, item := range result.Items {
+		if i >= len(itemErrs) {
+			break
+		}
+		for _, op := range item {
+			if op.Error != nil {
+				itemErrs[i] = &BulkItemError{Status: op.Status, Type: op.Error.Type, Reason: op.Error.Reason}
+			}
+		}
+	}
+
+	return itemErrs, nil
+}
+
 func (r *logRepository) GetAll(ctx context.Context, page, limit int) ([]models.Log, error) {
 	from := (page - 1) * limit
 
@@ -61,16 +190,19 @@ func (r *logRepository) GetAll(ctx context.Context, page, limit int) ([]models.L
 			{"timestamp": map[string]string{"order": "desc"}},
 		},
 	}
+	limits := applyLimits(ctx, query, false)
 
 	var buf strings.Builder
 	if err := json.NewEncoder(&buf).Encode(query); err != nil {
 		return nil, fmt.Errorf("error encoding query: %w", err)
 	}
+	tracing.RecordQuery(ctx, buf.String())
 
 	res, err := r.es.Client.Search(
 		r.es.Client.Search.WithContext(ctx),
-		r.es.Client.Search.WithIndex(r.es.IndexName),
+		r.es.Client.Search.WithIndex(r.index(ctx)),
 		r.es.Client.Search.WithBody(strings.NewReader(buf.String())),
+		r.es.Client.Search.WithIgnoreUnavailable(true),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error searching logs: %w", err)
@@ -85,6 +217,9 @@ func (r *logRepository) GetAll(ctx context.Context, page, limit int) ([]models.L
 	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("error parsing response: %w", err)
 	}
+	if timedOut, _ := result["timed_out"].(bool); timedOut {
+		return nil, guardrail.TimedOut(limits)
+	}
 
 	hits := result["hits"].(map[string]interface{})["hits"].([]interface{})
 	logs := make([]models.Log, len(hits))
@@ -106,7 +241,7 @@ func (r *logRepository) GetAll(ctx context.Context, page, limit int) ([]models.L
 
 func (r *logRepository) GetByID(ctx context.Context, id string) (*models.Log, error) {
 	res, err := r.es.Client.Get(
-		r.es.IndexName,
+		r.index(ctx),
 		id,
 		r.es.Client.Get.WithContext(ctx),
 	)
@@ -148,7 +283,7 @@ func (r *logRepository) Update(ctx context.Context, log *models.Log) error {
 	}
 
 	res, err := r.es.Client.Update(
-		r.es.IndexName,
+		r.index(ctx),
 		log.ID,
 		strings.NewReader(fmt.Sprintf(`{"doc":%s}`, string(body))),
 		r.es.Client.Update.WithContext(ctx),
@@ -168,7 +303,7 @@ func (r *logRepository) Update(ctx context.Context, log *models.Log) error {
 
 func (r *logRepository) Delete(ctx context.Context, id string) error {
 	res, err := r.es.Client.Delete(
-		r.es.IndexName,
+		r.index(ctx),
 		id,
 		r.es.Client.Delete.WithContext(ctx),
 		r.es.Client.Delete.WithRefresh("true"),
@@ -185,32 +320,56 @@ func (r *logRepository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
-func (r *logRepository) Search(ctx context.Context, query string, page, limit int) ([]models.Log, error) {
+func (r *logRepository) Search(ctx context.Context, filter models.LogFilter, page, limit int) ([]models.Log, error) {
+	result, err := r.search(ctx, filter, page, limit, models.SearchOptions{})
+	if err != nil {
+		return nil, err
+	}
+	logs := make([]models.Log, len(result.Hits))
+	for i, hit := range result.Hits {
+		logs[i] = hit.Log
+	}
+	return logs, nil
+}
+
+func (r *logRepository) SearchHits(ctx context.Context, filter models.LogFilter, page, limit int, opts models.SearchOptions) (*models.SearchResult, error) {
+	return r.search(ctx, filter, page, limit, opts)
+}
+
+func (r *logRepository) search(ctx context.Context, filter models.LogFilter, page, limit int, opts models.SearchOptions) (*models.SearchResult, error) {
 	from := (page - 1) * limit
 
 	searchQuery := map[string]interface{}{
-		"from": from,
-		"size": limit,
-		"query": map[string]interface{}{
-			"multi_match": map[string]interface{}{
-				"query":  query,
-				"fields": []string{"message", "source", "level", "metadata"},
-			},
-		},
+		"from":  from,
+		"size":  limit,
+		"query": buildFilterQuery(filter),
 		"sort": []map[string]interface{}{
 			{"timestamp": map[string]string{"order": "desc"}},
 		},
 	}
+	limits := applyLimits(ctx, searchQuery, false)
+	if opts.Highlight || opts.Explain {
+		// Hits are sorted by time, so scores are only computed on request.
+		searchQuery["track_scores"] = true
+	}
+	if opts.Highlight {
+		searchQuery["highlight"] = buildHighlight(filter.RestrictedFields)
+	}
+	if opts.Explain {
+		searchQuery["explain"] = true
+	}
 
 	var buf strings.Builder
 	if err := json.NewEncoder(&buf).Encode(searchQuery); err != nil {
 		return nil, fmt.Errorf("error encoding query: %w", err)
 	}
+	tracing.RecordQuery(ctx, buf.String())
 
 	res, err := r.es.Client.Search(
 		r.es.Client.Search.WithContext(ctx),
-		r.es.Client.Search.WithIndex(r.es.IndexName),
+		r.es.Client.Search.WithIndex(r.index(ctx)),
 		r.es.Client.Search.WithBody(strings.NewReader(buf.String())),
+		r.es.Client.Search.WithIgnoreUnavailable(true),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error searching logs: %w", err)
@@ -221,25 +380,672 @@ func (r *logRepository) Search(ctx context.Context, query string, page, limit in
 		return nil, fmt.Errorf("error searching logs: %s", res.String())
 	}
 
-	var result map[string]interface{}
+	var result struct {
+		TimedOut bool `json:"timed_out"`
+		Hits     struct {
+			Hits []struct {
+				ID          string              `json:"_id"`
+				Score       *float64            `json:"_score"`
+				Source      models.Log          `json:"_source"`
+				Highlight   map[string][]string `json:"highlight"`
+				Explanation json.RawMessage     `json:"_explanation"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
 	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("error parsing response: %w", err)
 	}
+	if result.TimedOut {
+		return nil, guardrail.TimedOut(limits)
+	}
 
-	hits := result["hits"].(map[string]interface{})["hits"].([]interface{})
-	logs := make([]models.Log, len(hits))
+	hits := make([]models.LogHit, len(result.Hits.Hits))
+	for i, hit := range result.Hits.Hits {
+		hit.Source.ID = hit.ID
+		hits[i] = models.LogHit{
+			Log:         hit.Source,
+			Score:       hit.Score,
+			Highlights:  hitHighlights(hit.Highlight, filter.RestrictedFields),
+			Explanation: hit.Explanation,
+		}
+	}
+	searchResult := &models.SearchResult{Hits: hits}
+	if opts.Explain {
+		searchResult.Query = json.RawMessage(buf.String())
+	}
+	return searchResult, nil
+}
 
-	for i, hit := range hits {
-		source := hit.(map[string]interface{})["_source"]
-		sourceBytes, err := json.Marshal(source)
+// buildHighlight marks matches in the message and the metadata values. The
+// query names the searched fields only loosely, so any field holding one of
+// its terms is highlighted; restricted fields are dropped from the result.
+func buildHighlight(restricted []string) map[string]interface{} {
+	fields := map[string]interface{}{
+		"metadata.*": map[string]interface{}{"number_of_fragments": 0},
+	}
+	if !isRestricted("message", restricted) {
+		fields["message"] = map[string]interface{}{"fragment_size": 150, "number_of_fragments": 3}
+	}
+	return map[string]interface{}{
+		"require_field_match": false,
+		"fields":              fields,
+	}
+}
+
+// hitHighlights folds keyword subfields into their field and drops the
+// fields the caller may not see.
+func hitHighlights(highlight map[string][]string, restricted []string) map[string][]string {
+	var highlights map[string][]string
+	for field, fragments := range highlight {
+		field = strings.TrimSuffix(field, ".keyword")
+		if isRestricted(field, restricted) {
+			continue
+		}
+		if highlights == nil {
+			highlights = make(map[string][]string)
+		}
+		if _, ok := highlights[field]; !ok {
+			highlights[field] = fragments
+		}
+	}
+	return highlights
+}
+
+func (r *logRepository) Count(ctx context.Context, filter models.LogFilter) (int64, error) {
+	query := map[string]interface{}{"query": buildFilterQuery(filter)}
+
+	var buf strings.Builder
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		return 0, fmt.Errorf("error encoding query: %w", err)
+	}
+	tracing.RecordQuery(ctx, buf.String())
+
+	res, err := r.es.Client.Count(
+		r.es.Client.Count.WithContext(ctx),
+		r.es.Client.Count.WithIndex(r.index(ctx)),
+		r.es.Client.Count.WithBody(strings.NewReader(buf.String())),
+		r.es.Client.Count.WithIgnoreUnavailable(true),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error counting logs: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return 0, fmt.Errorf("error counting logs: %s", res.String())
+	}
+
+	var result struct {
+		Count int64 `json:"count"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	return result.Count, nil
+}
+
+func (r *logRepository) CountPatterns(ctx context.Context, filter models.LogFilter, ids []string, size int) ([]models.PatternCount, error) {
+	terms := map[string]interface{}{
+		"field": "pattern_id.keyword",
+		"size":  size,
+	}
+	if len(ids) > 0 {
+		terms["include"] = ids
+	}
+	query := map[string]interface{}{
+		"size":  0,
+		"query": buildFilterQuery(filter),
+		"aggs": map[string]interface{}{
+			"patterns": map[string]interface{}{"terms": terms},
+		},
+	}
+	limits := applyLimits(ctx, query, true)
+
+	var buf strings.Builder
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		return nil, fmt.Errorf("error encoding query: %w", err)
+	}
+	tracing.RecordQuery(ctx, buf.String())
+
+	res, err := r.es.Client.Search(
+		r.es.Client.Search.WithContext(ctx),
+		r.es.Client.Search.WithIndex(r.index(ctx)),
+		r.es.Client.Search.WithBody(strings.NewReader(buf.String())),
+		r.es.Client.Search.WithIgnoreUnavailable(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error counting patterns: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("error counting patterns: %s", res.String())
+	}
+
+	var result struct {
+		TimedOut        bool `json:"timed_out"`
+		TerminatedEarly bool `json:"terminated_early"`
+		Aggregations    struct {
+			Patterns struct {
+				Buckets []struct {
+					Key      string `json:"key"`
+					DocCount int64  `json:"doc_count"`
+				} `json:"buckets"`
+			} `json:"patterns"`
+		} `json:"aggregations"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+	if result.TimedOut {
+		return nil, guardrail.TimedOut(limits)
+	}
+	if result.TerminatedEarly {
+		return nil, guardrail.TerminatedEarly(limits)
+	}
+
+	counts := make([]models.PatternCount, len(result.Aggregations.Patterns.Buckets))
+	for i, bucket := range result.Aggregations.Patterns.Buckets {
+		counts[i].ID = bucket.Key
+		counts[i].Count = bucket.DocCount
+	}
+	return counts, nil
+}
+
+// maxLevels bounds the levels counted per source; logs use a handful.
+const maxLevels = 20
+
+func (r *logRepository) VolumeBuckets(ctx context.Context, starts []time.Time, width time.Duration, maxSeries int) ([]models.VolumeBucket, error) {
+	// Only the windows are searched, and a filters aggregation splits
+	// them, so the windows need not line up with any calendar.
+	windows := make([]interface{}, len(starts))
+	filters := make(map[string]interface{}, len(starts))
+	for i, start := range starts {
+		window := map[string]interface{}{
+			"range": map[string]interface{}{"timestamp": map[string]interface{}{
+				"gte": start.Format(time.RFC3339Nano),
+				"lt":  start.Add(width).Format(time.RFC3339Nano),
+			}},
+		}
+		windows[i] = window
+		filters[strconv.Itoa(i)] = window
+	}
+	query := map[string]interface{}{
+		"size": 0,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{"should": windows, "minimum_should_match": 1},
+		},
+		"aggs": map[string]interface{}{
+			"sources": map[string]interface{}{
+				"terms": map[string]interface{}{"field": "source.keyword", "size": maxSeries},
+				"aggs": map[string]interface{}{
+					"levels": map[string]interface{}{
+						"terms": map[string]interface{}{"field": "level.keyword", "size": maxLevels},
+						"aggs": map[string]interface{}{
+							"windows": map[string]interface{}{
+								"filters": map[string]interface{}{"filters": filters},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	var buf strings.Builder
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		return nil, fmt.Errorf("error encoding query: %w", err)
+	}
+	tracing.RecordQuery(ctx, buf.String())
+
+	res, err := r.es.Client.Search(
+		r.es.Client.Search.WithContext(ctx),
+		r.es.Client.Search.WithIndex(r.index(ctx)),
+		r.es.Client.Search.WithBody(strings.NewReader(buf.String())),
+		r.es.Client.Search.WithIgnoreUnavailable(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error counting log volume: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("error counting log volume: %s", res.String())
+	}
+
+	type countBucket struct {
+		DocCount int64 `json:"doc_count"`
+	}
+	var result struct {
+		TimedOut     bool `json:"timed_out"`
+		Aggregations struct {
+			Sources struct {
+				Buckets []struct {
+					Key    string `json:"key"`
+					Levels struct {
+						Buckets []struct {
+							Key     string `json:"key"`
+							Windows struct {
+								Buckets map[string]countBucket `json:"buckets"`
+							} `json:"windows"`
+						} `json:"buckets"`
+					} `json:"levels"`
+				} `json:"buckets"`
+			} `json:"sources"`
+		} `json:"aggregations"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	var buckets []models.VolumeBucket
+	for _, source := range result.Aggregations.Sources.Buckets {
+		for _, level := range source.Levels.Buckets {
+			for i, start := range starts {
+				buckets = append(buckets, models.VolumeBucket{
+					Source: source.Key,
+					Level:  level.Key,
+					Start:  start,
+					Count:  level.Windows.Buckets[strconv.Itoa(i)].DocCount,
+				})
+			}
+		}
+	}
+	return buckets, nil
+}
+
+func (r *logRepository) StorageSize(ctx context.Context) (int64, error) {
+	res, err := r.es.Client.Indices.Stats(
+		r.es.Client.Indices.Stats.WithContext(ctx),
+		r.es.Client.Indices.Stats.WithIndex(r.index(ctx)),
+		r.es.Client.Indices.Stats.WithMetric("store"),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error getting index stats: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		return 0, nil
+	}
+	if res.IsError() {
+		return 0, fmt.Errorf("error getting index stats: %s", res.String())
+	}
+
+	var result struct {
+		All struct {
+			Primaries struct {
+				Store struct {
+					SizeInBytes int64 `json:"size_in_bytes"`
+				} `json:"store"`
+			} `json:"primaries"`
+		} `json:"_all"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	return result.All.Primaries.Store.SizeInBytes, nil
+}
+
+func (r *logRepository) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"range": map[string]interface{}{
+				"timestamp": map[string]interface{}{"lt": cutoff.Format(time.RFC3339Nano)},
+			},
+		},
+	}
+
+	var buf strings.Builder
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		return 0, fmt.Errorf("error encoding query: %w", err)
+	}
+	tracing.RecordQuery(ctx, buf.String())
+
+	res, err := r.es.Client.DeleteByQuery(
+		[]string{r.index(ctx)},
+		strings.NewReader(buf.String()),
+		r.es.Client.DeleteByQuery.WithContext(ctx),
+		r.es.Client.DeleteByQuery.WithIgnoreUnavailable(true),
+		r.es.Client.DeleteByQuery.WithConflicts("proceed"),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting old logs: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return 0, fmt.Errorf("error deleting old logs: %s", res.String())
+	}
+
+	var result struct {
+		Deleted int64 `json:"deleted"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	return result.Deleted, nil
+}
+
+const pointInTimeKeepAlive = "1m"
+
+type searchResponse struct {
+	PitID    string `json:"pit_id"`
+	TimedOut bool   `json:"timed_out"`
+	Hits     struct {
+		Hits []searchHit `json:"hits"`
+	} `json:"hits"`
+}
+
+type searchHit struct {
+	ID     string        `json:"_id"`
+	Source models.Log    `json:"_source"`
+	Sort   []interface{} `json:"sort"`
+}
+
+// Export walks every log matching filter in timestamp order using a point in
+// time and search_after, handing each page to fn. Only one page is held in
+// memory at a time. The scan stops as soon as ctx is cancelled or fn fails.
+func (r *logRepository) Export(ctx context.Context, filter models.LogFilter, batchSize int, fn func([]models.Log) error) error {
+	pitID, err := r.openPointInTime(ctx)
+	if err != nil || pitID == "" {
+		return err
+	}
+	defer func() {
+		r.closePointInTime(pitID)
+	}()
+
+	var searchAfter []interface{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		searchQuery := map[string]interface{}{
+			"size":  batchSize,
+			"query": buildFilterQuery(filter),
+			"pit": map[string]interface{}{
+				"id":         pitID,
+				"keep_alive": pointInTimeKeepAlive,
+			},
+			"sort": []map[string]interface{}{
+				{"timestamp": map[string]string{"order": "desc"}},
+				{"_shard_doc": map[string]string{"order": "desc"}},
+			},
+			"track_total_hits": false,
+		}
+		if searchAfter != nil {
+			searchQuery["search_after"] = searchAfter
+		}
+		// Exports read every match, so only the timeout applies to them.
+		limits := applyLimits(ctx, searchQuery, false)
+
+		var buf strings.Builder
+		if err := json.NewEncoder(&buf).Encode(searchQuery); err != nil {
+			return fmt.Errorf("error encoding query: %w", err)
+		}
+		tracing.RecordQuery(ctx, buf.String())
+
+		res, err := r.es.Client.Search(
+			r.es.Client.Search.WithContext(ctx),
+			r.es.Client.Search.WithBody(strings.NewReader(buf.String())),
+		)
 		if err != nil {
-			return nil, fmt.Errorf("error marshaling hit source: %w", err)
+			return fmt.Errorf("error searching logs: %w", err)
 		}
 
-		if err := json.Unmarshal(sourceBytes, &logs[i]); err != nil {
-			return nil, fmt.Errorf("error unmarshaling log: %w", err)
+		if res.IsError() {
+			res.Body.Close()
+			return fmt.Errorf("error searching logs: %s", res.String())
+		}
+
+		var result searchResponse
+		dec := json.NewDecoder(res.Body)
+		dec.UseNumber()
+		err = dec.Decode(&result)
+		res.Body.Close()
+		if err != nil {
+			return fmt.Errorf("error parsing response: %w", err)
+		}
+		if result.TimedOut {
+			return guardrail.TimedOut(limits)
+		}
+
+		hits := result.Hits.Hits
+		if len(hits) == 0 {
+			return nil
+		}
+		if result.PitID != "" {
+			pitID = result.PitID
 		}
+
+		logs := make([]models.Log, len(hits))
+		for i, hit := range hits {
+			logs[i] = hit.Source
+			if logs[i].ID == "" {
+				logs[i].ID = hit.ID
+			}
+		}
+
+		if err := fn(logs); err != nil {
+			return err
+		}
+
+		if len(hits) < batchSize {
+			return nil
+		}
+		searchAfter = hits[len(hits)-1].Sort
 	}
+}
 
-	return logs, nil
+func (r *logRepository) openPointInTime(ctx context.Context) (string, error) {
+	res, err := r.es.Client.OpenPointInTime(
+		[]string{r.index(ctx)},
+		pointInTimeKeepAlive,
+		r.es.Client.OpenPointInTime.WithContext(ctx),
+	)
+	if err != nil {
+		return "", fmt.Errorf("error opening point in time: %w", err)
+	}
+	defer res.Body.Close()
+
+	// A tenant that never ingested anything has no index yet.
+	if res.StatusCode == 404 {
+		return "", nil
+	}
+	if res.IsError() {
+		return "", fmt.Errorf("error opening point in time: %s", res.String())
+	}
+
+	var result struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("error parsing response: %w", err)
+	}
+
+	return result.ID, nil
+}
+
+// closePointInTime releases the point in time on a fresh context, since the
+// request context is usually already cancelled by the time the scan ends.
+func (r *logRepository) closePointInTime(pitID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	body, _ := json.Marshal(map[string]string{"id": pitID})
+	res, err := r.es.Client.ClosePointInTime(
+		r.es.Client.ClosePointInTime.WithContext(ctx),
+		r.es.Client.ClosePointInTime.WithBody(strings.NewReader(string(body))),
+	)
+	if err != nil {
+		return
+	}
+	res.Body.Close()
+}
+
+// applyLimits adds the guardrail limits of the request in ctx to a search
+// body; terminate selects whether terminate_after applies, which only
+// aggregations that report terminated_early as an error may ask for.
+func applyLimits(ctx context.Context, body map[string]interface{}, terminate bool) guardrail.Limits {
+	limits := guardrail.LimitsFromContext(ctx)
+	if limits.Timeout > 0 {
+		body["timeout"] = fmt.Sprintf("%dms", limits.Timeout.Milliseconds())
+	}
+	if terminate && limits.TerminateAfter > 0 {
+		body["terminate_after"] = limits.TerminateAfter
+	}
+	return limits
+}
+
+var searchFields = []string{"message", "source", "level", "metadata"}
+
+// searchableFields drops restricted fields from the free-text search. Any
+// restricted metadata key excludes the whole metadata object, since the
+// others cannot be listed individually.
+func searchableFields(restricted []string) []string {
+	if len(restricted) == 0 {
+		return searchFields
+	}
+
+	excluded := make(map[string]bool, len(restricted))
+	for _, field := range restricted {
+		if strings.HasPrefix(field, "metadata.") {
+			field = "metadata"
+		}
+		excluded[field] = true
+	}
+
+	var fields []string
+	for _, field := range searchFields {
+		if !excluded[field] {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// patternTermQuery matches a wildcard or regular expression term against
+// the indexed terms of the searchable fields. The metadata object cannot be
+// matched as a whole, so it is left out; nil means no field is left.
+func patternTermQuery(term guardrail.Term, fields []string) map[string]interface{} {
+	var should []interface{}
+	for _, field := range fields {
+		if field == "metadata" {
+			continue
+		}
+		should = append(should, map[string]interface{}{
+			term.Kind: map[string]interface{}{
+				field: map[string]interface{}{
+					"value":            term.Value,
+					"case_insensitive": true,
+				},
+			},
+		})
+	}
+	if len(should) == 0 {
+		return nil
+	}
+	return map[string]interface{}{
+		"bool": map[string]interface{}{"should": should, "minimum_should_match": 1},
+	}
+}
+
+func isRestricted(field string, restricted []string) bool {
+	for _, f := range restricted {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+func buildFilterQuery(filter models.LogFilter) map[string]interface{} {
+	var must, filters []interface{}
+
+	// Filtering on a restricted field would reveal its values through the
+	// matches, so it matches nothing instead. A time range would let the
+	// caller bisect hidden timestamps, and a pattern id is derived from the
+	// message.
+	for field, set := range map[string]bool{
+		"level":     filter.Level != "",
+		"source":    filter.Source != "",
+		"timestamp": !filter.From.IsZero() || !filter.To.IsZero(),
+		"message":   filter.PatternID != "",
+	} {
+		if set && isRestricted(field, filter.RestrictedFields) {
+			return map[string]interface{}{"match_none": map[string]interface{}{}}
+		}
+	}
+	if filter.Level != "" {
+		filters = append(filters, map[string]interface{}{
+			"match": map[string]interface{}{"level": filter.Level},
+		})
+	}
+	if filter.Source != "" {
+		filters = append(filters, map[string]interface{}{
+			"term": map[string]interface{}{"source.keyword": filter.Source},
+		})
+	}
+	if filter.PatternID != "" {
+		filters = append(filters, map[string]interface{}{
+			"term": map[string]interface{}{"pattern_id.keyword": filter.PatternID},
+		})
+	}
+
+	if filter.Query != "" {
+		fields := searchableFields(filter.RestrictedFields)
+		if len(fields) == 0 {
+			return map[string]interface{}{"match_none": map[string]interface{}{}}
+		}
+		text, terms := filter.Query, []guardrail.Term(nil)
+		if filter.Patterns {
+			text, terms = guardrail.SplitQuery(filter.Query)
+		}
+		if text != "" {
+			must = append(must, map[string]interface{}{
+				"multi_match": map[string]interface{}{
+					"query":  text,
+					"fields": fields,
+				},
+			})
+		}
+		for _, term := range terms {
+			clause := patternTermQuery(term, fields)
+			if clause == nil {
+				return map[string]interface{}{"match_none": map[string]interface{}{}}
+			}
+			must = append(must, clause)
+		}
+	}
+
+	if !filter.From.IsZero() || !filter.To.IsZero() {
+		timeRange := map[string]interface{}{}
+		if !filter.From.IsZero() {
+			timeRange["gte"] = filter.From.Format(time.RFC3339Nano)
+		}
+		if !filter.To.IsZero() {
+			timeRange["lte"] = filter.To.Format(time.RFC3339Nano)
+		}
+		filters = append(filters, map[string]interface{}{
+			"range": map[string]interface{}{"timestamp": timeRange},
+		})
+	}
+
+	if len(must) == 0 && len(filters) == 0 {
+		return map[string]interface{}{"match_all": map[string]interface{}{}}
+	}
+
+	boolQuery := map[string]interface{}{}
+	if len(must) > 0 {
+		boolQuery["must"] = must
+	}
+	if len(filters) > 0 {
+		boolQuery["filter"] = filters
+	}
+
+	return map[string]interface{}{"bool": boolQuery}
 }
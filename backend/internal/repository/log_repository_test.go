@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/config"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/guardrail"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+// fakeSearch serves _search requests with respond, after recording each
+// request body.
+func fakeSearch(t *testing.T, respond func(body map[string]interface{}) interface{}) (*logRepository, *[]map[string]interface{}) {
+	t.Helper()
+	var bodies []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+		if !strings.HasSuffix(r.URL.Path, "/_search") {
+			http.NotFound(w, r)
+			return
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		bodies = append(bodies, body)
+		json.NewEncoder(w).Encode(respond(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{srv.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &logRepository{es: &config.ElasticsearchConfig{Client: client, IndexName: "logs"}}, &bodies
+}
+
+func hitsResponse(logs ...models.Log) map[string]interface{} {
+	hits := make([]interface{}, len(logs))
+	for i, log := range logs {
+		id := log.ID
+		log.ID = ""
+		hits[i] = map[string]interface{}{"_id": id, "_source": log}
+	}
+	return map[string]interface{}{"hits": map[string]interface{}{"hits": hits}}
+}
+
+// toJSON round-trips v so it compares equal to a decoded request body.
+func toJSON(t *testing.T, v interface{}) interface{} {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out interface{}
+	json.Unmarshal(data, &out)
+	return out
+}
+
+func TestPlainQueriesKeepFreeTextSearch(t *testing.T) {
+	// Every query is free text unless patterns are asked for, so ? and *
+	// mean what they always did.
+	for _, q := range []string{"what?", "*", "file.* not found", "/var/log/"} {
+		want := toJSON(t, map[string]interface{}{"bool": map[string]interface{}{"must": []interface{}{
+			map[string]interface{}{"multi_match": map[string]interface{}{"query": q, "fields": searchFields}},
+		}}})
+
+		repo, bodies := fakeSearch(t, func(body map[string]interface{}) interface{} {
+			if !reflect.DeepEqual(body["query"], want) {
+				return hitsResponse()
+			}
+			return hitsResponse(models.Log{ID: "a", Message: "what? no idea"})
+		})
+		ctx := guardrail.New(guardrail.Config{Timeout: time.Second, TerminateAfter: 100}).WithLimits(context.Background())
+		logs, err := repo.Search(ctx, models.LogFilter{Query: q}, 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(logs) != 1 {
+			t.Errorf("%q: expected the free-text hit, sent %v", q, (*bodies)[0]["query"])
+		}
+		if _, ok := (*bodies)[0]["terminate_after"]; ok {
+			t.Errorf("%q: expected no terminate_after on a sorted search", q)
+		}
+	}
+}
+
+func TestSearchHitsCarryIDs(t *testing.T) {
+	repo, _ := fakeSearch(t, func(map[string]interface{}) interface{} {
+		return hitsResponse(models.Log{ID: "a1", Message: "first"}, models.Log{ID: "b2", Message: "second"})
+	})
+	result, err := repo.SearchHits(context.Background(), models.LogFilter{Query: "first"}, 1, 10, models.SearchOptions{Highlight: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Hits) != 2 || result.Hits[0].Log.ID != "a1" || result.Hits[1].Log.ID != "b2" {
+		t.Fatalf("expected the document ids on the hits, got %+v", result.Hits)
+	}
+
+	logs, err := repo.Search(context.Background(), models.LogFilter{Query: "first"}, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 2 || logs[0].ID != "a1" {
+		t.Fatalf("expected the document ids on the logs, got %+v", logs)
+	}
+}
+
+func TestPatternQueries(t *testing.T) {
+	query := buildFilterQuery(models.LogFilter{Query: "disk f?ll /ab+c/", Patterns: true})
+	must := query["bool"].(map[string]interface{})["must"].([]interface{})
+	if len(must) != 3 {
+		t.Fatalf("expected the text and two pattern clauses, got %v", must)
+	}
+	if text := must[0].(map[string]interface{})["multi_match"].(map[string]interface{})["query"]; text != "disk" {
+		t.Errorf("unexpected free text %v", text)
+	}
+}
+
+func TestCountPatternsReportsTerminatedEarly(t *testing.T) {
+	repo, bodies := fakeSearch(t, func(map[string]interface{}) interface{} {
+		return map[string]interface{}{"terminated_early": true}
+	})
+	ctx := guardrail.New(guardrail.Config{TerminateAfter: 100}).WithLimits(context.Background())
+	_, err := repo.CountPatterns(ctx, models.LogFilter{}, nil, 10)
+
+	var rejected *guardrail.Error
+	if !errors.As(err, &rejected) || rejected.Guardrail != guardrail.TerminateAfter {
+		t.Fatalf("expected the terminate_after guardrail, got %v", err)
+	}
+	if n := (*bodies)[0]["terminate_after"]; n != float64(100) {
+		t.Errorf("expected terminate_after 100, got %v", n)
+	}
+}
+
+func TestRestrictedFiltersMatchNothing(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name       string
+		filter     models.LogFilter
+		restricted string
+	}{
+		{"level", models.LogFilter{Level: "ERROR"}, "level"},
+		{"source", models.LogFilter{Source: "api"}, "source"},
+		{"from", models.LogFilter{From: now.Add(-time.Hour)}, "timestamp"},
+		{"to", models.LogFilter{To: now}, "timestamp"},
+		{"pattern", models.LogFilter{PatternID: "p1"}, "message"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := buildFilterQuery(tt.filter)["match_none"]; ok {
+				t.Fatal("expected an unrestricted filter to run")
+			}
+			tt.filter.RestrictedFields = []string{tt.restricted}
+			if _, ok := buildFilterQuery(tt.filter)["match_none"]; !ok {
+				t.Fatalf("expected match_none with %s restricted", tt.restricted)
+			}
+		})
+	}
+}
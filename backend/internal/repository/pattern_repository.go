@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/config"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+// PatternRepository stores the mined message patterns so their IDs survive
+// restarts.
+type PatternRepository interface {
+	EnsureIndex(ctx context.Context) error
+	// Save creates or replaces the pattern document with the pattern's ID.
+	Save(ctx context.Context, pattern *models.Pattern) error
+	List(ctx context.Context, tenantID string) ([]models.Pattern, error)
+}
+
+const patternMapping = `{
+  "mappings": {
+    "properties": {
+      "id":         {"type": "keyword"},
+      "tenant":     {"type": "keyword"},
+      "template":   {"type": "text"},
+      "first_seen": {"type": "date"},
+      "updated_at": {"type": "date"}
+    }
+  }
+}`
+
+// maxStoredPatterns stays within the default max_result_window.
+const maxStoredPatterns = 10000
+
+type patternRepository struct {
+	es *config.ElasticsearchConfig
+}
+
+func NewPatternRepository(es *config.ElasticsearchConfig) PatternRepository {
+	return &patternRepository{es: es}
+}
+
+// EnsureIndex creates the pattern index with its mapping if it does not exist.
+func (r *patternRepository) EnsureIndex(ctx context.Context) error {
+	res, err := r.es.Client.Indices.Exists(
+		[]string{r.es.PatternIndexName},
+		r.es.Client.Indices.Exists.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("error checking pattern index: %w", err)
+	}
+	res.Body.Close()
+	if res.StatusCode == 200 {
+		return nil
+	}
+
+	res, err = r.es.Client.Indices.Create(
+		r.es.PatternIndexName,
+		r.es.Client.Indices.Create.WithContext(ctx),
+		r.es.Client.Indices.Create.WithBody(strings.NewReader(patternMapping)),
+	)
+	if err != nil {
+		return fmt.Errorf("error creating pattern index: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() && !strings.Contains(res.String(), "resource_already_exists_exception") {
+		return fmt.Errorf("error creating pattern index: %s", res.String())
+	}
+
+	return nil
+}
+
+// Save does not refresh the index; patterns are read back only when a miner
+// starts.
+func (r *patternRepository) Save(ctx context.Context, pattern *models.Pattern) error {
+	body, err := json.Marshal(pattern)
+	if err != nil {
+		return fmt.Errorf("error marshaling pattern: %w", err)
+	}
+
+	res, err := r.es.Client.Index(
+		r.es.PatternIndexName,
+		strings.NewReader(string(body)),
+		r.es.Client.Index.WithDocumentID(pattern.ID),
+		r.es.Client.Index.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("error saving pattern: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("error saving pattern: %s", res.String())
+	}
+
+	return nil
+}
+
+func (r *patternRepository) List(ctx context.Context, tenantID string) ([]models.Pattern, error) {
+	query := map[string]interface{}{
+		"size": maxStoredPatterns,
+		"query": map[string]interface{}{
+			"term": map[string]interface{}{"tenant": tenantID},
+		},
+	}
+
+	var buf strings.Builder
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		return nil, fmt.Errorf("error encoding query: %w", err)
+	}
+
+	res, err := r.es.Client.Search(
+		r.es.Client.Search.WithContext(ctx),
+		r.es.Client.Search.WithIndex(r.es.PatternIndexName),
+		r.es.Client.Search.WithBody(strings.NewReader(buf.String())),
+		r.es.Client.Search.WithIgnoreUnavailable(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error listing patterns: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("error listing patterns: %s", res.String())
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.Pattern `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	patterns := make([]models.Pattern, len(result.Hits.Hits))
+	for i, hit := range result.Hits.Hits {
+		patterns[i] = hit.Source
+	}
+
+	return patterns, nil
+}
@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/config"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+type SavedSearchRepository interface {
+	EnsureIndex(ctx context.Context) error
+	Save(ctx context.Context, search *models.SavedSearch) error
+	GetByID(ctx context.Context, id string) (*models.SavedSearch, error)
+	// List returns the searches of tenantID that owner may open: their own
+	// and those shared with the tenant.
+	List(ctx context.Context, tenantID, owner string) ([]models.SavedSearch, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// savedSearchMapping stores the query state without indexing it; searches
+// are only ever looked up by ID, tenant and owner.
+const savedSearchMapping = `{
+  "mappings": {
+    "properties": {
+      "id":          {"type": "keyword"},
+      "tenant":      {"type": "keyword"},
+      "owner":       {"type": "keyword"},
+      "owner_name":  {"type": "keyword"},
+      "name":        {"type": "keyword"},
+      "description": {"type": "text"},
+      "visibility":  {"type": "keyword"},
+      "query":       {"type": "keyword", "index": false},
+      "level":       {"type": "keyword", "index": false},
+      "source":      {"type": "keyword", "index": false},
+      "from":        {"type": "keyword", "index": false},
+      "to":          {"type": "keyword", "index": false},
+      "columns":     {"type": "keyword", "index": false},
+      "sort":        {"type": "object", "enabled": false},
+      "created_at":  {"type": "date"},
+      "updated_at":  {"type": "date"}
+    }
+  }
+}`
+
+const maxSavedSearches = 1000
+
+type savedSearchRepository struct {
+	es *config.ElasticsearchConfig
+}
+
+func NewSavedSearchRepository(es *config.ElasticsearchConfig) SavedSearchRepository {
+	return &savedSearchRepository{es: es}
+}
+
+// EnsureIndex creates the saved search index with its mapping if it does not exist.
+func (r *savedSearchRepository) EnsureIndex(ctx context.Context) error {
+	res, err := r.es.Client.Indices.Exists(
+		[]string{r.es.SavedSearchIndexName},
+		r.es.Client.Indices.Exists.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("error checking saved search index: %w", err)
+	}
+	res.Body.Close()
+	if res.StatusCode == 200 {
+		return nil
+	}
+
+	res, err = r.es.Client.Indices.Create(
+		r.es.SavedSearchIndexName,
+		r.es.Client.Indices.Create.WithContext(ctx),
+		r.es.Client.Indices.Create.WithBody(strings.NewReader(savedSearchMapping)),
+	)
+	if err != nil {
+		return fmt.Errorf("error creating saved search index: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() && !strings.Contains(res.String(), "resource_already_exists_exception") {
+		return fmt.Errorf("error creating saved search index: %s", res.String())
+	}
+
+	return nil
+}
+
+// Save creates or replaces the saved search document with its ID.
+func (r *savedSearchRepository) Save(ctx context.Context, search *models.SavedSearch) error {
+	body, err := json.Marshal(search)
+	if err != nil {
+		return fmt.Errorf("error marshaling saved search: %w", err)
+	}
+
+	res, err := r.es.Client.Index(
+		r.es.SavedSearchIndexName,
+		strings.NewReader(string(body)),
+		r.es.Client.Index.WithDocumentID(search.ID),
+		r.es.Client.Index.WithContext(ctx),
+		r.es.Client.Index.WithRefresh("true"),
+	)
+	if err != nil {
+		return fmt.Errorf("error saving saved search: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("error saving saved search: %s", res.String())
+	}
+
+	return nil
+}
+
+func (r *savedSearchRepository) GetByID(ctx context.Context, id string) (*models.SavedSearch, error) {
+	res, err := r.es.Client.Get(
+		r.es.SavedSearchIndexName,
+		id,
+		r.es.Client.Get.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting saved search: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		if res.StatusCode == 404 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting saved search: %s", res.String())
+	}
+
+	var result struct {
+		Source models.SavedSearch `json:"_source"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	return &result.Source, nil
+}
+
+func (r *savedSearchRepository) List(ctx context.Context, tenantID, owner string) ([]models.SavedSearch, error) {
+	query := map[string]interface{}{
+		"size": maxSavedSearches,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": []map[string]interface{}{
+					{"term": map[string]interface{}{"tenant": tenantID}},
+				},
+				"should": []map[string]interface{}{
+					{"term": map[string]interface{}{"visibility": models.VisibilityTenant}},
+					{"term": map[string]interface{}{"owner": owner}},
+				},
+				"minimum_should_match": 1,
+			},
+		},
+		"sort": []map[string]interface{}{
+			{"name": map[string]string{"order": "asc"}},
+		},
+	}
+
+	var buf strings.Builder
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		return nil, fmt.Errorf("error encoding query: %w", err)
+	}
+
+	res, err := r.es.Client.Search(
+		r.es.Client.Search.WithContext(ctx),
+		r.es.Client.Search.WithIndex(r.es.SavedSearchIndexName),
+		r.es.Client.Search.WithBody(strings.NewReader(buf.String())),
+		r.es.Client.Search.WithIgnoreUnavailable(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error listing saved searches: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("error listing saved searches: %s", res.String())
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.SavedSearch `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	searches := make([]models.SavedSearch, len(result.Hits.Hits))
+	for i, hit := range result.Hits.Hits {
+		searches[i] = hit.Source
+	}
+
+	return searches, nil
+}
+
+func (r *savedSearchRepository) Delete(ctx context.Context, id string) error {
+	res, err := r.es.Client.Delete(
+		r.es.SavedSearchIndexName,
+		id,
+		r.es.Client.Delete.WithContext(ctx),
+		r.es.Client.Delete.WithRefresh("true"),
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting saved search: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() && res.StatusCode != 404 {
+		return fmt.Errorf("error deleting saved search: %s", res.String())
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/config"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+type SilenceRepository interface {
+	EnsureIndex(ctx context.Context) error
+	Save(ctx context.Context, silence *models.Silence) error
+	GetByID(ctx context.Context, id string) (*models.Silence, error)
+	// List returns the silences of tenantID, or of every tenant if it is
+	// empty, that have not ended.
+	List(ctx context.Context, tenantID string) ([]models.Silence, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// silenceMapping leaves matchers unindexed so their keys cannot grow the
+// mapping.
+const silenceMapping = `{
+  "mappings": {
+    "properties": {
+      "id":         {"type": "keyword"},
+      "tenant":     {"type": "keyword"},
+      "matchers":   {"type": "object", "enabled": false},
+      "starts_at":  {"type": "date"},
+      "ends_at":    {"type": "date"},
+      "comment":    {"type": "text"},
+      "created_by": {"type": "keyword"},
+      "created_at": {"type": "date"}
+    }
+  }
+}`
+
+const maxSilences = 1000
+
+type silenceRepository struct {
+	es *config.ElasticsearchConfig
+}
+
+func NewSilenceRepository(es *config.ElasticsearchConfig) SilenceRepository {
+	return &silenceRepository{es: es}
+}
+
+// EnsureIndex creates the silence index with its mapping if it does not exist.
+func (r *silenceRepository) EnsureIndex(ctx context.Context) error {
+	res, err := r.es.Client.Indices.Exists(
+		[]string{r.es.SilenceIndexName},
+		r.es.Client.Indices.Exists.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("error checking silence index: %w", err)
+	}
+	res.Body.Close()
+	if res.StatusCode == 200 {
+		return nil
+	}
+
+	res, err = r.es.Client.Indices.Create(
+		r.es.SilenceIndexName,
+		r.es.Client.Indices.Create.WithContext(ctx),
+		r.es.Client.Indices.Create.WithBody(strings.NewReader(silenceMapping)),
+	)
+	if err != nil {
+		return fmt.Errorf("error creating silence index: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() && !strings.Contains(res.String(), "resource_already_exists_exception") {
+		return fmt.Errorf("error creating silence index: %s", res.String())
+	}
+
+	return nil
+}
+
+// Save creates or replaces the silence document with the silence's ID.
+func (r *silenceRepository) Save(ctx context.Context, silence *models.Silence) error {
+	body, err := json.Marshal(silence)
+	if err != nil {
+		return fmt.Errorf("error marshaling silence: %w", err)
+	}
+
+	res, err := r.es.Client.Index(
+		r.es.SilenceIndexName,
+		strings.NewReader(string(body)),
+		r.es.Client.Index.WithDocumentID(silence.ID),
+		r.es.Client.Index.WithContext(ctx),
+		r.es.Client.Index.WithRefresh("true"),
+	)
+	if err != nil {
+		return fmt.Errorf("error saving silence: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("error saving silence: %s", res.String())
+	}
+
+	return nil
+}
+
+func (r *silenceRepository) GetByID(ctx context.Context, id string) (*models.Silence, error) {
+	res, err := r.es.Client.Get(
+		r.es.SilenceIndexName,
+		id,
+		r.es.Client.Get.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting silence: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		if res.StatusCode == 404 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting silence: %s", res.String())
+	}
+
+	var result struct {
+		Source models.Silence `json:"_source"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	return &result.Source, nil
+}
+
+func (r *silenceRepository) List(ctx context.Context, tenantID string) ([]models.Silence, error) {
+	query := map[string]interface{}{
+		"size": maxSilences,
+		"sort": []map[string]interface{}{
+			{"ends_at": map[string]string{"order": "asc"}},
+		},
+	}
+	filter := []map[string]interface{}{
+		{"range": map[string]interface{}{"ends_at": map[string]string{"gt": "now"}}},
+	}
+	if tenantID != "" {
+		filter = append(filter, map[string]interface{}{
+			"term": map[string]interface{}{"tenant": tenantID},
+		})
+	}
+	query["query"] = map[string]interface{}{
+		"bool": map[string]interface{}{"filter": filter},
+	}
+
+	var buf strings.Builder
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		return nil, fmt.Errorf("error encoding query: %w", err)
+	}
+
+	res, err := r.es.Client.Search(
+		r.es.Client.Search.WithContext(ctx),
+		r.es.Client.Search.WithIndex(r.es.SilenceIndexName),
+		r.es.Client.Search.WithBody(strings.NewReader(buf.String())),
+		r.es.Client.Search.WithIgnoreUnavailable(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error listing silences: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("error listing silences: %s", res.String())
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source models.Silence `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	silences := make([]models.Silence, len(result.Hits.Hits))
+	for i, hit := range result.Hits.Hits {
+		silences[i] = hit.Source
+	}
+
+	return silences, nil
+}
+
+func (r *silenceRepository) Delete(ctx context.Context, id string) error {
+	res, err := r.es.Client.Delete(
+		r.es.SilenceIndexName,
+		id,
+		r.es.Client.Delete.WithContext(ctx),
+		r.es.Client.Delete.WithRefresh("true"),
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting silence: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() && res.StatusCode != 404 {
+		return fmt.Errorf("error deleting silence: %s", res.String())
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+// Package savedsearch validates saved searches and resolves them into the
+// query state a permalink opens.
+package savedsearch
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/export"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+var ErrInvalid = errors.New("invalid saved search")
+
+// IDLength keeps permalinks short; 10 base62 characters leave collisions
+// out of reach for any realistic number of searches.
+const IDLength = 10
+
+const idAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+var sortFields = map[string]bool{"timestamp": true, "level": true, "source": true}
+
+var relativeTime = regexp.MustCompile(`^now(?:([+-])(\d+)([smhdw]))?$`)
+
+var units = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// NewID returns a random permalink ID.
+func NewID() (string, error) {
+	max := big.NewInt(int64(len(idAlphabet)))
+	b := make([]byte, IDLength)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("error generating saved search id: %w", err)
+		}
+		b[i] = idAlphabet[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// Validate checks s and fills in its default visibility and sort orders.
+func Validate(s *models.SavedSearch) error {
+	if strings.TrimSpace(s.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalid)
+	}
+
+	switch s.Visibility {
+	case "":
+		s.Visibility = models.VisibilityPrivate
+	case models.VisibilityPrivate, models.VisibilityTenant:
+	default:
+		return fmt.Errorf("%w: unknown visibility %q", ErrInvalid, s.Visibility)
+	}
+
+	now := time.Now()
+	from, err := ParseTime(s.From, now)
+	if err != nil {
+		return fmt.Errorf("%w: from: %v", ErrInvalid, err)
+	}
+	to, err := ParseTime(s.To, now)
+	if err != nil {
+		return fmt.Errorf("%w: to: %v", ErrInvalid, err)
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalid)
+	}
+
+	if len(s.Columns) > 0 {
+		if _, err := export.ParseColumns(strings.Join(s.Columns, ",")); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+	}
+
+	for i, field := range s.Sort {
+		if !sortFields[field.Field] {
+			return fmt.Errorf("%w: cannot sort by %q", ErrInvalid, field.Field)
+		}
+		switch field.Order {
+		case "":
+			s.Sort[i].Order = "desc"
+		case "asc", "desc":
+		default:
+			return fmt.Errorf("%w: unknown sort order %q", ErrInvalid, field.Order)
+		}
+	}
+	return nil
+}
+
+// ParseTime parses an RFC 3339 time or one relative to now, written "now",
+// "now-15m" or "now+1d" with units s, m, h, d and w. An empty spec is the
+// zero time.
+func ParseTime(spec string, now time.Time) (time.Time, error) {
+	if spec == "" {
+		return time.Time{}, nil
+	}
+
+	m := relativeTime.FindStringSubmatch(spec)
+	if m == nil {
+		t, err := time.Parse(time.RFC3339, spec)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%q is neither RFC 3339 nor relative like now-15m", spec)
+		}
+		return t, nil
+	}
+	if m[1] == "" {
+		return now, nil
+	}
+
+	n, err := strconv.Atoi(m[2])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q: %v", spec, err)
+	}
+	offset := time.Duration(n) * units[m[3]]
+	if m[1] == "-" {
+		offset = -offset
+	}
+	return now.Add(offset), nil
+}
+
+// Resolve evaluates s's time range at now.
+func Resolve(s models.SavedSearch, now time.Time) (models.QueryState, error) {
+	state := models.QueryState{
+		Query:   s.Query,
+		Level:   s.Level,
+		Source:  s.Source,
+		Columns: s.Columns,
+		Sort:    s.Sort,
+	}
+
+	params := url.Values{}
+	for name, value := range map[string]string{"q": s.Query, "level": s.Level, "source": s.Source} {
+		if value != "" {
+			params.Set(name, value)
+		}
+	}
+	for _, bound := range []struct {
+		name string
+		spec string
+		dst  **time.Time
+	}{
+		{"from", s.From, &state.From},
+		{"to", s.To, &state.To},
+	} {
+		t, err := ParseTime(bound.spec, now)
+		if err != nil {
+			return models.QueryState{}, fmt.Errorf("%w: %s: %v", ErrInvalid, bound.name, err)
+		}
+		if t.IsZero() {
+			continue
+		}
+		t = t.UTC()
+		*bound.dst = &t
+		params.Set(bound.name, t.Format(time.RFC3339))
+	}
+	if len(s.Columns) > 0 {
+		params.Set("columns", strings.Join(s.Columns, ","))
+	}
+
+	state.Params = params.Encode()
+	return state, nil
+}
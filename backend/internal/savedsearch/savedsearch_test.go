@@ -0,0 +1,91 @@
+package savedsearch
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+)
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	for spec, want := range map[string]time.Time{
+		"":                     {},
+		"now":                  now,
+		"now-15m":              now.Add(-15 * time.Minute),
+		"now+1h":               now.Add(time.Hour),
+		"now-7d":               now.AddDate(0, 0, -7),
+		"now-2w":               now.AddDate(0, 0, -14),
+		"2024-04-30T08:00:00Z": time.Date(2024, 4, 30, 8, 0, 0, 0, time.UTC),
+	} {
+		got, err := ParseTime(spec, now)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("ParseTime(%q) = %v, %v; want %v", spec, got, err, want)
+		}
+	}
+
+	for _, spec := range []string{"now-", "now-15", "yesterday", "now-1y"} {
+		if _, err := ParseTime(spec, now); err == nil {
+			t.Errorf("expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestResolveReevaluatesRelativeTimes(t *testing.T) {
+	search := models.SavedSearch{
+		Name:    "checkout timeouts",
+		Query:   "timeout",
+		Source:  "checkout",
+		From:    "now-15m",
+		To:      "now",
+		Columns: []string{"timestamp", "message"},
+	}
+
+	first := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	state, err := Resolve(search, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.From.Equal(first.Add(-15*time.Minute)) || !state.To.Equal(first) {
+		t.Fatalf("unexpected range %v - %v", state.From, state.To)
+	}
+	params, _ := url.ParseQuery(state.Params)
+	if params.Get("q") != "timeout" || params.Get("source") != "checkout" || params.Get("from") != "2024-05-01T11:45:00Z" || params.Get("columns") != "timestamp,message" {
+		t.Fatalf("unexpected params %s", state.Params)
+	}
+
+	later, _ := Resolve(search, first.Add(time.Hour))
+	if !later.To.Equal(first.Add(time.Hour)) {
+		t.Fatalf("expected the range to move with the time of opening, got %v", later.To)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	search := models.SavedSearch{Name: "errors", Sort: []models.SortField{{Field: "timestamp"}}}
+	if err := Validate(&search); err != nil {
+		t.Fatal(err)
+	}
+	if search.Visibility != models.VisibilityPrivate || search.Sort[0].Order != "desc" {
+		t.Fatalf("expected defaults to be filled in, got %+v", search)
+	}
+
+	for _, invalid := range []models.SavedSearch{
+		{},
+		{Name: "x", Visibility: "public"},
+		{Name: "x", From: "now", To: "now-1h"},
+		{Name: "x", Columns: []string{"password"}},
+		{Name: "x", Sort: []models.SortField{{Field: "message"}}},
+	} {
+		if err := Validate(&invalid); !errors.Is(err, ErrInvalid) {
+			t.Errorf("expected %+v to be invalid, got %v", invalid, err)
+		}
+	}
+
+	id, err := NewID()
+	if err != nil || len(id) != IDLength {
+		t.Fatalf("unexpected id %q: %v", id, err)
+	}
+}
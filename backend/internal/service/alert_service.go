@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/alerting"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/notify"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/repository"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tenant"
+)
+
+var (
+	ErrAlertRuleNotFound = errors.New("alert rule not found")
+	ErrSilenceNotFound   = errors.New("silence not found")
+)
+
+// AlertService manages the caller's tenant's alerting rules. Rules of other
+// tenants are reported as not found.
+type AlertService interface {
+	CreateRule(ctx context.Context, req models.AlertRuleRequest) (*models.AlertRule, error)
+	ListRules(ctx context.Context) ([]models.AlertRule, error)
+	GetRule(ctx context.Context, id string) (*models.AlertRule, error)
+	UpdateRule(ctx context.Context, id string, req models.AlertRuleRequest) (*models.AlertRule, error)
+	DeleteRule(ctx context.Context, id string) error
+	// ListAlerts returns the current state of the tenant's evaluated rules.
+	ListAlerts(ctx context.Context) []models.Alert
+
+	// Silences mute the tenant's notifications; they are listed until they
+	// end.
+	CreateSilence(ctx context.Context, req models.SilenceRequest) (*models.Silence, error)
+	ListSilences(ctx context.Context) ([]models.Silence, error)
+	DeleteSilence(ctx context.Context, id string) error
+
+	// Notification channels are shared by all tenants.
+	ListChannels() []notify.ChannelConfig
+	TestChannel(ctx context.Context, name string) error
+}
+
+type alertService struct {
+	repo       repository.AlertRuleRepository
+	silences   repository.SilenceRepository
+	scheduler  *alerting.Scheduler
+	dispatcher *notify.Dispatcher
+	audit      AuditService
+}
+
+// NewAlertService returns the rule service. audit may be nil.
+func NewAlertService(repo repository.AlertRuleRepository, silences repository.SilenceRepository, scheduler *alerting.Scheduler, dispatcher *notify.Dispatcher, audit AuditService) AlertService {
+	return &alertService{repo: repo, silences: silences, scheduler: scheduler, dispatcher: dispatcher, audit: audit}
+}
+
+func (s *alertService) CreateRule(ctx context.Context, req models.AlertRuleRequest) (*models.AlertRule, error) {
+	id, err := randomHex(8)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	rule := ruleFromRequest(req)
+	rule.ID = id
+	rule.Tenant = tenant.FromContext(ctx)
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	if err := alerting.Validate(rule); err != nil {
+		return nil, err
+	}
+
+	err = s.repo.Save(ctx, rule)
+	s.record(ctx, models.AuditEvent{
+		Action:     AuditAlertRuleCreate,
+		Resource:   "alert_rule",
+		ResourceID: id,
+		After:      rule,
+	}, err)
+	if err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *alertService) ListRules(ctx context.Context) ([]models.AlertRule, error) {
+	return s.repo.List(ctx, tenant.FromContext(ctx))
+}
+
+func (s *alertService) GetRule(ctx context.Context, id string) (*models.AlertRule, error) {
+	rule, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil || rule.Tenant != tenant.FromContext(ctx) {
+		return nil, ErrAlertRuleNotFound
+	}
+	return rule, nil
+}
+
+func (s *alertService) UpdateRule(ctx context.Context, id string, req models.AlertRuleRequest) (*models.AlertRule, error) {
+	before, err := s.GetRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := ruleFromRequest(req)
+	rule.ID = before.ID
+	rule.Tenant = before.Tenant
+	rule.CreatedAt = before.CreatedAt
+	rule.UpdatedAt = time.Now().UTC()
+	if err := alerting.Validate(rule); err != nil {
+		return nil, err
+	}
+
+	err = s.repo.Save(ctx, rule)
+	s.record(ctx, models.AuditEvent{
+		Action:     AuditAlertRuleUpdate,
+		Resource:   "alert_rule",
+		ResourceID: id,
+		Before:     before,
+		After:      rule,
+	}, err)
+	if err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *alertService) DeleteRule(ctx context.Context, id string) error {
+	before, err := s.GetRule(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.Delete(ctx, id)
+	s.record(ctx, models.AuditEvent{
+		Action:     AuditAlertRuleDelete,
+		Resource:   "alert_rule",
+		ResourceID: id,
+		Before:     before,
+	}, err)
+	return err
+}
+
+func (s *alertService) ListAlerts(ctx context.Context) []models.Alert {
+	return s.scheduler.Alerts(tenant.FromContext(ctx))
+}
+
+func (s *alertService) CreateSilence(ctx context.Context, req models.SilenceRequest) (*models.Silence, error) {
+	id, err := randomHex(8)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	silence := &models.Silence{
+		ID:        id,
+		Tenant:    tenant.FromContext(ctx),
+		Matchers:  req.Matchers,
+		StartsAt:  now,
+		EndsAt:    req.EndsAt.UTC(),
+		Comment:   req.Comment,
+		CreatedAt: now,
+	}
+	if req.StartsAt != nil {
+		silence.StartsAt = req.StartsAt.UTC()
+	}
+	if p := auth.PrincipalFromContext(ctx); p != nil {
+		silence.CreatedBy = p.Name
+	}
+	if err := notify.ValidateSilence(silence, now); err != nil {
+		return nil, err
+	}
+
+	err = s.silences.Save(ctx, silence)
+	s.record(ctx, models.AuditEvent{
+		Action:     AuditSilenceCreate,
+		Resource:   "silence",
+		ResourceID: id,
+		After:      silence,
+	}, err)
+	if err != nil {
+		return nil, err
+	}
+	return silence, nil
+}
+
+func (s *alertService) ListSilences(ctx context.Context) ([]models.Silence, error) {
+	return s.silences.List(ctx, tenant.FromContext(ctx))
+}
+
+func (s *alertService) DeleteSilence(ctx context.Context, id string) error {
+	before, err := s.silences.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if before == nil || before.Tenant != tenant.FromContext(ctx) {
+		return ErrSilenceNotFound
+	}
+
+	err = s.silences.Delete(ctx, id)
+	s.record(ctx, models.AuditEvent{
+		Action:     AuditSilenceDelete,
+		Resource:   "silence",
+		ResourceID: id,
+		Before:     before,
+	}, err)
+	return err
+}
+
+func (s *alertService) ListChannels() []notify.ChannelConfig {
+	return s.dispatcher.Channels()
+}
+
+func (s *alertService) TestChannel(ctx context.Context, name string) error {
+	err := s.dispatcher.Test(ctx, name, tenant.FromContext(ctx))
+	s.record(ctx, models.AuditEvent{
+		Action:     AuditNotificationTest,
+		Resource:   "notification_channel",
+		ResourceID: name,
+	}, err)
+	return err
+}
+
+func (s *alertService) record(ctx context.Context, event models.AuditEvent, err error) {
+	if s.audit != nil {
+		s.audit.Record(ctx, event, err)
+	}
+}
+
+func ruleFromRequest(req models.AlertRuleRequest) *models.AlertRule {
+	return &models.AlertRule{
+		Name:        req.Name,
+		Description: req.Description,
+		Query:       req.Query,
+		Level:       req.Level,
+		Source:      req.Source,
+		Condition:   req.Condition,
+		Window:      req.Window,
+		For:         req.For,
+		Interval:    req.Interval,
+		Labels:      req.Labels,
+		Disabled:    req.Disabled,
+	}
+}
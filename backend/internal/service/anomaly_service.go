@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/access"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/repository"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tenant"
+)
+
+const maxAnomalies = 1000
+
+// ErrAnomalyRestricted is returned by List to principals that may not see
+// the source, level or timestamp of logs, which anomalies are made of.
+var ErrAnomalyRestricted = errors.New("anomalies are derived from log sources, levels and timestamps, which you may not see")
+
+// AnomalyService lists the log volume anomalies detected for the caller's
+// tenant.
+type AnomalyService interface {
+	List(ctx context.Context, filter models.AnomalyFilter, limit int) ([]models.Anomaly, error)
+}
+
+type anomalyService struct {
+	repo   repository.AnomalyRepository
+	policy func() *access.Policy
+}
+
+// NewAnomalyService returns the anomaly service. policy returns the field
+// access policy in effect, which may be nil.
+func NewAnomalyService(repo repository.AnomalyRepository, policy func() *access.Policy) AnomalyService {
+	return &anomalyService{repo: repo, policy: policy}
+}
+
+func (s *anomalyService) List(ctx context.Context, filter models.AnomalyFilter, limit int) ([]models.Anomaly, error) {
+	restricted := s.policy().ViewFor(auth.PrincipalFromContext(ctx)).RestrictedFields()
+	if readsRestricted([]string{"source", "level", "timestamp"}, restricted) {
+		return nil, ErrAnomalyRestricted
+	}
+	if limit < 1 {
+		limit = 100
+	}
+	if limit > maxAnomalies {
+		limit = maxAnomalies
+	}
+	return s.repo.List(ctx, tenant.FromContext(ctx), filter, limit)
+}
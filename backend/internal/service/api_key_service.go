@@ -0,0 +1,337 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/repository"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tenant"
+)
+
+// API keys look like "lgn_<id>_<secret>". The id locates the stored key and
+// only a SHA-256 hash of the secret is persisted; the secrets are random
+// 256-bit values, so a slow password hash would add nothing.
+const (
+	apiKeyPrefix = "lgn_"
+	// apiKeyIDLen is the length of the hex ids made by randomHex(8).
+	apiKeyIDLen = 16
+
+	// apiKeyCacheTTL bounds how long a revoked key may keep working on
+	// other backend instances.
+	apiKeyCacheTTL = 30 * time.Second
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrInvalidScope   = errors.New("invalid scope")
+	ErrInvalidTenant  = errors.New("invalid tenant")
+)
+
+type APIKeyService interface {
+	CreateKey(ctx context.Context, req models.CreateAPIKeyRequest) (*models.APIKey, string, error)
+	ListKeys(ctx context.Context) ([]models.APIKey, error)
+	// RotateKey issues a new secret for the key. The old secret keeps
+	// working for grace, which may be zero.
+	RotateKey(ctx context.Context, id string, grace time.Duration) (*models.APIKey, string, error)
+	RevokeKey(ctx context.Context, id string) error
+	Authenticate(ctx context.Context, credential string) (*auth.Principal, error)
+}
+
+type cachedAPIKey struct {
+	key       *models.APIKey
+	fetchedAt time.Time
+}
+
+type apiKeyService struct {
+	repo  repository.APIKeyRepository
+	audit AuditService
+
+	mu    sync.Mutex
+	cache map[string]cachedAPIKey
+}
+
+// NewAPIKeyService returns the key service. audit may be nil.
+func NewAPIKeyService(repo repository.APIKeyRepository, audit AuditService) APIKeyService {
+	return &apiKeyService{repo: repo, audit: audit, cache: make(map[string]cachedAPIKey)}
+}
+
+func (s *apiKeyService) CreateKey(ctx context.Context, req models.CreateAPIKeyRequest) (*models.APIKey, string, error) {
+	if len(req.Scopes) == 0 {
+		return nil, "", fmt.Errorf("%w: at least one scope is required", ErrInvalidScope)
+	}
+	for _, scope := range req.Scopes {
+		if !auth.ValidScope(scope) {
+			return nil, "", fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+	}
+
+	tenantID := req.Tenant
+	if tenantID == "" {
+		tenantID = tenant.FromContext(ctx)
+	}
+	if !tenant.Valid(tenantID) || !canManageTenant(ctx, tenantID) {
+		return nil, "", fmt.Errorf("%w: %s", ErrInvalidTenant, tenantID)
+	}
+
+	id, err := randomHex(8)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomSecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now().UTC()
+	key := &models.APIKey{
+		ID:        id,
+		Name:      req.Name,
+		Tenant:    tenantID,
+		Scopes:    req.Scopes,
+		KeyHash:   hashSecret(secret),
+		ExpiresAt: req.ExpiresAt,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err = s.repo.Save(ctx, key)
+	s.record(ctx, models.AuditEvent{
+		Action:     AuditAPIKeyCreate,
+		Resource:   "api_key",
+		ResourceID: id,
+		After:      sanitizeAPIKey(key),
+	}, err)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return sanitizeAPIKey(key), apiKeyPrefix + id + "_" + secret, nil
+}
+
+func (s *apiKeyService) ListKeys(ctx context.Context) ([]models.APIKey, error) {
+	keys, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	visible := keys[:0]
+	for i := range keys {
+		if canManageTenant(ctx, keyTenant(&keys[i])) {
+			visible = append(visible, *sanitizeAPIKey(&keys[i]))
+		}
+	}
+	return visible, nil
+}
+
+func (s *apiKeyService) RotateKey(ctx context.Context, id string, grace time.Duration) (*models.APIKey, string, error) {
+	key, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if key == nil || key.RevokedAt != nil || !canManageTenant(ctx, keyTenant(key)) {
+		return nil, "", ErrAPIKeyNotFound
+	}
+
+	secret, err := randomSecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	before := sanitizeAPIKey(key)
+	now := time.Now().UTC()
+	key.PreviousKeyHash = ""
+	key.PreviousKeyExpiresAt = nil
+	if grace > 0 {
+		expires := now.Add(grace)
+		key.PreviousKeyHash = key.KeyHash
+		key.PreviousKeyExpiresAt = &expires
+	}
+	key.KeyHash = hashSecret(secret)
+	key.UpdatedAt = now
+
+	err = s.repo.Save(ctx, key)
+	s.record(ctx, models.AuditEvent{
+		Action:     AuditAPIKeyRotate,
+		Resource:   "api_key",
+		ResourceID: id,
+		Before:     before,
+		After:      sanitizeAPIKey(key),
+		Details:    map[string]string{"grace": grace.String()},
+	}, err)
+	if err != nil {
+		return nil, "", err
+	}
+	s.forget(id)
+
+	return sanitizeAPIKey(key), apiKeyPrefix + id + "_" + secret, nil
+}
+
+func (s *apiKeyService) RevokeKey(ctx context.Context, id string) error {
+	key, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if key == nil || !canManageTenant(ctx, keyTenant(key)) {
+		return ErrAPIKeyNotFound
+	}
+	if key.RevokedAt != nil {
+		return nil
+	}
+
+	before := sanitizeAPIKey(key)
+	now := time.Now().UTC()
+	key.RevokedAt = &now
+	key.UpdatedAt = now
+	err = s.repo.Save(ctx, key)
+	s.record(ctx, models.AuditEvent{
+		Action:     AuditAPIKeyRevoke,
+		Resource:   "api_key",
+		ResourceID: id,
+		Before:     before,
+		After:      sanitizeAPIKey(key),
+	}, err)
+	if err != nil {
+		return err
+	}
+	s.forget(id)
+	return nil
+}
+
+func (s *apiKeyService) record(ctx context.Context, event models.AuditEvent, err error) {
+	if s.audit != nil {
+		s.audit.Record(ctx, event, err)
+	}
+}
+
+func (s *apiKeyService) Authenticate(ctx context.Context, credential string) (*auth.Principal, error) {
+	if !strings.HasPrefix(credential, apiKeyPrefix) {
+		return nil, auth.ErrNotApplicable
+	}
+	id, secret, ok := strings.Cut(strings.TrimPrefix(credential, apiKeyPrefix), "_")
+	if !ok || !validKeyID(id) || secret == "" {
+		return nil, auth.ErrInvalidCredential
+	}
+
+	key, err := s.lookup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil || key.RevokedAt != nil {
+		return nil, auth.ErrInvalidCredential
+	}
+
+	now := time.Now()
+	if key.ExpiresAt != nil && now.After(*key.ExpiresAt) {
+		return nil, auth.ErrInvalidCredential
+	}
+
+	hash := hashSecret(secret)
+	valid := subtle.ConstantTimeCompare([]byte(hash), []byte(key.KeyHash)) == 1
+	if !valid && key.PreviousKeyHash != "" && key.PreviousKeyExpiresAt != nil && now.Before(*key.PreviousKeyExpiresAt) {
+		valid = subtle.ConstantTimeCompare([]byte(hash), []byte(key.PreviousKeyHash)) == 1
+	}
+	if !valid {
+		return nil, auth.ErrInvalidCredential
+	}
+
+	return &auth.Principal{ID: key.ID, Name: key.Name, Kind: "api_key", Tenant: keyTenant(key), Scopes: key.Scopes}, nil
+}
+
+func (s *apiKeyService) lookup(ctx context.Context, id string) (*models.APIKey, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[id]
+	s.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < apiKeyCacheTTL {
+		return cached.key, nil
+	}
+
+	key, err := s.repo.GetByID(ctx, id)
+	if err != nil || key == nil {
+		// Misses are not cached: the ids are attacker-chosen, so caching
+		// them would let anyone grow the map without bound.
+		return nil, err
+	}
+
+	s.mu.Lock()
+	for cachedID, entry := range s.cache {
+		if time.Since(entry.fetchedAt) >= apiKeyCacheTTL {
+			delete(s.cache, cachedID)
+		}
+	}
+	s.cache[id] = cachedAPIKey{key: key, fetchedAt: time.Now()}
+	s.mu.Unlock()
+	return key, nil
+}
+
+func (s *apiKeyService) forget(id string) {
+	s.mu.Lock()
+	delete(s.cache, id)
+	s.mu.Unlock()
+}
+
+// validKeyID reports whether id has the form CreateKey issues, so
+// malformed credentials are rejected without a store round trip.
+func validKeyID(id string) bool {
+	if len(id) != apiKeyIDLen {
+		return false
+	}
+	for _, c := range id {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// keyTenant treats keys created before tenants existed as default tenant keys.
+func keyTenant(key *models.APIKey) string {
+	if key.Tenant == "" {
+		return tenant.Default
+	}
+	return key.Tenant
+}
+
+// canManageTenant reports whether the caller may manage keys of tenantID.
+// Admins of the default tenant operate the deployment and manage every
+// tenant; other admins only see their own tenant's keys.
+func canManageTenant(ctx context.Context, tenantID string) bool {
+	caller := tenant.FromContext(ctx)
+	return caller == tenant.Default || caller == tenantID
+}
+
+func sanitizeAPIKey(key *models.APIKey) *models.APIKey {
+	clean := *key
+	clean.KeyHash = ""
+	clean.PreviousKeyHash = ""
+	return &clean
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating key id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func randomSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating key secret: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
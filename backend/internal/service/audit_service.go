@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/repository"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tenant"
+)
+
+// Audited actions.
+const (
+	AuditLogUpdate         = "log.update"
+	AuditLogDelete         = "log.delete"
+	AuditLogDeleteByQuery  = "log.delete_by_query"
+	AuditAPIKeyCreate      = "api_key.create"
+	AuditAPIKeyRotate      = "api_key.rotate"
+	AuditAPIKeyRevoke      = "api_key.revoke"
+	AuditConfigChange      = "config.change"
+	AuditAlertRuleCreate   = "alert_rule.create"
+	AuditAlertRuleUpdate   = "alert_rule.update"
+	AuditAlertRuleDelete   = "alert_rule.delete"
+	AuditSilenceCreate     = "silence.create"
+	AuditSilenceDelete     = "silence.delete"
+	AuditNotificationTest  = "notification.test"
+	AuditSavedSearchCreate = "saved_search.create"
+	AuditSavedSearchUpdate = "saved_search.update"
+	AuditSavedSearchDelete = "saved_search.delete"
+)
+
+const auditWriteTimeout = 5 * time.Second
+
+type AuditService interface {
+	// Record appends event, filling in the actor, tenant and client from
+	// ctx. A non-nil err marks the operation as failed.
+	Record(ctx context.Context, event models.AuditEvent, err error)
+	Search(ctx context.Context, filter models.AuditFilter, page, limit int) ([]models.AuditEvent, error)
+}
+
+// RequestInfo describes where a request came from.
+type RequestInfo struct {
+	RemoteAddr string
+	UserAgent  string
+}
+
+type requestInfoKey struct{}
+
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+type auditService struct {
+	repo repository.AuditRepository
+}
+
+func NewAuditService(repo repository.AuditRepository) AuditService {
+	return &auditService{repo: repo}
+}
+
+func (s *auditService) Record(ctx context.Context, event models.AuditEvent, err error) {
+	event.Timestamp = time.Now().UTC()
+	event.Tenant = tenant.FromContext(ctx)
+	event.Outcome = "success"
+	if err != nil {
+		event.Outcome = "failure"
+		event.Error = err.Error()
+	}
+
+	// Operations without a principal are run by logana itself, such as
+	// retention.
+	event.ActorID, event.ActorName, event.ActorKind = "logana", "logana", "system"
+	if p := auth.PrincipalFromContext(ctx); p != nil {
+		event.ActorID, event.ActorName, event.ActorKind = p.ID, p.Name, p.Kind
+	}
+	if info, ok := ctx.Value(requestInfoKey{}).(RequestInfo); ok {
+		event.RemoteAddr = info.RemoteAddr
+		event.UserAgent = info.UserAgent
+	}
+
+	// The event is written even if the request was cancelled after the
+	// operation went through.
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), auditWriteTimeout)
+	defer cancel()
+
+	if err := s.repo.Append(writeCtx, &event); err != nil {
+		log.Printf("Failed to record audit event %s on %s %s: %v", event.Action, event.Resource, event.ResourceID, err)
+	}
+}
+
+// Search only returns the caller's tenant's events, except for callers in the
+// default tenant, who operate the whole deployment.
+func (s *auditService) Search(ctx context.Context, filter models.AuditFilter, page, limit int) ([]models.AuditEvent, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 50
+	}
+	if caller := tenant.FromContext(ctx); caller != tenant.Default {
+		filter.Tenant = caller
+	}
+	return s.repo.Search(ctx, filter, page, limit)
+}
@@ -2,9 +2,58 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/access"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/guardrail"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/logmetrics"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/metrics"
 	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/multiline"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/patterns"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/pipeline"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/redact"
 	"github.com/sanjeevmurmu/logana/logana-backend/internal/repository"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tenant"
+)
+
+// ErrLogDropped is returned by CreateLog when an ingest pipeline or the
+// redactor discarded the log instead of indexing it.
+var ErrLogDropped = errors.New("log dropped during ingestion")
+
+// ErrLogBuffered is returned by CreateLog when the log was accepted into a
+// multiline buffer and will be indexed once its event is complete.
+var ErrLogBuffered = errors.New("log buffered for multiline assembly")
+
+// ErrPatternsDisabled is returned by ListPatterns when pattern mining is off.
+var ErrPatternsDisabled = errors.New("pattern mining is disabled")
+
+// ErrMessageRestricted is returned by ListPatterns to principals that may
+// not see log messages, since patterns are made of them.
+var ErrMessageRestricted = errors.New("patterns are derived from log messages, which you may not see")
+
+// ErrLogMetricRestricted is returned by QueryLogMetric when the metric reads
+// a field the principal may not see.
+var ErrLogMetricRestricted = errors.New("the log metric reads fields you may not see")
+
+// multilineIndexTimeout bounds indexing of events flushed by the multiline
+// aggregator, which runs outside any request context.
+const multilineIndexTimeout = 10 * time.Second
+
+const (
+	// storageCheckInterval is how stale a tenant's measured index size may
+	// get before ingestion re-checks it against the storage quota.
+	storageCheckInterval     = time.Minute
+	defaultRetentionInterval = time.Hour
+	defaultRetentionTimeout  = 10 * time.Minute
 )
 
 type LogService interface {
@@ -13,19 +62,508 @@ type LogService interface {
 	GetLogByID(ctx context.Context, id string) (*models.Log, error)
 	UpdateLog(ctx context.Context, log *models.Log) error
 	DeleteLog(ctx context.Context, id string) error
-	SearchLogs(ctx context.Context, query string, page, limit int) ([]models.Log, error)
+	SearchLogs(ctx context.Context, filter models.LogFilter, page, limit int) ([]models.Log, error)
+	// SearchLogHits is SearchLogs with the scores, highlights and
+	// explanations opts asks for.
+	SearchLogHits(ctx context.Context, filter models.LogFilter, page, limit int, opts models.SearchOptions) (*models.SearchResult, error)
+	ExportLogs(ctx context.Context, filter models.LogFilter, fn func([]models.Log) error) error
+	// GetLogContext returns the log with id and up to before and after logs
+	// around it from the same stream. It returns nil if the log does not
+	// exist.
+	GetLogContext(ctx context.Context, id string, before, after int) (*models.LogContext, error)
+	// ListPatterns returns the message patterns of the logs matching
+	// filter, the most frequent first. A non-zero newSince only returns
+	// patterns first seen at or after it.
+	ListPatterns(ctx context.Context, filter models.LogFilter, newSince time.Time, limit int) ([]models.PatternCount, error)
+	// ListLogMetrics describes the metrics derived from logs at ingest
+	// whose fields the principal may see.
+	ListLogMetrics(ctx context.Context) []models.LogMetric
+	// QueryLogMetric returns the caller's tenant's series of a log metric.
+	QueryLogMetric(ctx context.Context, name string, query logmetrics.Query) ([]models.MetricSeries, error)
+	// Reload replaces the ingestion and access rules without dropping
+	// buffered events.
+	Reload(rules Rules)
+	// Pending returns the number of events waiting in ingestion buffers.
+	Pending() int
+	// Close flushes logs still buffered for ingestion.
+	Close() error
+}
+
+const exportBatchSize = 1000
+
+// Rules are the parts of the log service that can change while it runs.
+// Nil fields are disabled.
+type Rules struct {
+	Pipelines *pipeline.Set
+	Redactor  *redact.Redactor
+	Quotas    *tenant.Config
+	Policy    *access.Policy
+}
+
+// activeRules is swapped as a whole so a request never sees half a reload.
+type activeRules struct {
+	Rules
+	limiter *tenant.Limiter
 }
 
 type logService struct {
-	repo repository.LogRepository
+	repo  repository.LogRepository
+	rules atomic.Pointer[activeRules]
+
+	multilineConfig *multiline.Config
+
+	// Each tenant gets its own aggregator so merged events are indexed into
+	// the tenant they were received for.
+	multilineMu sync.Mutex
+	multiline   map[string]*multiline.Aggregator
+
+	audit AuditService
+
+	// Each tenant gets its own pattern miner, loaded from the repository
+	// the first time the tenant is seen.
+	patternConfig *patterns.Config
+	patternRepo   repository.PatternRepository
+	patternMu     sync.Mutex
+	miners        map[string]*patterns.Miner
+
+	tenantsMu sync.Mutex
+	tenants   map[string]*tenantUsage
+
+	retentionInterval time.Duration
+	retentionTimeout  time.Duration
+
+	contextStreamKeys []string
+
+	logMetrics *logmetrics.Registry
+
+	guard *guardrail.Guard
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type tenantUsage struct {
+	storage   int64
+	checkedAt time.Time
+}
+
+// Option configures optional parts of the log service.
+type Option func(*logService)
+
+// WithPipelines runs new logs through the ingest pipeline selected by their
+// source before they are indexed.
+func WithPipelines(pipelines *pipeline.Set) Option {
+	return func(s *logService) {
+		s.updateRules(func(r *Rules) { r.Pipelines = pipelines })
+	}
 }
 
-func NewLogService(repo repository.LogRepository) LogService {
-	return &logService{repo: repo}
+// WithRedactor scrubs sensitive values from new logs after the ingest
+// pipelines ran, so values extracted into metadata are covered too.
+func WithRedactor(redactor *redact.Redactor) Option {
+	return func(s *logService) {
+		s.updateRules(func(r *Rules) { r.Redactor = redactor })
+	}
+}
+
+// WithMultiline merges stack traces and other multi-line output into single
+// events before the pipelines run.
+func WithMultiline(cfg multiline.Config) Option {
+	return func(s *logService) {
+		s.multilineConfig = &cfg
+	}
+}
+
+// WithTenantQuotas enforces per-tenant ingest rate and storage limits and
+// deletes logs older than each tenant's retention.
+func WithTenantQuotas(cfg tenant.Config) Option {
+	return func(s *logService) {
+		s.updateRules(func(r *Rules) { r.Quotas = &cfg })
+	}
+}
+
+// WithFieldPolicy hides or masks sensitive fields in responses and keeps
+// them out of queries for principals the policy restricts.
+func WithFieldPolicy(policy *access.Policy) Option {
+	return func(s *logService) {
+		s.updateRules(func(r *Rules) { r.Policy = policy })
+	}
+}
+
+// WithAudit records updates and deletions, with before and after snapshots,
+// in the audit trail.
+func WithAudit(audit AuditService) Option {
+	return func(s *logService) {
+		s.audit = audit
+	}
+}
+
+// WithRetention sets how often logs past their tenant's retention are
+// deleted and how long one tenant's deletion may take.
+func WithRetention(interval, timeout time.Duration) Option {
+	return func(s *logService) {
+		s.retentionInterval = interval
+		s.retentionTimeout = timeout
+	}
+}
+
+// WithPatterns tags every new log with the ID of its mined message pattern
+// and stores the patterns in repo.
+func WithPatterns(cfg patterns.Config, repo repository.PatternRepository) Option {
+	return func(s *logService) {
+		s.patternConfig = &cfg
+		s.patternRepo = repo
+	}
+}
+
+// WithContextStreamKeys sets the metadata keys that, with the source,
+// identify the stream a log belongs to when fetching its context.
+func WithContextStreamKeys(keys []string) Option {
+	return func(s *logService) {
+		s.contextStreamKeys = keys
+	}
+}
+
+// WithLogMetrics evaluates the registry's metric definitions against every
+// indexed log.
+func WithLogMetrics(registry *logmetrics.Registry) Option {
+	return func(s *logService) {
+		s.logMetrics = registry
+	}
+}
+
+// WithGuardrails bounds the cost of searches, exports and pattern counts
+// and the number each user runs at once.
+func WithGuardrails(guard *guardrail.Guard) Option {
+	return func(s *logService) {
+		s.guard = guard
+	}
+}
+
+func NewLogService(repo repository.LogRepository, opts ...Option) (LogService, error) {
+	s := &logService{
+		repo:              repo,
+		multiline:         make(map[string]*multiline.Aggregator),
+		miners:            make(map[string]*patterns.Miner),
+		tenants:           make(map[string]*tenantUsage),
+		retentionInterval: defaultRetentionInterval,
+		retentionTimeout:  defaultRetentionTimeout,
+	}
+	s.rules.Store(&activeRules{})
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.multilineConfig != nil {
+		// Build the default tenant's aggregator up front so an invalid
+		// configuration fails at startup.
+		if _, err := s.aggregator(tenant.Default); err != nil {
+			return nil, err
+		}
+		metrics.RegisterQueue("multiline", func() float64 {
+			return float64(s.Pending())
+		})
+	}
+
+	// Retention runs even without quotas, which a reload may add.
+	s.tenants[tenant.Default] = &tenantUsage{}
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.retentionLoop()
+
+	return s, nil
+}
+
+// updateRules applies change to a copy of the current rules and swaps it in.
+func (s *logService) updateRules(change func(*Rules)) {
+	rules := s.rules.Load().Rules
+	change(&rules)
+	s.Reload(rules)
+}
+
+// Reload swaps in new rules. Changing quotas resets the ingest rate
+// limiters, so tenants start again from a full burst.
+func (s *logService) Reload(rules Rules) {
+	active := &activeRules{Rules: rules}
+	if rules.Quotas != nil {
+		active.limiter = tenant.NewLimiter(*rules.Quotas)
+
+		s.tenantsMu.Lock()
+		for id := range rules.Quotas.Tenants {
+			if _, ok := s.tenants[id]; !ok {
+				s.tenants[id] = &tenantUsage{}
+			}
+		}
+		s.tenantsMu.Unlock()
+	}
+	s.rules.Store(active)
 }
 
 func (s *logService) CreateLog(ctx context.Context, log *models.Log) error {
-	return s.repo.Create(ctx, log)
+	if err := s.checkQuota(ctx); err != nil {
+		metrics.IngestedLog(log.Source, log.Level, metrics.OutcomeRejected)
+		return err
+	}
+
+	if s.multilineConfig != nil {
+		agg, err := s.aggregator(tenant.FromContext(ctx))
+		if err != nil {
+			return err
+		}
+		if agg.Add(*log) {
+			metrics.IngestedLog(log.Source, log.Level, metrics.OutcomeBuffered)
+			return ErrLogBuffered
+		}
+	}
+	return s.ingest(ctx, log)
+}
+
+func (s *logService) aggregator(tenantID string) (*multiline.Aggregator, error) {
+	s.multilineMu.Lock()
+	defer s.multilineMu.Unlock()
+
+	if agg, ok := s.multiline[tenantID]; ok {
+		return agg, nil
+	}
+	agg, err := multiline.New(*s.multilineConfig, func(event models.Log) {
+		s.indexMerged(tenantID, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.multiline[tenantID] = agg
+	return agg, nil
+}
+
+// indexMerged indexes an event completed by the multiline aggregator.
+func (s *logService) indexMerged(tenantID string, event models.Log) {
+	ctx, cancel := context.WithTimeout(context.Background(), multilineIndexTimeout)
+	defer cancel()
+
+	ctx = tenant.WithTenant(ctx, tenantID)
+	if err := s.ingest(ctx, &event); err != nil && !errors.Is(err, ErrLogDropped) {
+		log.Printf("Failed to index multiline event from %s: %v", event.Source, err)
+	}
+}
+
+// Pending counts the multiline events buffered across tenants.
+func (s *logService) Pending() int {
+	s.multilineMu.Lock()
+	defer s.multilineMu.Unlock()
+
+	pending := 0
+	for _, agg := range s.multiline {
+		pending += agg.Pending()
+	}
+	return pending
+}
+
+func (s *logService) Close() error {
+	close(s.stop)
+	<-s.done
+
+	s.multilineMu.Lock()
+	aggregators := make([]*multiline.Aggregator, 0, len(s.multiline))
+	for _, agg := range s.multiline {
+		aggregators = append(aggregators, agg)
+	}
+	s.multilineMu.Unlock()
+
+	for _, agg := range aggregators {
+		agg.Close()
+	}
+	return nil
+}
+
+// checkQuota rejects the log when the caller's tenant is over its ingest
+// rate or storage quota. The index size is measured at most once per
+// storageCheckInterval.
+func (s *logService) checkQuota(ctx context.Context) error {
+	rules := s.rules.Load()
+	if rules.Quotas == nil {
+		return nil
+	}
+
+	tenantID := tenant.FromContext(ctx)
+	if !rules.limiter.Allow(tenantID, 1) {
+		return fmt.Errorf("%w: ingest rate", tenant.ErrQuotaExceeded)
+	}
+
+	maxStorage := int64(rules.Quotas.QuotaFor(tenantID).MaxStorage)
+
+	s.tenantsMu.Lock()
+	usage, ok := s.tenants[tenantID]
+	if !ok {
+		usage = &tenantUsage{}
+		s.tenants[tenantID] = usage
+	}
+	stale := time.Since(usage.checkedAt) > storageCheckInterval
+	if stale {
+		// Claim the refresh so concurrent requests keep using the last value.
+		usage.checkedAt = time.Now()
+	}
+	storage := usage.storage
+	s.tenantsMu.Unlock()
+
+	if maxStorage == 0 {
+		return nil
+	}
+
+	if stale {
+		size, err := s.repo.StorageSize(ctx)
+		if err != nil {
+			log.Printf("Failed to measure storage of tenant %s: %v", tenantID, err)
+		} else {
+			storage = size
+			s.tenantsMu.Lock()
+			usage.storage = size
+			s.tenantsMu.Unlock()
+		}
+	}
+
+	if storage >= maxStorage {
+		return fmt.Errorf("%w: storage", tenant.ErrQuotaExceeded)
+	}
+	return nil
+}
+
+// retentionLoop deletes logs past their tenant's retention for every tenant
+// configured or seen since startup.
+func (s *logService) retentionLoop() {
+	defer close(s.done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.stop
+		cancel()
+	}()
+
+	ticker := time.NewTicker(s.retentionInterval)
+	defer ticker.Stop()
+
+	for {
+		s.enforceRetention(ctx)
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *logService) enforceRetention(ctx context.Context) {
+	quotas := s.rules.Load().Quotas
+	if quotas == nil {
+		return
+	}
+
+	s.tenantsMu.Lock()
+	ids := make([]string, 0, len(s.tenants))
+	for id := range s.tenants {
+		ids = append(ids, id)
+	}
+	s.tenantsMu.Unlock()
+
+	for _, id := range ids {
+		retention := quotas.QuotaFor(id).Retention
+		if retention <= 0 {
+			continue
+		}
+
+		cutoff := time.Now().Add(-retention)
+		tenantCtx, cancel := context.WithTimeout(tenant.WithTenant(ctx, id), s.retentionTimeout)
+		deleted, err := s.repo.DeleteBefore(tenantCtx, cutoff)
+		cancel()
+
+		if err != nil || deleted > 0 {
+			s.record(tenant.WithTenant(ctx, id), models.AuditEvent{
+				Action:   AuditLogDeleteByQuery,
+				Resource: "log",
+				Details: map[string]string{
+					"reason":  "retention",
+					"before":  cutoff.UTC().Format(time.RFC3339),
+					"deleted": strconv.FormatInt(deleted, 10),
+				},
+			}, err)
+		}
+		if err != nil {
+			log.Printf("Failed to apply retention for tenant %s: %v", id, err)
+			continue
+		}
+		if deleted > 0 {
+			log.Printf("Deleted %d logs of tenant %s older than %s", deleted, id, retention)
+		}
+	}
+}
+
+func (s *logService) ingest(ctx context.Context, log *models.Log) error {
+	rules := s.rules.Load()
+	if (rules.Pipelines != nil && !rules.Pipelines.Process(log)) || (rules.Redactor != nil && !rules.Redactor.Redact(tenant.FromContext(ctx), log)) {
+		metrics.IngestedLog(log.Source, log.Level, metrics.OutcomeDropped)
+		return ErrLogDropped
+	}
+	s.tagPattern(ctx, log)
+
+	if err := s.repo.Create(ctx, log); err != nil {
+		metrics.IngestedLog(log.Source, log.Level, metrics.OutcomeFailed)
+		return err
+	}
+	metrics.IngestedLog(log.Source, log.Level, metrics.OutcomeIndexed)
+	if s.logMetrics != nil {
+		s.logMetrics.Observe(tenant.FromContext(ctx), log, time.Now())
+	}
+	return nil
+}
+
+// tagPattern sets the log's pattern ID after the pipelines and the
+// redactor ran, so patterns never contain redacted values. New and changed
+// patterns are saved right away; that is rare once the common message
+// shapes have been seen. Failures leave the log untagged.
+func (s *logService) tagPattern(ctx context.Context, event *models.Log) {
+	if s.patternConfig == nil {
+		return
+	}
+	miner, err := s.miner(ctx, tenant.FromContext(ctx))
+	if err != nil {
+		log.Printf("Failed to load patterns: %v", err)
+		return
+	}
+
+	pattern, changed := miner.Add(event.Message, time.Now().UTC())
+	if pattern == nil {
+		return
+	}
+	event.PatternID = pattern.ID
+	if changed {
+		if err := s.patternRepo.Save(ctx, pattern); err != nil {
+			log.Printf("Failed to save pattern %s: %v", pattern.ID, err)
+		}
+	}
+}
+
+// miner returns the tenant's pattern miner. A miner whose patterns could
+// not be loaded is not kept, so the next log tries again.
+func (s *logService) miner(ctx context.Context, tenantID string) (*patterns.Miner, error) {
+	s.patternMu.Lock()
+	defer s.patternMu.Unlock()
+
+	if miner, ok := s.miners[tenantID]; ok {
+		return miner, nil
+	}
+	stored, err := s.patternRepo.List(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	miner := patterns.NewMiner(*s.patternConfig, tenantID)
+	miner.Restore(stored)
+	s.miners[tenantID] = miner
+	return miner, nil
+}
+
+// view returns the field restrictions for the request's principal.
+func (s *logService) view(ctx context.Context) *access.View {
+	return s.rules.Load().Policy.ViewFor(auth.PrincipalFromContext(ctx))
 }
 
 func (s *logService) GetLogs(ctx context.Context, page, limit int) ([]models.Log, error) {
@@ -35,27 +573,335 @@ func (s *logService) GetLogs(ctx context.Context, page, limit int) ([]models.Log
 	if limit < 1 {
 		limit = 10
 	}
-	return s.repo.GetAll(ctx, page, limit)
+	ctx, release, err := s.guardSearch(ctx, nil, page, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	logs, err := s.repo.GetAll(ctx, page, limit)
+	if err != nil {
+		return nil, err
+	}
+	applyView(s.view(ctx), logs)
+	return logs, nil
 }
 
 func (s *logService) GetLogByID(ctx context.Context, id string) (*models.Log, error) {
-	return s.repo.GetByID(ctx, id)
+	log, err := s.repo.GetByID(ctx, id)
+	if err != nil || log == nil {
+		return log, err
+	}
+	s.view(ctx).Apply(log)
+	return log, nil
 }
 
 func (s *logService) UpdateLog(ctx context.Context, log *models.Log) error {
-	return s.repo.Update(ctx, log)
+	view := s.view(ctx)
+
+	var original *models.Log
+	if view != nil || s.audit != nil {
+		var err error
+		original, err = s.repo.GetByID(ctx, log.ID)
+		if err != nil {
+			return err
+		}
+	}
+	view.Restore(log, original)
+
+	err := s.repo.Update(ctx, log)
+	s.record(ctx, models.AuditEvent{
+		Action:     AuditLogUpdate,
+		Resource:   "log",
+		ResourceID: log.ID,
+		Before:     original,
+		After:      log,
+	}, err)
+	return err
 }
 
 func (s *logService) DeleteLog(ctx context.Context, id string) error {
-	return s.repo.Delete(ctx, id)
+	var original *models.Log
+	if s.audit != nil {
+		var err error
+		original, err = s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := s.repo.Delete(ctx, id)
+	s.record(ctx, models.AuditEvent{
+		Action:     AuditLogDelete,
+		Resource:   "log",
+		ResourceID: id,
+		Before:     original,
+	}, err)
+	return err
+}
+
+func (s *logService) record(ctx context.Context, event models.AuditEvent, err error) {
+	if s.audit != nil {
+		s.audit.Record(ctx, event, err)
+	}
+}
+
+func (s *logService) SearchLogs(ctx context.Context, filter models.LogFilter, page, limit int) ([]models.Log, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	ctx, release, err := s.guardSearch(ctx, &filter, page, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	view := s.view(ctx)
+	filter.RestrictedFields = view.RestrictedFields()
+	logs, err := s.repo.Search(ctx, filter, page, limit)
+	if err != nil {
+		return nil, err
+	}
+	applyView(view, logs)
+	return logs, nil
 }
 
-func (s *logService) SearchLogs(ctx context.Context, query string, page, limit int) ([]models.Log, error) {
+func (s *logService) SearchLogHits(ctx context.Context, filter models.LogFilter, page, limit int, opts models.SearchOptions) (*models.SearchResult, error) {
 	if page < 1 {
 		page = 1
 	}
 	if limit < 1 {
 		limit = 10
 	}
-	return s.repo.Search(ctx, query, page, limit)
+
+	ctx, release, err := s.guardSearch(ctx, &filter, page, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// The repository leaves restricted fields out of the highlights.
+	view := s.view(ctx)
+	filter.RestrictedFields = view.RestrictedFields()
+	result, err := s.repo.SearchHits(ctx, filter, page, limit, opts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range result.Hits {
+		view.Apply(&result.Hits[i].Log)
+	}
+	return result, nil
+}
+
+func (s *logService) ExportLogs(ctx context.Context, filter models.LogFilter, fn func([]models.Log) error) error {
+	ctx, release, err := s.guardSearch(ctx, &filter, 0, 0)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	view := s.view(ctx)
+	filter.RestrictedFields = view.RestrictedFields()
+	return s.repo.Export(ctx, filter, exportBatchSize, func(logs []models.Log) error {
+		applyView(view, logs)
+		return fn(logs)
+	})
+}
+
+// guardSearch checks a search against the guardrails, rewriting filter if
+// they rewrite its query, and takes one of the caller's concurrent search
+// slots until release is called. A zero limit is not paged; a nil filter
+// has no range or query. The returned context carries the Elasticsearch
+// limits.
+func (s *logService) guardSearch(ctx context.Context, filter *models.LogFilter, page, limit int) (context.Context, func(), error) {
+	if s.guard == nil {
+		return ctx, func() {}, nil
+	}
+	if limit > 0 {
+		if err := s.guard.CheckPage(page, limit); err != nil {
+			return ctx, nil, err
+		}
+	}
+	if filter != nil {
+		if err := s.guard.CheckFilter(auth.PrincipalFromContext(ctx), filter); err != nil {
+			return ctx, nil, err
+		}
+	}
+	release, err := s.guard.Acquire(searcher(ctx))
+	if err != nil {
+		return ctx, nil, err
+	}
+	return s.guard.WithLimits(ctx), release, nil
+}
+
+// searcher identifies who runs a search: the principal, or the client
+// address for principals every caller shares, such as the anonymous one
+// when authentication is off and the bootstrap admin key.
+func searcher(ctx context.Context) string {
+	if p := auth.PrincipalFromContext(ctx); p != nil && p != auth.Anonymous && p.Kind != "static_key" {
+		return tenant.FromContext(ctx) + "/" + p.ID
+	}
+	info, _ := ctx.Value(requestInfoKey{}).(RequestInfo)
+	return "addr/" + info.RemoteAddr
+}
+
+func (s *logService) GetLogContext(ctx context.Context, id string, before, after int) (*models.LogContext, error) {
+	anchor, err := s.repo.GetByID(ctx, id)
+	if err != nil || anchor == nil {
+		return nil, err
+	}
+
+	// Fields the caller may not see do not narrow the stream, so the
+	// neighbours returned cannot reveal their values.
+	view := s.view(ctx)
+	restricted := make(map[string]bool)
+	for _, field := range view.RestrictedFields() {
+		restricted[field] = true
+	}
+	stream := make(map[string]string)
+	if !restricted["source"] {
+		stream["source"] = anchor.Source
+	}
+	for _, key := range s.contextStreamKeys {
+		if field := "metadata." + key; !restricted[field] {
+			stream[field] = anchor.Metadata[key]
+		}
+	}
+
+	preceding, following, err := s.repo.Surrounding(ctx, id, stream, before, after)
+	if err != nil {
+		return nil, err
+	}
+	view.Apply(anchor)
+	applyView(view, preceding)
+	applyView(view, following)
+	return &models.LogContext{Log: *anchor, Stream: stream, Before: preceding, After: following}, nil
+}
+
+func (s *logService) ListPatterns(ctx context.Context, filter models.LogFilter, newSince time.Time, limit int) ([]models.PatternCount, error) {
+	if s.patternConfig == nil {
+		return nil, ErrPatternsDisabled
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	view := s.view(ctx)
+	filter.RestrictedFields = view.RestrictedFields()
+	for _, field := range filter.RestrictedFields {
+		if field == "message" {
+			return nil, ErrMessageRestricted
+		}
+	}
+
+	ctx, release, err := s.guardSearch(ctx, &filter, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	tenantID := tenant.FromContext(ctx)
+	miner, err := s.miner(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	// Other instances may have mined patterns this one has not seen.
+	refresh := func() error {
+		stored, err := s.patternRepo.List(ctx, tenantID)
+		if err != nil {
+			return err
+		}
+		miner.Restore(stored)
+		return nil
+	}
+
+	var ids []string
+	if !newSince.IsZero() {
+		if err := refresh(); err != nil {
+			return nil, err
+		}
+		for id, pattern := range miner.Patterns() {
+			if !pattern.FirstSeen.Before(newSince) {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) == 0 {
+			return []models.PatternCount{}, nil
+		}
+		sort.Strings(ids)
+	}
+
+	counts, err := s.repo.CountPatterns(ctx, filter, ids, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	known := miner.Patterns()
+	for _, count := range counts {
+		if _, ok := known[count.ID]; !ok {
+			if err := refresh(); err != nil {
+				return nil, err
+			}
+			known = miner.Patterns()
+			break
+		}
+	}
+	for i := range counts {
+		if pattern, ok := known[counts[i].ID]; ok {
+			counts[i].Pattern = pattern
+		}
+	}
+	return counts, nil
+}
+
+func (s *logService) ListLogMetrics(ctx context.Context) []models.LogMetric {
+	if s.logMetrics == nil {
+		return []models.LogMetric{}
+	}
+	restricted := s.view(ctx).RestrictedFields()
+	visible := []models.LogMetric{}
+	for _, metric := range s.logMetrics.Metrics() {
+		if fields, _ := s.logMetrics.Fields(metric.Name); !readsRestricted(fields, restricted) {
+			visible = append(visible, metric)
+		}
+	}
+	return visible
+}
+
+// QueryLogMetric only sees the logs this instance indexed; each backend
+// instance derives its own series.
+func (s *logService) QueryLogMetric(ctx context.Context, name string, query logmetrics.Query) ([]models.MetricSeries, error) {
+	if s.logMetrics == nil {
+		return nil, logmetrics.ErrNotFound
+	}
+	fields, ok := s.logMetrics.Fields(name)
+	if !ok {
+		return nil, logmetrics.ErrNotFound
+	}
+	if readsRestricted(fields, s.view(ctx).RestrictedFields()) {
+		return nil, ErrLogMetricRestricted
+	}
+	query.Tenant = tenant.FromContext(ctx)
+	return s.logMetrics.Query(name, query)
+}
+
+func readsRestricted(fields, restricted []string) bool {
+	for _, field := range fields {
+		for _, r := range restricted {
+			if field == r {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func applyView(view *access.View, logs []models.Log) {
+	for i := range logs {
+		view.Apply(&logs[i])
+	}
 }
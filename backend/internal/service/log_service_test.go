@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/guardrail"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tenant"
+)
+
+func searchContext(p *auth.Principal, addr string) context.Context {
+	ctx := WithRequestInfo(context.Background(), RequestInfo{RemoteAddr: addr})
+	if p != nil {
+		ctx = tenant.WithTenant(auth.WithPrincipal(ctx, p), p.Tenant)
+	}
+	return ctx
+}
+
+func TestConcurrencyIsPerClientForSharedPrincipals(t *testing.T) {
+	bootstrap, err := auth.NewStaticKey("secret").Authenticate(context.Background(), "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := &auth.Principal{ID: "0123456789abcdef", Kind: "api_key", Tenant: tenant.Default}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		// shared is whether callers from different addresses share a slot.
+		shared bool
+	}{
+		{"auth off", auth.Anonymous, false},
+		{"no principal", nil, false},
+		{"bootstrap key", bootstrap, false},
+		{"api key", key, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := guardrail.New(guardrail.Config{MaxConcurrent: 1})
+
+			release, err := g.Acquire(searcher(searchContext(tt.principal, "10.0.0.1")))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer release()
+
+			if _, err := g.Acquire(searcher(searchContext(tt.principal, "10.0.0.1"))); err == nil {
+				t.Fatal("expected a second search from the same client to be rejected")
+			}
+			_, err = g.Acquire(searcher(searchContext(tt.principal, "10.0.0.2")))
+			if shared := err != nil; shared != tt.shared {
+				t.Fatalf("expected shared=%v for another client, got err %v", tt.shared, err)
+			}
+		})
+	}
+}
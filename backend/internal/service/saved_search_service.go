@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/repository"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/savedsearch"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tenant"
+)
+
+var (
+	ErrSavedSearchNotFound = errors.New("saved search not found")
+	ErrNotSearchOwner      = errors.New("only the owner or an admin can change a saved search")
+)
+
+// SavedSearchService manages saved searches. A search is visible to its
+// owner, and to the rest of the owner's tenant once shared; searches the
+// caller cannot see are reported as not found.
+type SavedSearchService interface {
+	Create(ctx context.Context, req models.SavedSearchRequest) (*models.SavedSearch, error)
+	List(ctx context.Context) ([]models.SavedSearch, error)
+	// Open returns the search with its time range evaluated now.
+	Open(ctx context.Context, id string) (*models.ResolvedSearch, error)
+	Update(ctx context.Context, id string, req models.SavedSearchRequest) (*models.SavedSearch, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type savedSearchService struct {
+	repo  repository.SavedSearchRepository
+	audit AuditService
+}
+
+// NewSavedSearchService returns the saved search service. audit may be nil.
+func NewSavedSearchService(repo repository.SavedSearchRepository, audit AuditService) SavedSearchService {
+	return &savedSearchService{repo: repo, audit: audit}
+}
+
+func (s *savedSearchService) Create(ctx context.Context, req models.SavedSearchRequest) (*models.SavedSearch, error) {
+	id, err := savedsearch.NewID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	search := searchFromRequest(req)
+	search.ID = id
+	search.Tenant = tenant.FromContext(ctx)
+	if p := auth.PrincipalFromContext(ctx); p != nil {
+		search.Owner, search.OwnerName = p.ID, p.Name
+	}
+	search.CreatedAt = now
+	search.UpdatedAt = now
+	if err := savedsearch.Validate(search); err != nil {
+		return nil, err
+	}
+
+	err = s.repo.Save(ctx, search)
+	s.record(ctx, models.AuditEvent{
+		Action:     AuditSavedSearchCreate,
+		Resource:   "saved_search",
+		ResourceID: id,
+		After:      search,
+	}, err)
+	if err != nil {
+		return nil, err
+	}
+	return search, nil
+}
+
+func (s *savedSearchService) List(ctx context.Context) ([]models.SavedSearch, error) {
+	return s.repo.List(ctx, tenant.FromContext(ctx), callerID(ctx))
+}
+
+func (s *savedSearchService) Open(ctx context.Context, id string) (*models.ResolvedSearch, error) {
+	search, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := savedsearch.Resolve(*search, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &models.ResolvedSearch{SavedSearch: *search, State: state}, nil
+}
+
+func (s *savedSearchService) Update(ctx context.Context, id string, req models.SavedSearchRequest) (*models.SavedSearch, error) {
+	before, err := s.getOwned(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	search := searchFromRequest(req)
+	search.ID = before.ID
+	search.Tenant = before.Tenant
+	search.Owner, search.OwnerName = before.Owner, before.OwnerName
+	search.CreatedAt = before.CreatedAt
+	search.UpdatedAt = time.Now().UTC()
+	if err := savedsearch.Validate(search); err != nil {
+		return nil, err
+	}
+
+	err = s.repo.Save(ctx, search)
+	s.record(ctx, models.AuditEvent{
+		Action:     AuditSavedSearchUpdate,
+		Resource:   "saved_search",
+		ResourceID: id,
+		Before:     before,
+		After:      search,
+	}, err)
+	if err != nil {
+		return nil, err
+	}
+	return search, nil
+}
+
+func (s *savedSearchService) Delete(ctx context.Context, id string) error {
+	before, err := s.getOwned(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = s.repo.Delete(ctx, id)
+	s.record(ctx, models.AuditEvent{
+		Action:     AuditSavedSearchDelete,
+		Resource:   "saved_search",
+		ResourceID: id,
+		Before:     before,
+	}, err)
+	return err
+}
+
+func (s *savedSearchService) record(ctx context.Context, event models.AuditEvent, err error) {
+	if s.audit != nil {
+		s.audit.Record(ctx, event, err)
+	}
+}
+
+func (s *savedSearchService) get(ctx context.Context, id string) (*models.SavedSearch, error) {
+	search, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if search == nil || search.Tenant != tenant.FromContext(ctx) {
+		return nil, ErrSavedSearchNotFound
+	}
+	if search.Visibility != models.VisibilityTenant && search.Owner != callerID(ctx) {
+		return nil, ErrSavedSearchNotFound
+	}
+	return search, nil
+}
+
+// getOwned returns a search the caller may change: their own, or any visible
+// one for admins.
+func (s *savedSearchService) getOwned(ctx context.Context, id string) (*models.SavedSearch, error) {
+	search, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if search.Owner != callerID(ctx) && !auth.PrincipalFromContext(ctx).HasScope(auth.ScopeAdmin) {
+		return nil, ErrNotSearchOwner
+	}
+	return search, nil
+}
+
+func callerID(ctx context.Context) string {
+	if p := auth.PrincipalFromContext(ctx); p != nil {
+		return p.ID
+	}
+	return ""
+}
+
+func searchFromRequest(req models.SavedSearchRequest) *models.SavedSearch {
+	return &models.SavedSearch{
+		Name:        req.Name,
+		Description: req.Description,
+		Visibility:  req.Visibility,
+		Query:       req.Query,
+		Level:       req.Level,
+		Source:      req.Source,
+		From:        req.From,
+		To:          req.To,
+		Columns:     req.Columns,
+		Sort:        req.Sort,
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/logmetrics"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tracing"
+)
+
+// tracedLogService wraps every LogService method in a span.
+type tracedLogService struct {
+	next LogService
+}
+
+func NewTracedLogService(next LogService) LogService {
+	return &tracedLogService{next: next}
+}
+
+func (s *tracedLogService) CreateLog(ctx context.Context, log *models.Log) error {
+	ctx, span := tracing.Start(ctx, "LogService.CreateLog", attribute.String("log.source", log.Source))
+	err := s.next.CreateLog(ctx, log)
+	switch {
+	case errors.Is(err, ErrLogDropped):
+		span.SetAttributes(attribute.String("ingest.outcome", "dropped"))
+		tracing.End(span, nil)
+	case errors.Is(err, ErrLogBuffered):
+		span.SetAttributes(attribute.String("ingest.outcome", "buffered"))
+		tracing.End(span, nil)
+	default:
+		tracing.End(span, err)
+	}
+	return err
+}
+
+func (s *tracedLogService) GetLogs(ctx context.Context, page, limit int) ([]models.Log, error) {
+	ctx, span := tracing.Start(ctx, "LogService.GetLogs", attribute.Int("page", page), attribute.Int("limit", limit))
+	logs, err := s.next.GetLogs(ctx, page, limit)
+	tracing.End(span, err)
+	return logs, err
+}
+
+func (s *tracedLogService) GetLogByID(ctx context.Context, id string) (*models.Log, error) {
+	ctx, span := tracing.Start(ctx, "LogService.GetLogByID", attribute.String("log.id", id))
+	log, err := s.next.GetLogByID(ctx, id)
+	tracing.End(span, err)
+	return log, err
+}
+
+func (s *tracedLogService) UpdateLog(ctx context.Context, log *models.Log) error {
+	ctx, span := tracing.Start(ctx, "LogService.UpdateLog", attribute.String("log.id", log.ID))
+	err := s.next.UpdateLog(ctx, log)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedLogService) DeleteLog(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "LogService.DeleteLog", attribute.String("log.id", id))
+	err := s.next.DeleteLog(ctx, id)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedLogService) SearchLogs(ctx context.Context, filter models.LogFilter, page, limit int) ([]models.Log, error) {
+	ctx, span := tracing.Start(ctx, "LogService.SearchLogs", attribute.Int("page", page), attribute.Int("limit", limit))
+	logs, err := s.next.SearchLogs(ctx, filter, page, limit)
+	span.SetAttributes(attribute.Int("results", len(logs)))
+	tracing.End(span, err)
+	return logs, err
+}
+
+func (s *tracedLogService) SearchLogHits(ctx context.Context, filter models.LogFilter, page, limit int, opts models.SearchOptions) (*models.SearchResult, error) {
+	ctx, span := tracing.Start(ctx, "LogService.SearchLogHits", attribute.Int("page", page), attribute.Int("limit", limit),
+		attribute.Bool("highlight", opts.Highlight), attribute.Bool("explain", opts.Explain))
+	result, err := s.next.SearchLogHits(ctx, filter, page, limit, opts)
+	if result != nil {
+		span.SetAttributes(attribute.Int("results", len(result.Hits)))
+	}
+	tracing.End(span, err)
+	return result, err
+}
+
+func (s *tracedLogService) ExportLogs(ctx context.Context, filter models.LogFilter, fn func([]models.Log) error) error {
+	ctx, span := tracing.Start(ctx, "LogService.ExportLogs")
+	err := s.next.ExportLogs(ctx, filter, fn)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *tracedLogService) GetLogContext(ctx context.Context, id string, before, after int) (*models.LogContext, error) {
+	ctx, span := tracing.Start(ctx, "LogService.GetLogContext", attribute.String("log.id", id), attribute.Int("before", before), attribute.Int("after", after))
+	logContext, err := s.next.GetLogContext(ctx, id, before, after)
+	tracing.End(span, err)
+	return logContext, err
+}
+
+func (s *tracedLogService) ListPatterns(ctx context.Context, filter models.LogFilter, newSince time.Time, limit int) ([]models.PatternCount, error) {
+	ctx, span := tracing.Start(ctx, "LogService.ListPatterns", attribute.Int("limit", limit))
+	counts, err := s.next.ListPatterns(ctx, filter, newSince, limit)
+	span.SetAttributes(attribute.Int("results", len(counts)))
+	tracing.End(span, err)
+	return counts, err
+}
+
+func (s *tracedLogService) ListLogMetrics(ctx context.Context) []models.LogMetric {
+	return s.next.ListLogMetrics(ctx)
+}
+
+func (s *tracedLogService) QueryLogMetric(ctx context.Context, name string, query logmetrics.Query) ([]models.MetricSeries, error) {
+	ctx, span := tracing.Start(ctx, "LogService.QueryLogMetric", attribute.String("metric", name), attribute.String("stat", query.Stat))
+	series, err := s.next.QueryLogMetric(ctx, name, query)
+	span.SetAttributes(attribute.Int("results", len(series)))
+	tracing.End(span, err)
+	return series, err
+}
+
+func (s *tracedLogService) Reload(rules Rules) {
+	s.next.Reload(rules)
+}
+
+func (s *tracedLogService) Pending() int {
+	return s.next.Pending()
+}
+
+func (s *tracedLogService) Close() error {
+	return s.next.Close()
+}
@@ -0,0 +1,146 @@
+package tenant
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrQuotaExceeded = errors.New("tenant quota exceeded")
+
+// Quota limits one tenant. Zero values mean unlimited.
+type Quota struct {
+	// IngestRate is the sustained number of logs per second, with bursts
+	// of up to IngestBurst.
+	IngestRate  float64       `yaml:"ingest_rate"`
+	IngestBurst int           `yaml:"ingest_burst"`
+	MaxStorage  ByteSize      `yaml:"max_storage"`
+	Retention   time.Duration `yaml:"retention"`
+}
+
+// Config is loaded from TENANT_CONFIG. Tenants inherit every limit they do
+// not set from Defaults.
+type Config struct {
+	Defaults Quota            `yaml:"defaults"`
+	Tenants  map[string]Quota `yaml:"tenants"`
+}
+
+func LoadFile(path string) (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("error reading tenant config: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("error parsing tenant config: %w", err)
+	}
+	for id := range cfg.Tenants {
+		if !Valid(id) {
+			return cfg, fmt.Errorf("invalid tenant id %q", id)
+		}
+	}
+	return cfg, nil
+}
+
+func (c Config) QuotaFor(id string) Quota {
+	q := c.Defaults
+	t, ok := c.Tenants[id]
+	if !ok {
+		return q
+	}
+	if t.IngestRate > 0 {
+		q.IngestRate = t.IngestRate
+	}
+	if t.IngestBurst > 0 {
+		q.IngestBurst = t.IngestBurst
+	}
+	if t.MaxStorage > 0 {
+		q.MaxStorage = t.MaxStorage
+	}
+	if t.Retention > 0 {
+		q.Retention = t.Retention
+	}
+	return q
+}
+
+// Limiter enforces the per-tenant ingest rate.
+type Limiter struct {
+	cfg Config
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+func NewLimiter(cfg Config) *Limiter {
+	return &Limiter{cfg: cfg, limiters: make(map[string]*rate.Limiter)}
+}
+
+// Allow reports whether the tenant may ingest n more logs now.
+func (l *Limiter) Allow(id string, n int) bool {
+	l.mu.Lock()
+	limiter, ok := l.limiters[id]
+	if !ok {
+		q := l.cfg.QuotaFor(id)
+		limit, burst := rate.Inf, 0
+		if q.IngestRate > 0 {
+			limit = rate.Limit(q.IngestRate)
+			burst = q.IngestBurst
+			if burst < 1 {
+				burst = int(q.IngestRate) + 1
+			}
+		}
+		limiter = rate.NewLimiter(limit, burst)
+		l.limiters[id] = limiter
+	}
+	l.mu.Unlock()
+
+	return limiter.AllowN(time.Now(), n)
+}
+
+// ByteSize is a size in bytes that may be written as "500MB" or "10GB".
+type ByteSize int64
+
+var byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"TB", 1 << 40},
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	for _, unit := range byteUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			n, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(s, unit.suffix)), 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid size %q", s)
+			}
+			return ByteSize(n * float64(unit.size)), nil
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return ByteSize(n), nil
+}
+
+func (b *ByteSize) UnmarshalYAML(value *yaml.Node) error {
+	size, err := ParseByteSize(value.Value)
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
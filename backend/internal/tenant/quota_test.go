@@ -0,0 +1,63 @@
+package tenant
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestQuotaForInheritsDefaults(t *testing.T) {
+	var cfg Config
+	err := yaml.Unmarshal([]byte(`
+defaults:
+  ingest_rate: 100
+  max_storage: 10GB
+  retention: 720h
+tenants:
+  payments:
+    max_storage: 500MB
+`), &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := cfg.QuotaFor("payments")
+	if q.IngestRate != 100 || q.Retention != 720*time.Hour {
+		t.Fatalf("expected defaults to be inherited, got %+v", q)
+	}
+	if q.MaxStorage != 500<<20 {
+		t.Fatalf("expected 500MB, got %d", q.MaxStorage)
+	}
+	if cfg.QuotaFor("other").MaxStorage != 10<<30 {
+		t.Fatalf("expected unknown tenants to get the defaults")
+	}
+}
+
+func TestLimiterIsPerTenant(t *testing.T) {
+	l := NewLimiter(Config{Tenants: map[string]Quota{"noisy": {IngestRate: 1, IngestBurst: 2}}})
+
+	if !l.Allow("noisy", 1) || !l.Allow("noisy", 1) {
+		t.Fatal("expected the burst to be allowed")
+	}
+	if l.Allow("noisy", 1) {
+		t.Fatal("expected noisy to be rate limited")
+	}
+	for i := 0; i < 100; i++ {
+		if !l.Allow("quiet", 1) {
+			t.Fatal("expected tenants without a rate to be unlimited")
+		}
+	}
+}
+
+func TestIndexName(t *testing.T) {
+	if got := IndexName("logs", Default); got != "logs" {
+		t.Fatalf("expected the default tenant to keep the base index, got %s", got)
+	}
+	if got := IndexName("logs", "payments"); got != "logs-payments" {
+		t.Fatalf("got %s", got)
+	}
+	if Valid("../logs") || Valid("Payments") || !Valid("team-a_1") {
+		t.Fatal("unexpected tenant id validation")
+	}
+}
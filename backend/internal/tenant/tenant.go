@@ -0,0 +1,41 @@
+package tenant
+
+import (
+	"context"
+	"regexp"
+)
+
+// Default is the tenant of callers that are not bound to a team, such as the
+// bootstrap admin key or an unauthenticated deployment. Its logs stay in the
+// configured index so single-team installs see no change.
+const Default = "default"
+
+// Tenant ids become part of Elasticsearch index names, so they are limited
+// to what an index name may contain.
+var validID = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,47}$`)
+
+func Valid(id string) bool {
+	return validID.MatchString(id)
+}
+
+type contextKey struct{}
+
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the tenant the request runs as, or Default.
+func FromContext(ctx context.Context) string {
+	if id, _ := ctx.Value(contextKey{}).(string); id != "" {
+		return id
+	}
+	return Default
+}
+
+// IndexName returns the index holding a tenant's logs.
+func IndexName(base, id string) string {
+	if id == "" || id == Default {
+		return base
+	}
+	return base + "-" + id
+}
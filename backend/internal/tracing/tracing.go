@@ -0,0 +1,120 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/sanjeevmurmu/logana/logana-backend"
+
+// maxQueryEventSize caps query bodies attached to spans in debug mode.
+const maxQueryEventSize = 4096
+
+// Config selects the span exporter. The OTLP exporter reads its endpoint and
+// headers from the standard OTEL_EXPORTER_OTLP_* variables.
+type Config struct {
+	// Exporter is "otlp", "stdout" or "none".
+	Exporter    string
+	ServiceName string
+	// Debug attaches Elasticsearch query bodies to spans as events.
+	Debug bool
+}
+
+// Validate reports an unknown exporter or a missing service name.
+func (c Config) Validate() error {
+	switch c.Exporter {
+	case "otlp", "stdout", "none":
+	default:
+		return fmt.Errorf("unknown traces exporter %q, want otlp, stdout or none", c.Exporter)
+	}
+	if c.ServiceName == "" {
+		return errors.New("a service name is required")
+	}
+	return nil
+}
+
+var debug bool
+
+// Setup installs the global tracer provider and the W3C trace context
+// propagator. The returned function flushes buffered spans on shutdown.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	debug = cfg.Debug
+
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+	switch cfg.Exporter {
+	case "none":
+		return func(context.Context) error { return nil }, nil
+	case "otlp":
+		exporter, err = otlptracehttp.New(ctx)
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown traces exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s exporter: %w", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("error creating trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Start opens a span named name as a child of the span in ctx.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err, if any, on span and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// RecordQuery attaches an Elasticsearch request body to the current span
+// when debug mode is on.
+func RecordQuery(ctx context.Context, body string) {
+	if !debug {
+		return
+	}
+	if len(body) > maxQueryEventSize {
+		body = body[:maxQueryEventSize] + "...(truncated)"
+	}
+	trace.SpanFromContext(ctx).AddEvent("elasticsearch.query", trace.WithAttributes(
+		semconv.DBStatement(body),
+	))
+}
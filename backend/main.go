@@ -1,16 +1,41 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/access"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/alerting"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/anomaly"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/auth"
 	"github.com/sanjeevmurmu/logana/logana-backend/internal/config"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/guardrail"
 	"github.com/sanjeevmurmu/logana/logana-backend/internal/handler"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/health"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/logmetrics"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/metrics"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/models"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/notify"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/querycache"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/redact"
 	"github.com/sanjeevmurmu/logana/logana-backend/internal/repository"
 	"github.com/sanjeevmurmu/logana/logana-backend/internal/service"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tenant"
+	"github.com/sanjeevmurmu/logana/logana-backend/internal/tracing"
 )
 
 func main() {
@@ -19,25 +44,186 @@ func main() {
 		log.Printf("Warning: .env file not found")
 	}
 
+	configPath := flag.String("config", os.Getenv("LOGANA_CONFIG"), "YAML or TOML config file; environment variables override it")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+	rules, err := cfg.LoadRules()
+	if err != nil {
+		log.Fatalf("Failed to load rules: %v", err)
+	}
+	// Redaction counts outlive the redactor, which a reload replaces
+	redactions := redact.NewCounter()
+	if rules.Redactor != nil {
+		rules.Redactor.SetCounter(redactions)
+	}
+
+	// Set up tracing before anything creates spans
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing.Tracing())
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+
 	// Initialize Elasticsearch client
-	esConfig, err := config.NewElasticsearchClient()
+	esConfig, err := config.NewElasticsearchClient(cfg.Elasticsearch)
 	if err != nil {
 		log.Fatalf("Failed to create Elasticsearch client: %v", err)
 	}
 
+	// Ingestion and access rules; everything but multiline can be reloaded
+	serviceOpts := []service.Option{
+		service.WithPipelines(rules.Pipelines),
+		service.WithRedactor(rules.Redactor),
+		service.WithFieldPolicy(rules.AccessPolicy),
+		service.WithRetention(cfg.Retention.Interval.Duration, cfg.Retention.Timeout.Duration),
+		service.WithContextStreamKeys(cfg.Search.ContextStreamKeys),
+	}
+	if rules.Tenants != nil {
+		serviceOpts = append(serviceOpts, service.WithTenantQuotas(*rules.Tenants))
+	}
+	if rules.Multiline != nil {
+		serviceOpts = append(serviceOpts, service.WithMultiline(*rules.Multiline))
+	}
+
+	// Audit trail, kept in its own index
+	auditRepo := repository.NewAuditRepository(esConfig)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if err := auditRepo.EnsureIndex(ctx); err != nil {
+		log.Printf("Warning: failed to create audit index: %v", err)
+	}
+	cancel()
+	auditService := service.NewAuditService(auditRepo)
+	serviceOpts = append(serviceOpts, service.WithAudit(auditService))
+
+	// Message patterns mined from every new log
+	if cfg.Patterns.Enabled {
+		patternRepo := repository.NewPatternRepository(esConfig)
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+		if err := patternRepo.EnsureIndex(ctx); err != nil {
+			log.Printf("Warning: failed to create pattern index: %v", err)
+		}
+		cancel()
+		serviceOpts = append(serviceOpts, service.WithPatterns(cfg.Patterns.Miner(), patternRepo))
+	}
+
+	// Metrics derived from logs at ingest, exposed on /api/metrics
+	logMetrics := logmetrics.NewRegistry(rules.LogMetrics)
+	serviceOpts = append(serviceOpts, service.WithLogMetrics(logMetrics))
+
+	// Cost limits on searches, exports and pattern counts
+	serviceOpts = append(serviceOpts, service.WithGuardrails(guardrail.New(cfg.Search.Guardrails.Guardrails())))
+
 	// Initialize components
-	logRepo := repository.NewLogRepository(esConfig)
-	logService := service.NewLogService(logRepo)
+	logRepo := repository.NewInstrumentedLogRepository(repository.NewLogRepository(esConfig))
+	// API searches and counts go through the query cache; alerting and
+	// anomaly detection always query Elasticsearch
+	serviceRepo := logRepo
+	if cfg.Search.Cache.Enabled {
+		serviceRepo = repository.NewCachedLogRepository(logRepo, querycache.New(cfg.Search.Cache.Cache()))
+	}
+	logService, err := service.NewLogService(serviceRepo, serviceOpts...)
+	if err != nil {
+		log.Fatalf("Failed to create log service: %v", err)
+	}
+	logService = service.NewTracedLogService(logService)
+
+	// Alerting rules, evaluated against the logs on their own schedule
+	alertRuleRepo := repository.NewAlertRuleRepository(esConfig)
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	if err := alertRuleRepo.EnsureIndex(ctx); err != nil {
+		log.Printf("Warning: failed to create alert rule index: %v", err)
+	}
+	cancel()
+
+	// Notifications for firing and resolved alerts, unless silenced
+	silenceRepo := repository.NewSilenceRepository(esConfig)
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	if err := silenceRepo.EnsureIndex(ctx); err != nil {
+		log.Printf("Warning: failed to create silence index: %v", err)
+	}
+	cancel()
+	dispatcher, err := notify.NewDispatcher(rules.Notify, silenceRepo)
+	if err != nil {
+		log.Fatalf("Failed to configure notifications: %v", err)
+	}
+	dispatcher.Start()
+
+	scheduler := alerting.NewScheduler(alertRuleRepo, logRepo, alerting.WithObserver(dispatcher.Observe))
+	scheduler.Start()
+	alertService := service.NewAlertService(alertRuleRepo, silenceRepo, scheduler, dispatcher, auditService)
+
+	// Log volume anomalies, notified through the alert channels. The
+	// tenants with quotas are evaluated besides the default one, which
+	// changes on reload.
+	anomalyRepo := repository.NewAnomalyRepository(esConfig)
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	if err := anomalyRepo.EnsureIndex(ctx); err != nil {
+		log.Printf("Warning: failed to create anomaly index: %v", err)
+	}
+	cancel()
+	var tenantIDs atomic.Pointer[[]string]
+	ids := knownTenants(rules.Tenants)
+	tenantIDs.Store(&ids)
+	var detector *anomaly.Detector
+	if an := cfg.Anomaly; an.Enabled {
+		detector = anomaly.NewDetector(anomaly.Config{
+			Interval:        an.Interval.Duration,
+			Season:          an.Season.Duration,
+			Periods:         an.Periods,
+			Delay:           an.Delay.Duration,
+			Threshold:       an.Threshold,
+			NotifyThreshold: an.NotifyThreshold,
+			MinVolume:       an.MinVolume,
+			MaxSeries:       an.MaxSeries,
+		}, logRepo, anomalyRepo,
+			anomaly.WithTenants(func() []string { return *tenantIDs.Load() }),
+			anomaly.WithObserver(dispatcher.Observe),
+		)
+		detector.Start()
+	}
+	var accessPolicy atomic.Pointer[access.Policy]
+	accessPolicy.Store(rules.AccessPolicy)
+	anomalyService := service.NewAnomalyService(anomalyRepo, accessPolicy.Load)
+	// Saved searches and their permalinks
+	savedSearchRepo := repository.NewSavedSearchRepository(esConfig)
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	if err := savedSearchRepo.EnsureIndex(ctx); err != nil {
+		log.Printf("Warning: failed to create saved search index: %v", err)
+	}
+	cancel()
+	savedSearchService := service.NewSavedSearchService(savedSearchRepo, auditService)
+
 	logHandler := handler.NewLogHandler(logService)
+	apiKeyService := service.NewAPIKeyService(repository.NewAPIKeyRepository(esConfig), auditService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
 
 	// Set up Gin router
 	r := gin.Default()
+	r.Use(metrics.Middleware())
+	r.Use(tracing.Middleware())
 
-	// CORS middleware
+	// CORS middleware; the origins are swapped on reload
+	var allowedOrigins atomic.Pointer[[]string]
+	origins := cfg.Server.CORSAllowedOrigins
+	allowedOrigins.Store(&origins)
 	r.Use(func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		origin := c.GetHeader("Origin")
+		for _, allowed := range *allowedOrigins.Load() {
+			if allowed == "*" || allowed == origin {
+				c.Writer.Header().Set("Access-Control-Allow-Origin", allowed)
+				break
+			}
+		}
+		c.Writer.Header().Add("Vary", "Origin")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, Cache-Control, traceparent, tracestate")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Cache-Status, Retry-After")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -47,24 +233,230 @@ func main() {
 		c.Next()
 	})
 
+	// Liveness and readiness probes, registered before authentication so
+	// probes never need credentials
+	checker := health.NewChecker(
+		healthPolicy(cfg.Health),
+		health.NewElasticsearchCheck(esConfig),
+		health.NewIndexCheck(esConfig),
+		health.NewQueueCheck("ingest_queue", logService.Pending, cfg.Health.QueueCapacity),
+	)
+	healthHandler := handler.NewHealthHandler(checker)
+	healthHandler.RegisterRoutes(r)
+
+	r.Use(handler.RequestInfo())
+	r.Use(querycache.Middleware())
+
+	// Prometheus metrics, like the probes, are served without credentials
+	r.GET("/metrics", metrics.Handler())
+
+	// Authentication
+	var authenticators []auth.Authenticator
+	if cfg.Auth.Enabled {
+		if key := cfg.Auth.AdminAPIKey; key != "" {
+			authenticators = append(authenticators, auth.NewStaticKey(key))
+		}
+		authenticators = append(authenticators, apiKeyService)
+
+		jwtAuth, err := newJWTAuthenticator(cfg.Auth.JWT)
+		if err != nil {
+			log.Fatalf("Failed to configure JWT authentication: %v", err)
+		}
+		if jwtAuth != nil {
+			authenticators = append(authenticators, jwtAuth)
+		}
+	} else {
+		log.Printf("Warning: authentication is disabled, the API is open to anyone")
+	}
+	r.Use(auth.Middleware(authenticators...))
+
 	// Register routes
 	logHandler.RegisterRoutes(r)
-
-	// Health check endpoint
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status": "ok",
-		})
-	})
+	apiKeyHandler.RegisterRoutes(r)
+	handler.NewAuthHandler().RegisterRoutes(r)
+	handler.NewAuditHandler(auditService).RegisterRoutes(r)
+	handler.NewAlertHandler(alertService).RegisterRoutes(r)
+	handler.NewSavedSearchHandler(savedSearchService).RegisterRoutes(r)
+	handler.NewAnomalyHandler(anomalyService).RegisterRoutes(r)
+	handler.NewRedactionHandler(redactions).RegisterRoutes(r)
+	healthHandler.RegisterAdminRoutes(r)
+	handler.NewMetricsHandler(logMetrics).RegisterRoutes(r)
 
 	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	srv := &http.Server{
+		Addr:              ":" + strconv.Itoa(cfg.Server.Port),
+		Handler:           r,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout.Duration,
+		IdleTimeout:       cfg.Server.IdleTimeout.Duration,
 	}
 
-	log.Printf("Server starting on port %s", port)
-	if err := r.Run(":" + port); err != nil {
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server starting on port %d", cfg.Server.Port)
+		serverErr <- srv.ListenAndServe()
+	}()
+
+	// Reload the settings that are safe to change on SIGHUP
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	go func() {
+		for range hangups {
+			reloadConfig(*configPath, cfg, auditService, func(next *config.Config, rules *config.Rules) {
+				origins := next.Server.CORSAllowedOrigins
+				allowedOrigins.Store(&origins)
+				if rules.Redactor != nil {
+					rules.Redactor.SetCounter(redactions)
+				}
+				ids := knownTenants(rules.Tenants)
+				tenantIDs.Store(&ids)
+				logService.Reload(service.Rules{
+					Pipelines: rules.Pipelines,
+					Redactor:  rules.Redactor,
+					Quotas:    rules.Tenants,
+					Policy:    rules.AccessPolicy,
+				})
+				accessPolicy.Store(rules.AccessPolicy)
+				logMetrics.Reload(rules.LogMetrics)
+				checker.SetPolicy(healthPolicy(next.Health))
+				if err := dispatcher.Reload(rules.Notify); err != nil {
+					log.Printf("Warning: keeping the running notification channels: %v", err)
+				}
+			})
+		}
+	}()
+
+	signals, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
+	select {
+	case err := <-serverErr:
 		log.Fatalf("Failed to start server: %v", err)
+	case <-signals.Done():
+	}
+	stopSignals()
+
+	// Shut down in dependency order: fail readiness so load balancers stop
+	// sending traffic, stop accepting connections and drain in-flight
+	// requests, flush buffered ingestion, then release Elasticsearch. A
+	// second signal skips straight to exit.
+	signal.Stop(hangups)
+	readinessDelay := cfg.Server.ShutdownReadinessDelay.Duration
+	timeout := cfg.Server.ShutdownTimeout.Duration
+	log.Printf("Shutting down: failing readiness for %s, then draining for up to %s", readinessDelay, timeout)
+
+	checker.Drain()
+	time.Sleep(readinessDelay)
+
+	ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Warning: requests still running at the shutdown deadline, closing connections: %v", err)
+		srv.Close()
+	}
+	if err := <-serverErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Warning: server stopped with error: %v", err)
+	}
+	log.Printf("HTTP server stopped")
+
+	scheduler.Close()
+	if detector != nil {
+		detector.Close()
+	}
+	dispatcher.Close(ctx)
+	log.Printf("Alerting stopped")
+
+	if err := logService.Close(); err != nil {
+		log.Printf("Warning: failed to flush buffered logs: %v", err)
+	}
+	log.Printf("Buffered logs flushed")
+
+	if err := shutdownTracing(ctx); err != nil {
+		log.Printf("Warning: failed to flush traces: %v", err)
+	}
+	esConfig.Close()
+	log.Printf("Shutdown complete")
+}
+
+// knownTenants returns the default tenant and the tenants with quotas.
+func knownTenants(quotas *tenant.Config) []string {
+	ids := []string{tenant.Default}
+	if quotas != nil {
+		for id := range quotas.Tenants {
+			if id != tenant.Default {
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Strings(ids[1:])
+	return ids
+}
+
+// healthPolicy builds the readiness policy from its settings.
+func healthPolicy(settings config.HealthSettings) health.Policy {
+	critical := make(map[string]bool, len(settings.CriticalChecks))
+	for _, name := range settings.CriticalChecks {
+		critical[name] = true
+	}
+	return health.Policy{Critical: critical, FailOnDegraded: settings.FailOnDegraded}
+}
+
+// reloadConfig re-reads the configuration and its rule files and hands them
+// to apply. An invalid configuration is logged and the running one kept.
+// Every attempt is recorded in the audit trail.
+func reloadConfig(path string, running *config.Config, audit service.AuditService, apply func(*config.Config, *config.Rules)) {
+	event := models.AuditEvent{Action: service.AuditConfigChange, Resource: "config", ResourceID: path}
+
+	next, err := config.Load(path)
+	if err == nil {
+		err = next.Validate()
+	}
+	var rules *config.Rules
+	if err == nil {
+		rules, err = next.LoadRules()
+	}
+	if err != nil {
+		log.Printf("Configuration reload failed, keeping the running configuration: %v", err)
+		audit.Record(context.Background(), event, err)
+		return
+	}
+
+	restartRequired := running.Reload(next)
+	apply(running, rules)
+
+	log.Printf("Configuration reloaded")
+	if len(restartRequired) > 0 {
+		log.Printf("Warning: changes to %s take effect after a restart", strings.Join(restartRequired, ", "))
+		event.Details = map[string]string{"restart_required": strings.Join(restartRequired, ",")}
 	}
+	audit.Record(context.Background(), event, nil)
+}
+
+// newJWTAuthenticator returns nil when no key set is configured.
+func newJWTAuthenticator(settings config.JWTSettings) (auth.Authenticator, error) {
+	var (
+		jwks *auth.JWKS
+		err  error
+	)
+	switch {
+	case settings.JWKSFile != "":
+		jwks, err = auth.NewJWKSFromFile(settings.JWKSFile)
+	case settings.JWKSURL != "":
+		jwks, err = auth.NewJWKSFromURL(settings.JWKSURL)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return auth.NewJWTAuthenticator(jwks, auth.JWTConfig{
+		Issuer:        settings.Issuer,
+		Audience:      settings.Audience,
+		RoleClaim:     settings.RoleClaim,
+		RoleMapping:   settings.RoleMapping,
+		DefaultRole:   settings.DefaultRole,
+		TenantClaim:   settings.TenantClaim,
+		DefaultTenant: settings.DefaultTenant,
+	})
 }
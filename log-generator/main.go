@@ -85,13 +85,22 @@ func generateRandomString(length int) string {
 	return string(b)
 }
 
-func sendLog(client *http.Client, backendURL string, log LogEntry) error {
+func sendLog(client *http.Client, backendURL, apiKey string, log LogEntry) error {
 	jsonData, err := json.Marshal(log)
 	if err != nil {
 		return fmt.Errorf("failed to marshal log: %v", err)
 	}
 
-	resp, err := client.Post(backendURL+"/api/logs", "application/json", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequest(http.MethodPost, backendURL+"/api/logs", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to build request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to send log: %v", err)
 	}
@@ -103,7 +112,8 @@ func sendLog(client *http.Client, backendURL string, log LogEntry) error {
 		return fmt.Errorf("failed to read response body: %v", err)
 	}
 
-	if resp.StatusCode != http.StatusCreated {
+	// 202 means the backend accepted the log but buffered or dropped it
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusAccepted {
 		return fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
 	}
 
@@ -118,6 +128,7 @@ func main() {
 		concurrent  = flag.Int("concurrent", 5, "Number of concurrent workers")
 		batchSize   = flag.Int("batch", 1, "Number of logs to send in each request")
 		showMetrics = flag.Bool("metrics", true, "Show metrics while running")
+		apiKey      = flag.String("api-key", os.Getenv("LOGANA_API_KEY"), "API key with the logs:write scope")
 	)
 	flag.Parse()
 
@@ -168,7 +179,7 @@ func main() {
 				batch = append(batch, log)
 
 				if len(batch) >= *batchSize {
-					if err := sendLog(client, *backendURL, batch[0]); err != nil {
+					if err := sendLog(client, *backendURL, *apiKey, batch[0]); err != nil {
 						fmt.Printf("\nWorker %d: Error sending batch: %v", workerID, err)
 					} else {
 						atomic.AddInt64(&successCount, 1)
@@ -179,7 +190,7 @@ func main() {
 
 			// Send remaining logs in batch
 			if len(batch) > 0 {
-				if err := sendLog(client, *backendURL, batch[0]); err != nil {
+				if err := sendLog(client, *backendURL, *apiKey, batch[0]); err != nil {
 					fmt.Printf("\nWorker %d: Error sending final batch: %v", workerID, err)
 				} else {
 					atomic.AddInt64(&successCount, 1)